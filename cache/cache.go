@@ -1,27 +1,304 @@
 package cache
 
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// entryOverhead is a rough estimate of the bytes used by an entry beyond its key and value
+const entryOverhead = 64
+
+// maxShards and others control how the cache is split up to avoid a global lock
+// limits are enforced per shard, so small limits use fewer shards to stay accurate
+const (
+	maxShards          = 16
+	minEntriesPerShard = 64
+	minBytesPerShard   = 64 * 1024
+)
+
 // Cache defines the cache implementaion for astrocache
+// keys are spread across shards that each have their own lock, so it is safe for concurrent use
 type Cache struct {
-	Values map[string]string
+	shards  []*shard
+	options *Options
+}
+
+// Options defines the limits of a cache
+// a MaxEntries or MaxBytes of 0 means there is no limit
+// eviction is local to each node, the chain remains the source of truth
+// if KeepValue is set, versioned values it returns false for are dropped and only their content type, version and expiry are kept
+type Options struct {
+	MaxEntries int
+	MaxBytes   int64
+	Policy     string
+	KeepValue  func(contentType string, val []byte) bool
 }
 
-// EmptyCache returns an empty cache
+// Stats describes the current state of a cache
+type Stats struct {
+	Entries    int    `json:"entries"`
+	Bytes      int64  `json:"bytes"`
+	MaxEntries int    `json:"maxEntries,omitempty"`
+	MaxBytes   int64  `json:"maxBytes,omitempty"`
+	Policy     string `json:"policy"`
+	Evictions  int64  `json:"evictions"`
+	Expired    int64  `json:"expired"`
+}
+
+// EmptyCache returns an empty, unbounded cache
 func EmptyCache() *Cache {
-	return &Cache{
-		Values: make(map[string]string),
+	cache, _ := NewCache(&Options{})
+
+	return cache
+}
+
+// NewCache returns an empty cache with the given limits
+func NewCache(options *Options) (*Cache, error) {
+	if options.Policy == "" {
+		options.Policy = PolicyLRU
+	}
+
+	numShards := shardCount(options)
+
+	cache := &Cache{
+		shards:  make([]*shard, numShards),
+		options: options,
+	}
+
+	for i := range cache.shards {
+		policy, err := newEvictionPolicy(options.Policy)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewCache failed to newEvictionPolicy")
+		}
+
+		cache.shards[i] = newShard(policy, divideLimit(int64(options.MaxEntries), numShards), divideLimit(options.MaxBytes, numShards))
+	}
+
+	return cache, nil
+}
+
+// Item is a value read from the cache along with its content type and version
+// Value is shared with the cache and must not be modified, it is nil if Dropped is set because Options.KeepValue didn't keep it
+type Item struct {
+	Value       []byte
+	ContentType string
+	Version     string
+	Dropped     bool
+}
+
+// SetValueForKey sets a value with no content type for a key that never expires
+// the cache keeps val, so it must not be modified after it is set
+func (c *Cache) SetValueForKey(val []byte, key string) {
+	c.SetVersionedValueForKey(val, "", key, "", time.Time{})
+}
+
+// SetVersionedValueForKey sets a value and its content type for a key along with its version, that expires at expiresAt
+// the cache keeps val, so it must not be modified after it is set
+func (c *Cache) SetVersionedValueForKey(val []byte, contentType, key, version string, expiresAt time.Time) {
+	e := &entry{
+		Value:       val,
+		ContentType: contentType,
+		Version:     version,
+		ExpiresAt:   expiresAt,
+	}
+
+	if c.options.KeepValue != nil && !c.options.KeepValue(contentType, val) {
+		e.Value = nil
+		e.Dropped = true
+	}
+
+	c.shardForKey(key).set(key, e)
+}
+
+// ValueForKey retreives a value for a key, expired values are treated as missing and returned as nil
+func (c *Cache) ValueForKey(key string) []byte {
+	item, ok := c.ItemForKey(key)
+	if !ok {
+		return nil
+	}
+
+	return item.Value
+}
+
+// ItemForKey retreives a value along with its content type and version for a key
+func (c *Cache) ItemForKey(key string) (*Item, bool) {
+	e, ok := c.shardForKey(key).get(key, time.Now())
+	if !ok {
+		return nil, false
+	}
+
+	return e.item(), true
+}
+
+// VersionForKey returns the version of a key as of a particular time, or "" if it does not exist
+// unlike ValueForKey, this remembers the version of keys that have been evicted
+// known is false if the key may have been evicted after its tombstone was dropped, so its version can't be told
+func (c *Cache) VersionForKey(key string, at time.Time) (version string, known bool) {
+	return c.shardForKey(key).version(key, at)
+}
+
+// ValueForKeyAt returns the value of a key and when it expires as of a particular time
+// a missing key has a nil value, ok is false if the key was evicted (or may have been) and its value is unknown
+func (c *Cache) ValueForKeyAt(key string, at time.Time) (val []byte, expiresAt time.Time, ok bool) {
+	item, expiresAt, ok := c.ItemForKeyAt(key, at)
+	if item == nil {
+		return nil, expiresAt, ok
+	}
+
+	return item.Value, expiresAt, ok
+}
+
+// ItemForKeyAt is the same as ValueForKeyAt, but returns the value's content type and version as well
+// a missing key has a nil item
+func (c *Cache) ItemForKeyAt(key string, at time.Time) (item *Item, expiresAt time.Time, ok bool) {
+	e, expiresAt, ok := c.shardForKey(key).lookup(key, at)
+	if e == nil {
+		return nil, expiresAt, ok
+	}
+
+	return e.item(), expiresAt, ok
+}
+
+// SetEvictedVersionForKey records a new version for a key whose value is unknown because it was evicted
+// the key stays missing, but version checks against it continue to agree with other nodes
+func (c *Cache) SetEvictedVersionForKey(key, version string, expiresAt time.Time) {
+	t := &tombstone{
+		Version:   version,
+		ExpiresAt: expiresAt,
+	}
+
+	c.shardForKey(key).setEvicted(key, t)
+}
+
+// ForgetKey removes a key along with its tombstone, after which this cache no longer knows its version
+// it is for when a node can't tell what happened to a key, so that it never disagrees with nodes that can
+func (c *Cache) ForgetKey(key string) {
+	c.shardForKey(key).forget(key)
+}
+
+// DeleteValueForKey removes a key and its value
+func (c *Cache) DeleteValueForKey(key string) {
+	c.shardForKey(key).delete(key)
+}
+
+// KeyValue is a key and its value returned from a scan, Value is shared with the cache and must not be modified
+type KeyValue struct {
+	Key         string
+	Value       []byte
+	ContentType string
+}
+
+// Scan returns up to limit entries whose keys start with prefix and sort after after, in key order
+// more is true if there are entries left after the last one returned, which can be passed as after to continue
+// each shard only keeps its first limit+1 matches while scanning, so sorting them is cheap however many keys there are
+func (c *Cache) Scan(prefix, after string, limit int) (entries []KeyValue, more bool) {
+	now := time.Now()
+
+	shardLimit := 0
+	if limit > 0 {
+		shardLimit = limit + 1
+	}
+
+	entries = []KeyValue{}
+	for _, s := range c.shards {
+		entries = append(entries, s.scan(prefix, after, shardLimit, now)...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	if limit > 0 && len(entries) > limit {
+		return entries[:limit], true
+	}
+
+	return entries, false
+}
+
+// Flush removes every entry from the cache and returns the number removed
+// keys are forgotten entirely, so they have no version afterwards either
+func (c *Cache) Flush() (int, error) {
+	removed := 0
+
+	for _, s := range c.shards {
+		policy, err := newEvictionPolicy(c.options.Policy)
+		if err != nil {
+			return removed, errors.Wrap(err, "Flush failed to newEvictionPolicy")
+		}
+
+		removed += s.flush(policy)
+	}
+
+	return removed, nil
+}
+
+// Sweep removes every entry that has expired as of now and returns the number removed
+func (c *Cache) Sweep(now time.Time) int {
+	removed := 0
+
+	for _, s := range c.shards {
+		removed += s.sweep(now)
+	}
+
+	return removed
+}
+
+// Stats returns a snapshot of the cache's stats
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		MaxEntries: c.options.MaxEntries,
+		MaxBytes:   c.options.MaxBytes,
+		Policy:     c.options.Policy,
+	}
+
+	for _, s := range c.shards {
+		shardStats := s.stats()
+
+		stats.Entries += shardStats.Entries
+		stats.Bytes += shardStats.Bytes
+		stats.Evictions += shardStats.Evictions
+		stats.Expired += shardStats.Expired
+	}
+
+	return stats
+}
+
+func (c *Cache) shardForKey(key string) *shard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
 	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
 }
 
-// SetValueForKey sets a value for a key
-func (c *Cache) SetValueForKey(val, key string) {
-	c.Values[key] = val
+func shardCount(options *Options) int {
+	count := maxShards
+
+	if options.MaxEntries > 0 && options.MaxEntries/minEntriesPerShard < count {
+		count = options.MaxEntries / minEntriesPerShard
+	}
+
+	if options.MaxBytes > 0 && int(options.MaxBytes/minBytesPerShard) < count {
+		count = int(options.MaxBytes / minBytesPerShard)
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	return count
 }
 
-// ValueForKey retreives a value for a key
-func (c *Cache) ValueForKey(key string) string {
-	if val, ok := c.Values[key]; ok {
-		return val
+func divideLimit(limit int64, numShards int) int64 {
+	if limit <= 0 {
+		return 0
 	}
 
-	return ""
+	return (limit + int64(numShards) - 1) / int64(numShards)
 }
@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// the action worker writes each key with a newer version as blocks are executed, while handlers check versions and read values
+func TestCacheConcurrentVersionedWrites(t *testing.T) {
+	const (
+		numKeys     = 64
+		numVersions = 200
+	)
+
+	c := EmptyCache()
+	done := make(chan bool)
+
+	var readers sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+
+		go func() {
+			defer readers.Done()
+
+			seen := make([]int, numKeys)
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				for k := 0; k < numKeys; k++ {
+					key := fmt.Sprintf("key-%d", k)
+
+					item, ok := c.ItemForKey(key)
+					if !ok {
+						continue
+					}
+
+					// the value and version are written together, so a reader never sees one without the other
+					version, err := strconv.Atoi(item.Version)
+					if err != nil || string(item.Value) != item.Version {
+						t.Errorf("expected key %q to hold its version %q as its value, got %q", key, item.Version, item.Value)
+						return
+					}
+
+					if version < seen[k] {
+						t.Errorf("expected key %q to only move forward, got version %d after %d", key, version, seen[k])
+						return
+					}
+
+					seen[k] = version
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+
+	// keys are split between the writers, so each key is written in order
+	for g := 0; g < 4; g++ {
+		writers.Add(1)
+
+		go func(g int) {
+			defer writers.Done()
+
+			for v := 1; v <= numVersions; v++ {
+				for k := g; k < numKeys; k += 4 {
+					version := strconv.Itoa(v)
+					c.SetVersionedValueForKey([]byte(version), "text/plain", fmt.Sprintf("key-%d", k), version, time.Time{})
+				}
+			}
+		}(g)
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	if stats := c.Stats(); stats.Entries != numKeys {
+		t.Errorf("expected %d entries, got %d", numKeys, stats.Entries)
+	}
+
+	for k := 0; k < numKeys; k++ {
+		if version, known := c.VersionForKey(fmt.Sprintf("key-%d", k), time.Now()); !known || version != strconv.Itoa(numVersions) {
+			t.Errorf("expected key-%d at version %d, got %q", k, numVersions, version)
+		}
+	}
+}
+
+// keys spread across shards are evicted independently, and every evicted key leaves a tombstone with its version
+func TestCacheConcurrentEviction(t *testing.T) {
+	const (
+		maxEntries = 256
+		perWriter  = 500
+	)
+
+	c, err := NewCache(&Options{MaxEntries: maxEntries, Policy: PolicyLFU})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < perWriter; i++ {
+				key := fmt.Sprintf("key-%d-%d", g, i)
+
+				c.SetVersionedValueForKey([]byte("value"), "", key, key, time.Time{})
+
+				// reading keys bumps their frequency under the same shard lock that evicts them
+				c.ValueForKey(fmt.Sprintf("key-%d-%d", g, i/2))
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	stats := c.Stats()
+
+	if limit := len(c.shards) * int(divideLimit(maxEntries, len(c.shards))); stats.Entries > limit {
+		t.Errorf("expected at most %d entries, got %d", limit, stats.Entries)
+	}
+
+	if total := stats.Entries + int(stats.Evictions); total != 4*perWriter {
+		t.Errorf("expected every key to be cached or evicted, got %d entries and %d evictions", stats.Entries, stats.Evictions)
+	}
+
+	for g := 0; g < 4; g++ {
+		for i := 0; i < perWriter; i++ {
+			key := fmt.Sprintf("key-%d-%d", g, i)
+
+			if version, known := c.VersionForKey(key, time.Now()); !known || version != key {
+				t.Fatalf("expected the version of %q to be known whether or not it was evicted, got %q", key, version)
+			}
+		}
+	}
+}
+
+// scans merge every shard while writes land in them, and must still return keys in order
+func TestCacheScanWhileWriting(t *testing.T) {
+	const numKeys = 1000
+
+	c := EmptyCache()
+	done := make(chan bool)
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < numKeys; i++ {
+			c.SetValueForKey([]byte("value"), fmt.Sprintf("a/%04d", i))
+			c.SetValueForKey([]byte("value"), fmt.Sprintf("b/%04d", i))
+		}
+	}()
+
+	scanning := true
+
+	for scanning {
+		select {
+		case <-done:
+			scanning = false
+		default:
+		}
+
+		after := ""
+
+		for {
+			entries, more := c.Scan("a/", after, 50)
+
+			for _, entry := range entries {
+				if entry.Key <= after || entry.Key[:2] != "a/" {
+					t.Fatalf("expected keys with prefix a/ in order after %q, got %q", after, entry.Key)
+				}
+
+				after = entry.Key
+			}
+
+			if !more {
+				break
+			}
+		}
+	}
+
+	entries, more := c.Scan("a/", "", 0)
+	if len(entries) != numKeys || more {
+		t.Errorf("expected all %d keys once writing is done, got %d", numKeys, len(entries))
+	}
+}
+
+// the sweep worker removes expired keys while the action worker keeps writing
+func TestCacheSweepWhileWriting(t *testing.T) {
+	const perWriter = 500
+
+	c := EmptyCache()
+	now := time.Now()
+	done := make(chan bool)
+
+	var sweeper sync.WaitGroup
+	sweeper.Add(1)
+
+	go func() {
+		defer sweeper.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			c.Sweep(now)
+		}
+	}()
+
+	var writers sync.WaitGroup
+
+	for g := 0; g < 4; g++ {
+		writers.Add(1)
+
+		go func(g int) {
+			defer writers.Done()
+
+			for i := 0; i < perWriter; i++ {
+				c.SetVersionedValueForKey([]byte("value"), "", fmt.Sprintf("expired-%d-%d", g, i), "v1", now.Add(-time.Second))
+				c.SetVersionedValueForKey([]byte("value"), "", fmt.Sprintf("kept-%d-%d", g, i), "v1", now.Add(time.Hour))
+			}
+		}(g)
+	}
+
+	writers.Wait()
+	close(done)
+	sweeper.Wait()
+
+	c.Sweep(now)
+
+	stats := c.Stats()
+
+	if stats.Entries != 4*perWriter {
+		t.Errorf("expected only the %d keys that haven't expired to be left, got %d", 4*perWriter, stats.Entries)
+	}
+
+	if stats.Expired != 4*perWriter {
+		t.Errorf("expected %d keys to be swept, got %d", 4*perWriter, stats.Expired)
+	}
+}
+
+func TestCacheScan(t *testing.T) {
+	c := EmptyCache()
+
+	for i := 0; i < 300; i++ {
+		c.SetValueForKey([]byte("value"), fmt.Sprintf("a/%03d", i))
+		c.SetValueForKey([]byte("value"), fmt.Sprintf("b/%03d", i))
+	}
+
+	after := ""
+	seen := 0
+
+	for {
+		entries, more := c.Scan("a/", after, 40)
+
+		for _, entry := range entries {
+			if expected := fmt.Sprintf("a/%03d", seen); entry.Key != expected {
+				t.Fatalf("expected key %q, got %q", expected, entry.Key)
+			}
+
+			seen++
+		}
+
+		if !more {
+			break
+		}
+
+		after = entries[len(entries)-1].Key
+	}
+
+	if seen != 300 {
+		t.Errorf("expected to scan 300 keys, got %d", seen)
+	}
+}
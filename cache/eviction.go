@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand"
+)
+
+// PolicyLRU and others are the supported eviction policies
+const (
+	PolicyLRU    = "lru"
+	PolicyLFU    = "lfu"
+	PolicyRandom = "random"
+)
+
+// evictionPolicy tracks keys in the cache and decides which one should be evicted next
+// it is only ever used while its shard's lock is held
+type evictionPolicy interface {
+	added(key string)
+	accessed(key string)
+	removed(key string)
+	victim() (string, bool)
+}
+
+func newEvictionPolicy(name string) (evictionPolicy, error) {
+	switch name {
+	case PolicyLRU, "":
+		return newLRUPolicy(), nil
+	case PolicyLFU:
+		return newLFUPolicy(), nil
+	case PolicyRandom:
+		return newRandomPolicy(), nil
+	}
+
+	return nil, fmt.Errorf("newEvictionPolicy got unknown policy %q", name)
+}
+
+// lruPolicy evicts the least recently used key
+// the front of order is the most recently used
+type lruPolicy struct {
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newLRUPolicy() *lruPolicy {
+	return &lruPolicy{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (lru *lruPolicy) added(key string) {
+	if elem, ok := lru.elements[key]; ok {
+		lru.order.MoveToFront(elem)
+		return
+	}
+
+	lru.elements[key] = lru.order.PushFront(key)
+}
+
+func (lru *lruPolicy) accessed(key string) {
+	if elem, ok := lru.elements[key]; ok {
+		lru.order.MoveToFront(elem)
+	}
+}
+
+func (lru *lruPolicy) removed(key string) {
+	if elem, ok := lru.elements[key]; ok {
+		lru.order.Remove(elem)
+		delete(lru.elements, key)
+	}
+}
+
+func (lru *lruPolicy) victim() (string, bool) {
+	back := lru.order.Back()
+	if back == nil {
+		return "", false
+	}
+
+	return back.Value.(string), true
+}
+
+// lfuPolicy evicts the least frequently used key, breaking ties by least recently used
+// buckets is ordered by ascending frequency, and each bucket's keys are ordered most recently used first
+type lfuPolicy struct {
+	buckets *list.List
+	nodes   map[string]*lfuNode
+}
+
+type lfuBucket struct {
+	freq int
+	keys *list.List
+}
+
+type lfuNode struct {
+	bucket *list.Element
+	elem   *list.Element
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{
+		buckets: list.New(),
+		nodes:   make(map[string]*lfuNode),
+	}
+}
+
+func (lfu *lfuPolicy) added(key string) {
+	if _, ok := lfu.nodes[key]; ok {
+		lfu.accessed(key)
+		return
+	}
+
+	first := lfu.buckets.Front()
+	if first == nil || first.Value.(*lfuBucket).freq != 1 {
+		first = lfu.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+
+	lfu.nodes[key] = &lfuNode{
+		bucket: first,
+		elem:   first.Value.(*lfuBucket).keys.PushFront(key),
+	}
+}
+
+func (lfu *lfuPolicy) accessed(key string) {
+	node, ok := lfu.nodes[key]
+	if !ok {
+		return
+	}
+
+	current := node.bucket.Value.(*lfuBucket)
+
+	next := node.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != current.freq+1 {
+		next = lfu.buckets.InsertAfter(&lfuBucket{freq: current.freq + 1, keys: list.New()}, node.bucket)
+	}
+
+	current.keys.Remove(node.elem)
+	if current.keys.Len() == 0 {
+		lfu.buckets.Remove(node.bucket)
+	}
+
+	node.bucket = next
+	node.elem = next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (lfu *lfuPolicy) removed(key string) {
+	node, ok := lfu.nodes[key]
+	if !ok {
+		return
+	}
+
+	bucket := node.bucket.Value.(*lfuBucket)
+	bucket.keys.Remove(node.elem)
+	if bucket.keys.Len() == 0 {
+		lfu.buckets.Remove(node.bucket)
+	}
+
+	delete(lfu.nodes, key)
+}
+
+func (lfu *lfuPolicy) victim() (string, bool) {
+	first := lfu.buckets.Front()
+	if first == nil {
+		return "", false
+	}
+
+	return first.Value.(*lfuBucket).keys.Back().Value.(string), true
+}
+
+// randomPolicy evicts a key chosen at random
+type randomPolicy struct {
+	keys    []string
+	indexes map[string]int
+}
+
+func newRandomPolicy() *randomPolicy {
+	return &randomPolicy{
+		keys:    []string{},
+		indexes: make(map[string]int),
+	}
+}
+
+func (rp *randomPolicy) added(key string) {
+	if _, ok := rp.indexes[key]; ok {
+		return
+	}
+
+	rp.indexes[key] = len(rp.keys)
+	rp.keys = append(rp.keys, key)
+}
+
+func (rp *randomPolicy) accessed(key string) {}
+
+func (rp *randomPolicy) removed(key string) {
+	index, ok := rp.indexes[key]
+	if !ok {
+		return
+	}
+
+	// swap the last key into the removed key's place
+	last := len(rp.keys) - 1
+	rp.keys[index] = rp.keys[last]
+	rp.indexes[rp.keys[index]] = index
+	rp.keys = rp.keys[:last]
+
+	delete(rp.indexes, key)
+}
+
+func (rp *randomPolicy) victim() (string, bool) {
+	if len(rp.keys) == 0 {
+		return "", false
+	}
+
+	return rp.keys[rand.Intn(len(rp.keys))], true
+}
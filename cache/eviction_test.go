@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUPolicyVictim(t *testing.T) {
+	lru := newLRUPolicy()
+
+	lru.added("a")
+	lru.added("b")
+	lru.added("c")
+	lru.accessed("a")
+
+	if victim, _ := lru.victim(); victim != "b" {
+		t.Errorf("expected victim %q, got %q", "b", victim)
+	}
+
+	lru.removed("b")
+
+	if victim, _ := lru.victim(); victim != "c" {
+		t.Errorf("expected victim %q, got %q", "c", victim)
+	}
+
+	lru.removed("c")
+	lru.removed("a")
+
+	if _, ok := lru.victim(); ok {
+		t.Error("expected no victim once every key is removed")
+	}
+}
+
+func TestLFUPolicyVictim(t *testing.T) {
+	lfu := newLFUPolicy()
+
+	lfu.added("a")
+	lfu.added("b")
+	lfu.added("c")
+
+	lfu.accessed("a")
+	lfu.accessed("a")
+	lfu.accessed("c")
+
+	// b is used least, then c, then a
+	if victim, _ := lfu.victim(); victim != "b" {
+		t.Errorf("expected victim %q, got %q", "b", victim)
+	}
+
+	lfu.removed("b")
+
+	if victim, _ := lfu.victim(); victim != "c" {
+		t.Errorf("expected victim %q, got %q", "c", victim)
+	}
+
+	// d and c are now used as often as each other, so the one used longest ago goes first
+	lfu.added("d")
+	lfu.accessed("d")
+
+	if victim, _ := lfu.victim(); victim != "c" {
+		t.Errorf("expected victim %q on a tie, got %q", "c", victim)
+	}
+
+	lfu.removed("c")
+	lfu.removed("d")
+	lfu.removed("a")
+
+	if _, ok := lfu.victim(); ok {
+		t.Error("expected no victim once every key is removed")
+	}
+
+	if lfu.buckets.Len() != 0 {
+		t.Errorf("expected empty buckets to be removed, got %d", lfu.buckets.Len())
+	}
+}
+
+func TestRandomPolicyVictim(t *testing.T) {
+	rp := newRandomPolicy()
+
+	for i := 0; i < 10; i++ {
+		rp.added(fmt.Sprintf("key-%d", i))
+	}
+
+	rp.added("key-0")
+
+	for i := 0; i < 10; i += 2 {
+		rp.removed(fmt.Sprintf("key-%d", i))
+	}
+
+	if len(rp.keys) != 5 {
+		t.Fatalf("expected 5 keys, got %d", len(rp.keys))
+	}
+
+	for key, index := range rp.indexes {
+		if rp.keys[index] != key {
+			t.Errorf("expected key %q at index %d, got %q", key, index, rp.keys[index])
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		victim, ok := rp.victim()
+		if !ok {
+			t.Fatal("expected a victim")
+		}
+
+		if _, ok := rp.indexes[victim]; !ok {
+			t.Errorf("expected victim %q to be a key that wasn't removed", victim)
+		}
+	}
+}
+
+func TestCacheEvictsByPolicy(t *testing.T) {
+	cases := []struct {
+		policy  string
+		evicted string
+	}{
+		{PolicyLRU, "b"},
+		{PolicyLFU, "c"},
+	}
+
+	for _, c := range cases {
+		cache, err := NewCache(&Options{MaxEntries: 3, Policy: c.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cache.SetVersionedValueForKey([]byte("value"), "", "a", "v1", time.Time{})
+		cache.SetVersionedValueForKey([]byte("value"), "", "b", "v1", time.Time{})
+		cache.SetVersionedValueForKey([]byte("value"), "", "c", "v1", time.Time{})
+
+		// b was used longest ago, c was used least
+		cache.ValueForKey("b")
+		cache.ValueForKey("b")
+		cache.ValueForKey("a")
+		cache.ValueForKey("a")
+		cache.ValueForKey("c")
+
+		cache.SetVersionedValueForKey([]byte("value"), "", "d", "v2", time.Time{})
+
+		if _, ok := cache.ItemForKey(c.evicted); ok {
+			t.Errorf("%s: expected %q to be evicted", c.policy, c.evicted)
+		}
+
+		if _, ok := cache.ItemForKey("d"); !ok {
+			t.Errorf("%s: expected the key just set not to be evicted", c.policy)
+		}
+
+		if version, known := cache.VersionForKey(c.evicted, time.Now()); !known || version != "v1" {
+			t.Errorf("%s: expected the evicted key's version %q to still be known, got %q (known %t)", c.policy, "v1", version, known)
+		}
+
+		if stats := cache.Stats(); stats.Entries != 3 || stats.Evictions != 1 {
+			t.Errorf("%s: expected 3 entries and 1 eviction, got %d and %d", c.policy, stats.Entries, stats.Evictions)
+		}
+	}
+}
+
+func TestCacheEvictsByBytes(t *testing.T) {
+	c, err := NewCache(&Options{MaxBytes: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := make([]byte, 200)
+
+	for i := 0; i < 10; i++ {
+		c.SetVersionedValueForKey(value, "", fmt.Sprintf("key-%d", i), "v1", time.Time{})
+	}
+
+	stats := c.Stats()
+
+	if stats.Bytes > 1024 {
+		t.Errorf("expected at most 1024 bytes, got %d", stats.Bytes)
+	}
+
+	if stats.Entries+int(stats.Evictions) != 10 {
+		t.Errorf("expected every key to be either cached or evicted, got %d entries and %d evictions", stats.Entries, stats.Evictions)
+	}
+
+	if _, ok := c.ItemForKey("key-9"); !ok {
+		t.Error("expected the last key set to be cached")
+	}
+}
+
+func TestNewCacheUnknownPolicy(t *testing.T) {
+	if _, err := NewCache(&Options{MaxEntries: 10, Policy: "fifo"}); err == nil {
+		t.Error("expected an unknown policy to fail")
+	}
+}
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"container/heap"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shard is an independently locked portion of the cache
+// evicted remembers the version of keys that were evicted locally, so that version checks
+// give the same answer on every node regardless of what each one has evicted
+// it is bounded, and once it has dropped a tombstone the shard can no longer tell a missing key from an evicted one
+type shard struct {
+	entries    map[string]*entry
+	evicted    *tombstoneSet
+	policy     evictionPolicy
+	maxEntries int64
+	maxBytes   int64
+	bytes      int64
+	evictions  int64
+	expired    int64
+	lock       sync.Mutex
+}
+
+// entry is a single value in the cache
+// Version is the ID of the block that last wrote the value, followed by the index of the write if it was one of a batch
+// a zero ExpiresAt means the entry never expires
+type entry struct {
+	Value       []byte
+	ContentType string
+	Version     string
+	ExpiresAt   time.Time
+	Dropped     bool
+}
+
+func (e *entry) item() *Item {
+	return &Item{
+		Value:       e.Value,
+		ContentType: e.ContentType,
+		Version:     e.Version,
+		Dropped:     e.Dropped,
+	}
+}
+
+func (e *entry) isExpired(now time.Time) bool {
+	return isExpired(e.ExpiresAt, now)
+}
+
+func isExpired(expiresAt, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+func entrySize(key string, e *entry) int64 {
+	return int64(len(key) + len(e.Value) + len(e.ContentType) + len(e.Version) + entryOverhead)
+}
+
+func newShard(policy evictionPolicy, maxEntries, maxBytes int64) *shard {
+	return &shard{
+		entries:    make(map[string]*entry),
+		evicted:    newTombstoneSet(maxTombstonesPerShard),
+		policy:     policy,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (s *shard) set(key string, e *entry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeEntry(key)
+	s.evicted.remove(key)
+
+	s.entries[key] = e
+	s.bytes += entrySize(key, e)
+	s.policy.added(key)
+
+	s.evictIfNeeded(key)
+}
+
+func (s *shard) get(key string, now time.Time) (*entry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.isExpired(now) {
+		return nil, false
+	}
+
+	s.policy.accessed(key)
+
+	return e, true
+}
+
+// version returns the version of key as of now, known is false if the shard can't tell whether the key exists
+func (s *shard) version(key string, now time.Time) (version string, known bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		if e.isExpired(now) {
+			return "", true
+		}
+
+		return e.Version, true
+	}
+
+	if t, ok := s.evicted.get(key); ok {
+		if isExpired(t.ExpiresAt, now) {
+			return "", true
+		}
+
+		return t.Version, true
+	}
+
+	return "", !s.evicted.forgot
+}
+
+func (s *shard) lookup(key string, at time.Time) (*entry, time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		if e.isExpired(at) {
+			return nil, time.Time{}, true
+		}
+
+		return e, e.ExpiresAt, true
+	}
+
+	if t, ok := s.evicted.get(key); ok && !isExpired(t.ExpiresAt, at) {
+		return nil, t.ExpiresAt, false
+	}
+
+	return nil, time.Time{}, !s.evicted.forgot
+}
+
+func (s *shard) setEvicted(key string, t *tombstone) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeEntry(key)
+	s.evicted.add(key, t)
+}
+
+// scan returns the first limit live entries with prefix whose key sorts after after, in no particular order and without counting as an access
+// a limit of 0 returns every one of them
+func (s *shard) scan(prefix, after string, limit int, now time.Time) []KeyValue {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	found := &keyHeap{}
+
+	for key, e := range s.entries {
+		if key <= after || !strings.HasPrefix(key, prefix) || e.isExpired(now) {
+			continue
+		}
+
+		// the heap's root is the last key found so far, so anything after it can't make the cut
+		if limit > 0 && found.Len() == limit && key > (*found)[0].Key {
+			continue
+		}
+
+		heap.Push(found, KeyValue{Key: key, Value: e.Value, ContentType: e.ContentType})
+
+		if limit > 0 && found.Len() > limit {
+			heap.Pop(found)
+		}
+	}
+
+	return *found
+}
+
+// keyHeap is a heap of entries with the last key at its root
+type keyHeap []KeyValue
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i].Key > h[j].Key }
+func (h keyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *keyHeap) Push(x interface{}) {
+	*h = append(*h, x.(KeyValue))
+}
+
+func (h *keyHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return last
+}
+
+func (s *shard) delete(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeEntry(key)
+	s.evicted.remove(key)
+}
+
+func (s *shard) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeEntry(key)
+	s.evicted.remove(key)
+	s.evicted.forgot = true
+}
+
+// flush removes every entry and tombstone, replacing the eviction policy with an empty one
+func (s *shard) flush(policy evictionPolicy) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	removed := len(s.entries)
+
+	s.entries = make(map[string]*entry)
+	s.evicted = newTombstoneSet(maxTombstonesPerShard)
+	s.policy = policy
+	s.bytes = 0
+
+	return removed
+}
+
+func (s *shard) sweep(now time.Time) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	removed := 0
+
+	for key, e := range s.entries {
+		if e.isExpired(now) {
+			s.removeEntry(key)
+			removed++
+		}
+	}
+
+	s.evicted.sweep(now)
+
+	s.expired += int64(removed)
+
+	return removed
+}
+
+func (s *shard) stats() Stats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := Stats{
+		Entries:   len(s.entries),
+		Bytes:     s.bytes,
+		Evictions: s.evictions,
+		Expired:   s.expired,
+	}
+
+	return stats
+}
+
+// removeEntry removes an entry if it exists, the lock must be held
+func (s *shard) removeEntry(key string) {
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(s.entries, key)
+	s.bytes -= entrySize(key, e)
+	s.policy.removed(key)
+}
+
+// evictIfNeeded evicts entries until the shard is within its limits, the lock must be held
+// the key that was just set is never evicted
+func (s *shard) evictIfNeeded(newKey string) {
+	for s.isOverLimit() {
+		victim, ok := s.policy.victim()
+		if ok && victim == newKey {
+			// look past the new key by taking it out of the running while choosing
+			s.policy.removed(newKey)
+			victim, ok = s.policy.victim()
+			s.policy.added(newKey)
+		}
+
+		if !ok || victim == newKey {
+			return
+		}
+
+		e := s.entries[victim]
+
+		s.removeEntry(victim)
+		s.evictions++
+
+		if e.Version != "" {
+			s.evicted.add(victim, &tombstone{
+				Version:   e.Version,
+				ExpiresAt: e.ExpiresAt,
+			})
+		}
+	}
+}
+
+func (s *shard) isOverLimit() bool {
+	if s.maxEntries > 0 && int64(len(s.entries)) > s.maxEntries {
+		return true
+	}
+
+	if s.maxBytes > 0 && s.bytes > s.maxBytes {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// maxTombstonesPerShard limits how many evicted keys a shard remembers the version of
+const maxTombstonesPerShard = 16 * 1024
+
+// tombstone is what remains of an evicted entry
+type tombstone struct {
+	Version   string
+	ExpiresAt time.Time
+}
+
+// tombstoneSet holds a shard's tombstones, dropping the oldest once it is full
+// after dropping one, keys that aren't in the set may have been evicted, so forgot is set until the shard is flushed
+// it is only ever used while its shard's lock is held
+type tombstoneSet struct {
+	order    *list.List
+	elements map[string]*list.Element
+	max      int
+	forgot   bool
+}
+
+type tombstoneElement struct {
+	key       string
+	tombstone *tombstone
+}
+
+func newTombstoneSet(max int) *tombstoneSet {
+	return &tombstoneSet{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+		max:      max,
+	}
+}
+
+func (ts *tombstoneSet) get(key string) (*tombstone, bool) {
+	elem, ok := ts.elements[key]
+	if !ok {
+		return nil, false
+	}
+
+	return elem.Value.(*tombstoneElement).tombstone, true
+}
+
+func (ts *tombstoneSet) add(key string, t *tombstone) {
+	ts.remove(key)
+
+	ts.elements[key] = ts.order.PushFront(&tombstoneElement{key: key, tombstone: t})
+
+	for ts.max > 0 && ts.order.Len() > ts.max {
+		ts.remove(ts.order.Back().Value.(*tombstoneElement).key)
+		ts.forgot = true
+	}
+}
+
+func (ts *tombstoneSet) remove(key string) {
+	if elem, ok := ts.elements[key]; ok {
+		ts.order.Remove(elem)
+		delete(ts.elements, key)
+	}
+}
+
+// sweep removes tombstones that have expired as of now
+func (ts *tombstoneSet) sweep(now time.Time) {
+	for key, elem := range ts.elements {
+		if isExpired(elem.Value.(*tombstoneElement).tombstone.ExpiresAt, now) {
+			ts.remove(key)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"sync"
+	"time"
+
+	"github.com/astromechio/astrocache/model/blockchain"
+)
+
+// Applied tracks how many blocks have had their actions executed, and the ID of the last one
+// the action worker holds the lock while executing a block, so anything read inside View sees the cache at a single chain height
+// blocks are executed in chain order, so the block at index i in the chain has been executed once height is past i
+type Applied struct {
+	height  int
+	blockID string
+	changed chan struct{}
+	lock    sync.RWMutex
+}
+
+// Apply calls apply and then records the block as executed, with nothing able to View in between
+func (a *Applied) Apply(blockID string, apply func()) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	apply()
+
+	a.height++
+	a.blockID = blockID
+
+	// wake up anything waiting for a block
+	if a.changed != nil {
+		close(a.changed)
+		a.changed = nil
+	}
+}
+
+// View calls view with the current height and last block ID, while no block is able to be applied
+func (a *Applied) View(view func(height int, blockID string)) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	view(a.height, a.blockID)
+}
+
+// Current returns the current height and last block ID
+// nothing stops a block being applied right after, use View to read the cache at that height
+func (a *Applied) Current() (height int, blockID string) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.height, a.blockID
+}
+
+// WaitFor waits until the block with blockID has been applied, returning false if it isn't applied within timeout
+// the block is found in chain, which it is committed to before it is applied
+func (a *Applied) WaitFor(chain *blockchain.Chain, blockID string, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for true {
+		changed, applied := a.waitChan(chain, blockID)
+		if applied {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return false
+		}
+	}
+
+	return false
+}
+
+// waitChan returns whether blockID has been applied, and if not a chan that is closed when the next block is
+// the chan is taken before looking at the chain, so a block applied in between still closes it
+func (a *Applied) waitChan(chain *blockchain.Chain, blockID string) (chan struct{}, bool) {
+	a.lock.Lock()
+
+	if a.changed == nil {
+		a.changed = make(chan struct{})
+	}
+
+	changed := a.changed
+
+	a.lock.Unlock()
+
+	index := chain.IndexOfBlock(blockID)
+	if height, _ := a.Current(); index >= 0 && index < height {
+		return nil, true
+	}
+
+	return changed, false
+}
+
+// Clock is the time as of which the actions in applied blocks are executed, which is the same on every node
+// actions are created with a timestamp on whichever verifier received the write, so they can reach the chain out of order,
+// and each one is executed no earlier than the one before it so that this time only moves forward
+type Clock struct {
+	now  int64
+	lock sync.Mutex
+}
+
+// Advance returns the time an action created at timestamp (in unix nanoseconds) is executed as of, and moves the clock forward to it
+func (c *Clock) Advance(timestamp int64) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if timestamp > c.now {
+		c.now = timestamp
+	}
+
+	return c.now
+}
+
+// Now returns the time the last timed action was executed as of, or the zero time if none has been
+// no action applied after this is executed as of an earlier time, so anything that expired before it can be swept
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.now == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, c.now)
+}
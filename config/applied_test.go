@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockOnlyMovesForward(t *testing.T) {
+	c := &Clock{}
+
+	if !c.Now().IsZero() {
+		t.Errorf("expected a new clock to be at the zero time, got %s", c.Now())
+	}
+
+	if at := c.Advance(10); at != 10 {
+		t.Errorf("expected an action at 10 to be executed at 10, got %d", at)
+	}
+
+	if at := c.Advance(5); at != 10 {
+		t.Errorf("expected an action created at 5 after one at 10 to be executed at 10, got %d", at)
+	}
+
+	if at := c.Advance(20); at != 20 {
+		t.Errorf("expected an action at 20 to be executed at 20, got %d", at)
+	}
+
+	if !c.Now().Equal(time.Unix(0, 20)) {
+		t.Errorf("expected the clock to be at 20, got %d", c.Now().UnixNano())
+	}
+}
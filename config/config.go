@@ -2,6 +2,7 @@ package config
 
 import (
 	"math/rand"
+	"sync"
 
 	"github.com/astromechio/astrocache/cache"
 	acrypto "github.com/astromechio/astrocache/crypto"
@@ -22,6 +23,36 @@ type App struct {
 	Cache    *cache.Cache
 	NodeList *NodeList
 	Values   map[string]string
+	Applied  Applied
+	Clock    Clock
+	DataDir  *DataDir
+
+	Namespaces NamespaceList
+	Watchers   Watchers
+	Election   Election
+	Liveness   Liveness
+	Membership Membership
+}
+
+// IsMaster returns true if this node is currently the master
+func (a *App) IsMaster() bool {
+	master := a.NodeList.CurrentMaster()
+
+	return master != nil && master.NID == a.Self.NID
+}
+
+// CacheForNamespace returns the cache for a namespace, or the default cache if ns is empty
+func (a *App) CacheForNamespace(ns string) (*cache.Cache, error) {
+	if ns == "" {
+		return a.Cache, nil
+	}
+
+	namespace := a.Namespaces.Get(ns)
+	if namespace == nil {
+		return nil, ErrNoNamespace
+	}
+
+	return namespace.Cache, nil
 }
 
 // SetValueForKey sets a value for a key
@@ -38,19 +69,46 @@ func (a *App) ValueForKey(key string) string {
 	return a.Values[key]
 }
 
+// AuthorizeNamespace returns the cache for a namespace if token grants access to it
+func (a *App) AuthorizeNamespace(ns, token string) (*cache.Cache, error) {
+	if ns == "" {
+		return a.Cache, nil
+	}
+
+	namespace := a.Namespaces.Get(ns)
+	if namespace == nil {
+		return nil, ErrNoNamespace
+	}
+
+	if err := namespace.Authorize(token); err != nil {
+		return nil, err
+	}
+
+	return namespace.Cache, nil
+}
+
 // NodeList defines the nodes a master looks after
+// Master, Verifiers and Workers change as blocks are executed, so they should only be accessed through its methods once a node is running
+// on a worker, Verifiers only holds the verifier it is assigned to, whose NID is parentNID
 type NodeList struct {
-	Master    *model.Node
-	Verifiers []*model.Node
-	Workers   []*model.Node
+	Master     *model.Node
+	Verifiers  []*model.Node
+	Workers    []*model.Node
+	parentNID  string
+	primaryNID string
+	removed    map[string]bool
+	lock       sync.RWMutex
 }
 
 // WorkersForVerifierWithNID returns the worker nodes assigned to a verifier node with NID
 func (nl *NodeList) WorkersForVerifierWithNID(nid string) []*model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
 	workers := []*model.Node{}
 
 	// if we are the primary verifier, we need to distribute blocks to the master
-	if nl.Master.ParentNID != "" {
+	if nl.Master != nil && nl.Master.ParentNID != "" && nl.Master.ParentNID == nid {
 		workers = append(workers, nl.Master)
 	}
 
@@ -63,17 +121,81 @@ func (nl *NodeList) WorkersForVerifierWithNID(nid string) []*model.Node {
 	return workers
 }
 
+// CurrentMaster returns the node that is currently the master
+// it is nil on a verifier that was master when it went down, until a master is elected
+func (nl *NodeList) CurrentMaster() *model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	return nl.Master
+}
+
+// SetMaster replaces the master, such as when a new one is elected
+func (nl *NodeList) SetMaster(master *model.Node) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	nl.Master = master
+}
+
 // AddVerifier adds a verifier to the nodeList
 func (nl *NodeList) AddVerifier(verifier *model.Node) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
 	if nl.Verifiers == nil {
 		nl.Verifiers = []*model.Node{}
 	}
 
+	if nl.primaryNID == "" {
+		nl.primaryNID = verifier.NID
+	}
+
 	nl.Verifiers = append(nl.Verifiers, verifier)
 }
 
+// PrimaryNID returns the NID of the verifier distributing blocks to the original master
+// it is the first verifier added to the network until the master is reassigned to another one
+// it doesn't change when that verifier is removed, so that no other verifier is told it is primary when it joins again
+func (nl *NodeList) PrimaryNID() string {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	return nl.primaryNID
+}
+
+// ReassignMaster makes the verifier with parentNID distribute blocks to the master, such as when the primary verifier is removed
+// the master is replaced with a copy rather than changed in place, since on the master itself it is also app.Self
+func (nl *NodeList) ReassignMaster(parentNID string) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	if nl.Master != nil {
+		master := *nl.Master
+		master.ParentNID = parentNID
+
+		nl.Master = &master
+	}
+
+	nl.primaryNID = parentNID
+}
+
+// AllVerifiers returns a copy of the verifiers in the nodeList
+func (nl *NodeList) AllVerifiers() []*model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	verifiers := make([]*model.Node, len(nl.Verifiers))
+	copy(verifiers, nl.Verifiers)
+
+	return verifiers
+}
+
 // RandomVerifier returns a random verifier node from the NodeList
 func (nl *NodeList) RandomVerifier() *model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
 	if len(nl.Verifiers) == 0 {
 		return nil
 	} else if len(nl.Verifiers) == 1 {
@@ -85,11 +207,153 @@ func (nl *NodeList) RandomVerifier() *model.Node {
 	return nl.Verifiers[index]
 }
 
+// VerifierWithNID returns the verifier with NID, or nil if it isn't in the nodeList
+func (nl *NodeList) VerifierWithNID(nid string) *model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	for i, v := range nl.Verifiers {
+		if v.NID == nid {
+			return nl.Verifiers[i]
+		}
+	}
+
+	return nil
+}
+
 // AddWorker adds a worker to the nodeList
 func (nl *NodeList) AddWorker(worker *model.Node) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
 	if nl.Workers == nil {
 		nl.Workers = []*model.Node{}
 	}
 
 	nl.Workers = append(nl.Workers, worker)
 }
+
+// AllWorkers returns a copy of the workers in the nodeList
+func (nl *NodeList) AllWorkers() []*model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	workers := make([]*model.Node, len(nl.Workers))
+	copy(workers, nl.Workers)
+
+	return workers
+}
+
+// NodeWithNID returns the verifier or worker with NID, or nil if it isn't in the nodeList
+func (nl *NodeList) NodeWithNID(nid string) *model.Node {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	for i, v := range nl.Verifiers {
+		if v.NID == nid {
+			return nl.Verifiers[i]
+		}
+	}
+
+	for i, w := range nl.Workers {
+		if w.NID == nid {
+			return nl.Workers[i]
+		}
+	}
+
+	return nil
+}
+
+// ParentNID returns the NID of the verifier a worker is assigned to
+func (nl *NodeList) ParentNID() string {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	return nl.parentNID
+}
+
+// SetParent assigns a worker to verifier, replacing the verifier it was assigned to before
+func (nl *NodeList) SetParent(verifier *model.Node) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	if nl.parentNID != "" {
+		nl.removeNode(nl.parentNID)
+	}
+
+	nl.Verifiers = append(nl.Verifiers, verifier)
+	nl.parentNID = verifier.NID
+}
+
+// RemoveNode removes the verifier or worker with NID from the nodeList, returning it or nil if it wasn't there
+func (nl *NodeList) RemoveNode(nid string) *model.Node {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	return nl.removeNode(nid)
+}
+
+// Forbid records that the node with NID and the keyPair with fingerprint were removed from the network, so neither is ever let back in
+// the fingerprint is used rather than the KID, since a node picks its own KID and could rejoin with the same key under a new one
+// it is called as NodeRemoved blocks are executed, so every node rebuilds the same set when it replays its chain
+func (nl *NodeList) Forbid(nid, fingerprint string) {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	if nl.removed == nil {
+		nl.removed = make(map[string]bool)
+	}
+
+	nl.removed["nid:"+nid] = true
+
+	if fingerprint != "" {
+		nl.removed["key:"+fingerprint] = true
+	}
+}
+
+// Forbidden returns true if a node with NID or a keyPair with fingerprint was removed from the network
+func (nl *NodeList) Forbidden(nid, fingerprint string) bool {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	return nl.removed["nid:"+nid] || (fingerprint != "" && nl.removed["key:"+fingerprint])
+}
+
+// removeNode removes a node if it exists, the lock must be held
+func (nl *NodeList) removeNode(nid string) *model.Node {
+	for i, v := range nl.Verifiers {
+		if v.NID == nid {
+			nl.Verifiers = append(nl.Verifiers[:i:i], nl.Verifiers[i+1:]...)
+			return v
+		}
+	}
+
+	for i, w := range nl.Workers {
+		if w.NID == nid {
+			nl.Workers = append(nl.Workers[:i:i], nl.Workers[i+1:]...)
+			return w
+		}
+	}
+
+	return nil
+}
+
+// ReassignWorker gives the worker with NID a new parent verifier, returning false if it isn't in the nodeList
+// the worker is replaced with a copy rather than changed in place, since others may be holding the old one
+func (nl *NodeList) ReassignWorker(nid, parentNID string) bool {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	for i, w := range nl.Workers {
+		if w.NID == nid {
+			worker := *w
+			worker.ParentNID = parentNID
+
+			nl.Workers[i] = &worker
+
+			return true
+		}
+	}
+
+	return false
+}
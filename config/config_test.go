@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/astromechio/astrocache/model"
+)
+
+// the action worker changes the node list as blocks are executed, while handlers and other workers read it
+func TestNodeListConcurrentJoinsAndRemovals(t *testing.T) {
+	const numNodes = 200
+
+	nl := &NodeList{}
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				seen := map[string]bool{}
+
+				for _, node := range append(nl.AllVerifiers(), nl.AllWorkers()...) {
+					if seen[node.NID] {
+						t.Errorf("expected node with NID %s to be listed once", node.NID)
+						return
+					}
+
+					seen[node.NID] = true
+				}
+
+				if verifier := nl.RandomVerifier(); verifier != nil && verifier.Type != model.NodeTypeVerifier {
+					t.Errorf("expected a verifier, got a node of type %q", verifier.Type)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < numNodes; i++ {
+		verifier := &model.Node{NID: fmt.Sprintf("verifier-%d", i), Type: model.NodeTypeVerifier}
+		worker := &model.Node{NID: fmt.Sprintf("worker-%d", i), Type: model.NodeTypeWorker, ParentNID: verifier.NID}
+
+		nl.AddVerifier(verifier)
+		nl.AddWorker(worker)
+
+		// every third node added before this one is removed again
+		if i%3 == 2 {
+			removed := fmt.Sprintf("verifier-%d", i-2)
+
+			nl.RemoveNode(removed)
+			nl.Forbid(removed, "kid-"+removed)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	removed := numNodes / 3
+
+	if verifiers := nl.AllVerifiers(); len(verifiers) != numNodes-removed {
+		t.Errorf("expected %d verifiers, got %d", numNodes-removed, len(verifiers))
+	}
+
+	if workers := nl.AllWorkers(); len(workers) != numNodes {
+		t.Errorf("expected %d workers, got %d", numNodes, len(workers))
+	}
+
+	for i := 0; i < numNodes; i++ {
+		nid := fmt.Sprintf("verifier-%d", i)
+		wasRemoved := i%3 == 0 && i+2 < numNodes
+
+		if (nl.VerifierWithNID(nid) == nil) != wasRemoved || nl.Forbidden(nid, "kid-"+nid) != wasRemoved {
+			t.Errorf("expected verifier with NID %s removed to be %t", nid, wasRemoved)
+		}
+	}
+
+	if nid := nl.PrimaryNID(); nid != "verifier-0" {
+		t.Errorf("expected primary NID %q, got %q", "verifier-0", nid)
+	}
+}
+
+// the heartbeat worker reassigns workers while the distribute worker lists the ones it sends blocks to
+func TestNodeListConcurrentReassign(t *testing.T) {
+	const numWorkers = 100
+
+	nl := &NodeList{}
+	nl.AddVerifier(&model.Node{NID: "old", Type: model.NodeTypeVerifier})
+	nl.AddVerifier(&model.Node{NID: "new", Type: model.NodeTypeVerifier})
+
+	for i := 0; i < numWorkers; i++ {
+		nl.AddWorker(&model.Node{NID: fmt.Sprintf("worker-%d", i), Type: model.NodeTypeWorker, ParentNID: "old"})
+	}
+
+	done := make(chan bool)
+
+	var readers sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+
+		go func() {
+			defer readers.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				old := nl.WorkersForVerifierWithNID("old")
+				moved := nl.WorkersForVerifierWithNID("new")
+
+				// a worker that is reassigned is replaced, so the ones already returned keep their parent
+				for _, worker := range old {
+					if worker.ParentNID != "old" {
+						t.Errorf("expected worker with NID %s to keep its parent once returned, got %q", worker.NID, worker.ParentNID)
+						return
+					}
+				}
+
+				// workers only move from old to new, so reading old first can count one twice but never miss one
+				if len(nl.AllWorkers()) != numWorkers || len(old)+len(moved) < numWorkers {
+					t.Errorf("expected reassigning workers not to lose any, got %d and %d", len(old), len(moved))
+					return
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+
+	for g := 0; g < 4; g++ {
+		writers.Add(1)
+
+		go func(g int) {
+			defer writers.Done()
+
+			for i := g; i < numWorkers; i += 4 {
+				if !nl.ReassignWorker(fmt.Sprintf("worker-%d", i), "new") {
+					t.Errorf("expected worker-%d to be reassigned", i)
+				}
+			}
+		}(g)
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	if old := nl.WorkersForVerifierWithNID("old"); len(old) != 0 {
+		t.Errorf("expected no workers left on the old verifier, got %d", len(old))
+	}
+
+	if moved := nl.WorkersForVerifierWithNID("new"); len(moved) != numWorkers {
+		t.Errorf("expected %d workers on the new verifier, got %d", numWorkers, len(moved))
+	}
+}
+
+// elections and the term worker change the master while requests check who it is
+func TestNodeListConcurrentMasterChange(t *testing.T) {
+	candidates := []*model.Node{
+		{NID: "master", Type: model.NodeTypeMaster},
+		{NID: "elected", Type: model.NodeTypeVerifier},
+		nil,
+	}
+
+	nl := &NodeList{Master: candidates[0]}
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				master := nl.CurrentMaster()
+
+				if master != nil && master != candidates[0] && master != candidates[1] {
+					t.Errorf("expected the master to be one that was set, got %q", master.NID)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		nl.SetMaster(candidates[i%len(candidates)])
+	}
+
+	close(done)
+	wg.Wait()
+
+	if master := nl.CurrentMaster(); master != candidates[999%len(candidates)] {
+		t.Error("expected the master to be the last one set")
+	}
+}
+
+func TestNodeListSetParent(t *testing.T) {
+	nl := &NodeList{
+		Master: &model.Node{NID: "master", Type: model.NodeTypeMaster},
+	}
+
+	nl.SetParent(&model.Node{NID: "first", Type: model.NodeTypeVerifier})
+	nl.SetParent(&model.Node{NID: "second", Type: model.NodeTypeVerifier})
+
+	if nid := nl.ParentNID(); nid != "second" {
+		t.Errorf("expected parent NID %q, got %q", "second", nid)
+	}
+
+	if nl.VerifierWithNID("first") != nil {
+		t.Error("expected the previous parent to be removed")
+	}
+
+	if len(nl.AllVerifiers()) != 1 {
+		t.Errorf("expected 1 verifier, got %d", len(nl.AllVerifiers()))
+	}
+}
+
+func TestNodeListForbidden(t *testing.T) {
+	nl := &NodeList{}
+
+	if nl.Forbidden("verifier", "key") {
+		t.Error("expected nothing to be forbidden before a node is removed")
+	}
+
+	nl.Forbid("verifier", "key")
+	nl.Forbid("worker", "")
+
+	cases := []struct {
+		nid         string
+		fingerprint string
+		forbidden   bool
+	}{
+		{"verifier", "key", true},
+		{"verifier", "other-key", true},
+		{"other", "key", true},
+		{"other", "other-key", false},
+		{"worker", "", true},
+		{"other", "", false},
+	}
+
+	for _, c := range cases {
+		if forbidden := nl.Forbidden(c.nid, c.fingerprint); forbidden != c.forbidden {
+			t.Errorf("expected Forbidden(%q, %q) to be %t, got %t", c.nid, c.fingerprint, c.forbidden, forbidden)
+		}
+	}
+}
+
+func TestNodeListPrimaryNID(t *testing.T) {
+	nl := &NodeList{}
+
+	nl.AddVerifier(&model.Node{NID: "first", Type: model.NodeTypeVerifier})
+	nl.AddVerifier(&model.Node{NID: "second", Type: model.NodeTypeVerifier})
+
+	if nid := nl.PrimaryNID(); nid != "first" {
+		t.Errorf("expected primary NID %q, got %q", "first", nid)
+	}
+
+	nl.RemoveNode("first")
+
+	if nid := nl.PrimaryNID(); nid != "first" {
+		t.Errorf("expected primary NID to stay %q after it was removed, got %q", "first", nid)
+	}
+}
+
+func TestNodeListReassignMaster(t *testing.T) {
+	master := &model.Node{NID: "master", Type: model.NodeTypeMaster, ParentNID: "first"}
+
+	nl := &NodeList{Master: master}
+	nl.AddVerifier(&model.Node{NID: "first", Type: model.NodeTypeVerifier})
+	nl.AddVerifier(&model.Node{NID: "second", Type: model.NodeTypeVerifier})
+
+	if workers := nl.WorkersForVerifierWithNID("second"); len(workers) != 0 {
+		t.Errorf("expected only the primary verifier to distribute to the master, got %d nodes", len(workers))
+	}
+
+	nl.ReassignMaster("second")
+
+	if workers := nl.WorkersForVerifierWithNID("second"); len(workers) != 1 || workers[0].NID != "master" {
+		t.Error("expected the new primary verifier to distribute to the master")
+	}
+
+	if workers := nl.WorkersForVerifierWithNID("first"); len(workers) != 0 {
+		t.Errorf("expected the old primary verifier to stop distributing to the master, got %d nodes", len(workers))
+	}
+
+	if nid := nl.PrimaryNID(); nid != "second" {
+		t.Errorf("expected primary NID %q, got %q", "second", nid)
+	}
+
+	if master.ParentNID != "first" {
+		t.Error("expected the master to be replaced rather than changed in place")
+	}
+}
@@ -0,0 +1,335 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/astromechio/astrocache/cache"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// EnvDataDir and others are related to persisting node state
+const (
+	EnvDataDir = "ASTRO_DATA_DIR"
+
+	identityFilename = "identity.json"
+	electionFilename = "election.json"
+	chainFilename    = "chain.log"
+)
+
+// DataDir is a directory a node persists its identity and chain to so that it can be restarted
+type DataDir struct {
+	Path string
+}
+
+// Identity is everything a node needs to restart as itself
+// verifiers and workers save one with just Self and KeyPair before they join, so that if they die before the join is recorded
+// they join again as the same node rather than leaving one the network will never hear from behind
+type Identity struct {
+	Self      *model.Node     `json:"self"`
+	KeyPair   json.RawMessage `json:"keyPair"`
+	GlobalKey *acrypto.SymKey `json:"globalKey"`
+	JoinCode  string          `json:"joinCode,omitempty"`
+	Master    *model.Node     `json:"master,omitempty"`
+	Verifier  *model.Node     `json:"verifier,omitempty"`
+}
+
+// DataDirFromEnv opens the data dir named by EnvDataDir, or returns nil if it is not set
+func DataDirFromEnv() (*DataDir, error) {
+	path := os.Getenv(EnvDataDir)
+	if path == "" {
+		return nil, nil
+	}
+
+	return OpenDataDir(path)
+}
+
+// OpenDataDir opens a data dir, creating it if it does not exist
+func OpenDataDir(path string) (*DataDir, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, errors.Wrap(err, "OpenDataDir failed to MkdirAll")
+	}
+
+	dir := &DataDir{
+		Path: path,
+	}
+
+	return dir, nil
+}
+
+// ChainStore opens the file store for the chain inside the data dir
+func (d *DataDir) ChainStore() (*blockchain.FileStore, error) {
+	return blockchain.OpenFileStore(filepath.Join(d.Path, chainFilename))
+}
+
+// LoadIdentity loads the persisted identity, or returns nil if there isn't one yet
+func (d *DataDir) LoadIdentity() (*Identity, error) {
+	idJSON, err := ioutil.ReadFile(filepath.Join(d.Path, identityFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "LoadIdentity failed to ReadFile")
+	}
+
+	identity := &Identity{}
+	if err := json.Unmarshal(idJSON, identity); err != nil {
+		return nil, errors.Wrap(err, "LoadIdentity failed to Unmarshal")
+	}
+
+	return identity, nil
+}
+
+// SaveIdentity persists an identity, replacing the previous one atomically
+func (d *DataDir) SaveIdentity(identity *Identity) error {
+	idJSON, err := json.Marshal(identity)
+	if err != nil {
+		return errors.Wrap(err, "SaveIdentity failed to Marshal")
+	}
+
+	return writeFileAtomic(filepath.Join(d.Path, identityFilename), idJSON)
+}
+
+// LoadElection loads the persisted election state, or returns nil if there isn't one yet
+func (d *DataDir) LoadElection() (*ElectionState, error) {
+	stateJSON, err := ioutil.ReadFile(filepath.Join(d.Path, electionFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "LoadElection failed to ReadFile")
+	}
+
+	state := &ElectionState{}
+	if err := json.Unmarshal(stateJSON, state); err != nil {
+		return nil, errors.Wrap(err, "LoadElection failed to Unmarshal")
+	}
+
+	return state, nil
+}
+
+// SaveElection persists the election state, replacing the previous one atomically
+func (d *DataDir) SaveElection(state *ElectionState) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "SaveElection failed to Marshal")
+	}
+
+	return writeFileAtomic(filepath.Join(d.Path, electionFilename), stateJSON)
+}
+
+// Joined returns true if the identity was saved after its node joined the network
+func (i *Identity) Joined() bool {
+	return i.GlobalKey != nil
+}
+
+// IdentityFromApp creates an identity from the app's current state
+func IdentityFromApp(app *App) (*Identity, error) {
+	keyPairJSON, err := app.KeySet.KeyPair.PrivKeyJSON()
+	if err != nil {
+		return nil, errors.Wrap(err, "IdentityFromApp failed to PrivKeyJSON")
+	}
+
+	identity := &Identity{
+		Self:      app.Self,
+		KeyPair:   keyPairJSON,
+		GlobalKey: app.KeySet.GlobalKey,
+		JoinCode:  app.ValueForKey(AppJoinCodeKey),
+		Master:    app.NodeList.CurrentMaster(),
+	}
+
+	// workers need to remember which verifier they are assigned to now, which may not be the one they joined through
+	if app.Self.Type == model.NodeTypeWorker {
+		parentNID := app.NodeList.ParentNID()
+
+		self := *app.Self
+		self.ParentNID = parentNID
+
+		identity.Self = &self
+		identity.Verifier = app.NodeList.VerifierWithNID(parentNID)
+	}
+
+	return identity, nil
+}
+
+// SaveIdentity persists the app's current identity to its data dir, if it has one
+func (a *App) SaveIdentity() error {
+	if a.DataDir == nil {
+		return nil
+	}
+
+	identity, err := IdentityFromApp(a)
+	if err != nil {
+		return errors.Wrap(err, "SaveIdentity failed to IdentityFromApp")
+	}
+
+	if err := a.DataDir.SaveIdentity(identity); err != nil {
+		return errors.Wrap(err, "SaveIdentity failed to DataDir.SaveIdentity")
+	}
+
+	return nil
+}
+
+// AppFromIdentity creates an app from a persisted identity
+// the rest of the node list and keySet are rebuilt by replaying the chain
+func AppFromIdentity(identity *Identity, chain *blockchain.Chain) (*App, error) {
+	keySet, err := identity.KeySet()
+	if err != nil {
+		return nil, errors.Wrap(err, "AppFromIdentity failed to KeySet")
+	}
+
+	app := &App{
+		Self:     identity.Self,
+		KeySet:   keySet,
+		Chain:    chain,
+		Cache:    cache.EmptyCache(),
+		NodeList: &NodeList{},
+	}
+
+	if identity.JoinCode != "" {
+		app.SetValueForKey(identity.JoinCode, AppJoinCodeKey)
+	}
+
+	if identity.Master != nil {
+		masterKeyPair, err := identity.Master.KeyPair()
+		if err != nil {
+			return nil, errors.Wrap(err, "AppFromIdentity failed to Master.KeyPair")
+		}
+
+		app.KeySet.AddKeyPair(masterKeyPair)
+		app.NodeList.Master = identity.Master
+	}
+
+	if identity.Verifier != nil {
+		verifierKeyPair, err := identity.Verifier.KeyPair()
+		if err != nil {
+			return nil, errors.Wrap(err, "AppFromIdentity failed to Verifier.KeyPair")
+		}
+
+		app.KeySet.AddKeyPair(verifierKeyPair)
+		app.NodeList.SetParent(identity.Verifier)
+	}
+
+	return app, nil
+}
+
+// NewIdentity generates the keyPair and node a verifier or worker joins the network as, or loads them from pending if it already tried to join
+func NewIdentity(address, nodeType string, pending *Identity) (*acrypto.KeyPair, *model.Node, error) {
+	if pending == nil {
+		keyPair, err := acrypto.GenerateNewKeyPair()
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "NewIdentity failed to GenerateNewKeyPair")
+		}
+
+		return keyPair, model.NewNode(address, nodeType, keyPair), nil
+	}
+
+	if pending.Self.Type != nodeType {
+		return nil, nil, fmt.Errorf("NewIdentity found identity for node of type %q, not %s", pending.Self.Type, nodeType)
+	}
+
+	keySet, err := pending.KeySet()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "NewIdentity failed to KeySet")
+	}
+
+	logger.LogWarn(fmt.Sprintf("NewIdentity found node with NID %s didn't finish joining, joining again", pending.Self.NID))
+
+	return keySet.KeyPair, pending.Self, nil
+}
+
+// RestoreApp creates an app for the node of nodeType persisted in the data dir, with the chain it had committed
+// the node keeps the address the network knows it by, and the rest of its state is left to the caller to restore, such as by replaying the chain
+func (d *DataDir) RestoreApp(address, nodeType string, identity *Identity) (*App, error) {
+	if identity.Self.Type != nodeType {
+		return nil, fmt.Errorf("RestoreApp found identity for node of type %q, not %s", identity.Self.Type, nodeType)
+	}
+
+	if identity.Self.Address != address {
+		logger.LogWarn(fmt.Sprintf("RestoreApp ignoring address %q, the network knows this node as %q", address, identity.Self.Address))
+	}
+
+	store, err := d.ChainStore()
+	if err != nil {
+		return nil, errors.Wrap(err, "RestoreApp failed to ChainStore")
+	}
+
+	chain, err := blockchain.ReopenChain(store)
+	if err != nil {
+		return nil, errors.Wrap(err, "RestoreApp failed to ReopenChain")
+	}
+
+	app, err := AppFromIdentity(identity, chain)
+	if err != nil {
+		return nil, errors.Wrap(err, "RestoreApp failed to AppFromIdentity")
+	}
+
+	app.DataDir = d
+
+	return app, nil
+}
+
+// KeySet creates a keySet from the identity's keys
+func (i *Identity) KeySet() (*acrypto.KeySet, error) {
+	keyPair, err := acrypto.KeyPairFromPrivKeyJSON(i.KeyPair)
+	if err != nil {
+		return nil, errors.Wrap(err, "KeySet failed to KeyPairFromPrivKeyJSON")
+	}
+
+	keySet := &acrypto.KeySet{
+		KeyPair:   keyPair,
+		GlobalKey: i.GlobalKey,
+	}
+
+	return keySet, nil
+}
+
+// writeFileAtomic writes to a temp file, fsyncs it, renames it over path and fsyncs the directory
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "writeFileAtomic failed to OpenFile")
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return errors.Wrap(err, "writeFileAtomic failed to Write")
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "writeFileAtomic failed to Sync")
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "writeFileAtomic failed to Close")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "writeFileAtomic failed to Rename")
+	}
+
+	// the rename is only durable once the directory holding it is synced too
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return errors.Wrap(err, "writeFileAtomic failed to Open dir")
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return errors.Wrap(err, "writeFileAtomic failed to Sync dir")
+	}
+
+	return nil
+}
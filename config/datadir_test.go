@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/astromechio/astrocache/model"
+)
+
+func TestDataDirSaveElection(t *testing.T) {
+	dataDir, err := OpenDataDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := dataDir.LoadElection(); err != nil || state != nil {
+		t.Fatalf("expected no election state before one is saved, got %v, %v", state, err)
+	}
+
+	for term := 1; term <= 2; term++ {
+		if err := dataDir.SaveElection(&ElectionState{Term: term, VotedFor: "candidate"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := dataDir.LoadElection()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Term != 2 || state.VotedFor != "candidate" {
+		t.Errorf("expected the last saved state, got term %d voted for %q", state.Term, state.VotedFor)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir.Path, electionFilename+".tmp")); !os.IsNotExist(err) {
+		t.Error("expected the temp file to be renamed over the state")
+	}
+}
+
+func TestNewIdentity(t *testing.T) {
+	keyPair, node, err := NewIdentity("localhost:3000", model.NodeTypeWorker, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.Type != model.NodeTypeWorker || node.Address != "localhost:3000" || keyPair.KID == "" {
+		t.Errorf("expected a new worker at localhost:3000, got a %s at %s", node.Type, node.Address)
+	}
+
+	keyPairJSON, err := keyPair.PrivKeyJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a node that died while joining joins again as the same node
+	pending := &Identity{Self: node, KeyPair: keyPairJSON}
+
+	pendingKeyPair, pendingNode, err := NewIdentity("localhost:4000", model.NodeTypeWorker, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pendingNode.NID != node.NID || pendingKeyPair.KID != keyPair.KID {
+		t.Error("expected a pending identity to be joined with again")
+	}
+
+	if _, _, err := NewIdentity("localhost:3000", model.NodeTypeVerifier, pending); err == nil {
+		t.Error("expected a pending identity for another type of node to be refused")
+	}
+}
+
+func TestDataDirRestoreApp(t *testing.T) {
+	dataDir, err := OpenDataDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPair, node, err := NewIdentity("localhost:3000", model.NodeTypeVerifier, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPairJSON, err := keyPair.PrivKeyJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := &Identity{Self: node, KeyPair: keyPairJSON}
+
+	if _, err := dataDir.RestoreApp("localhost:3000", model.NodeTypeWorker, identity); err == nil {
+		t.Error("expected an identity for another type of node to be refused")
+	}
+
+	// the network knows the node by the address it joined with
+	app, err := dataDir.RestoreApp("localhost:4000", model.NodeTypeVerifier, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Self.NID != node.NID || app.Self.Address != "localhost:3000" || app.KeySet.KeyPair.KID != keyPair.KID {
+		t.Error("expected the app to be restored as the persisted node")
+	}
+
+	if app.DataDir != dataDir || app.Chain == nil || app.Chain.Height() != 0 {
+		t.Error("expected the app to keep persisting to the data dir, with the chain in it")
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Election tracks a verifier's view of master elections, which work like Raft's
+// each election happens in a new term, and a node votes for at most one candidate per term
+// terms only move forward, either by standing in an election or by seeing a higher term from another node or the chain
+// the term and vote are persisted to the data dir before they are acted on, so that a restarted node doesn't vote twice in a term
+type Election struct {
+	Timeout  time.Duration
+	term     int
+	votedFor string
+	heard    time.Time
+	dataDir  *DataDir
+	lock     sync.Mutex
+}
+
+// ElectionState is the part of an Election that is persisted
+type ElectionState struct {
+	Term     int    `json:"term"`
+	VotedFor string `json:"votedFor,omitempty"`
+}
+
+// Restore loads the term and vote persisted in dataDir, and persists them there whenever they change from then on
+func (e *Election) Restore(dataDir *DataDir) error {
+	state, err := dataDir.LoadElection()
+	if err != nil {
+		return errors.Wrap(err, "Restore failed to LoadElection")
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.dataDir = dataDir
+
+	if state != nil {
+		e.term = state.Term
+		e.votedFor = state.VotedFor
+	}
+
+	return nil
+}
+
+// Term returns the current term
+func (e *Election) Term() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.term
+}
+
+// Status returns the current term and who we voted for in it, if anyone
+func (e *Election) Status() (int, string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.term, e.votedFor
+}
+
+// Heard records that the master responded just now
+func (e *Election) Heard() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.heard = time.Now()
+}
+
+// HeardWithin returns true if the master has responded within d
+func (e *Election) HeardWithin(d time.Duration) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return time.Since(e.heard) < d
+}
+
+// Observe moves to term if it is newer than the current one, returning true if it was
+func (e *Election) Observe(term int) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if !e.observe(term) {
+		return false, nil
+	}
+
+	if err := e.save(); err != nil {
+		return true, errors.Wrap(err, "Observe failed to save")
+	}
+
+	return true, nil
+}
+
+// StartCandidacy moves to the next term and votes for nid, returning the new term
+// the candidacy must be abandoned if an error is returned, since the vote may not have been persisted
+func (e *Election) StartCandidacy(nid string) (int, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.term++
+	e.votedFor = nid
+
+	if err := e.save(); err != nil {
+		return 0, errors.Wrap(err, "StartCandidacy failed to save")
+	}
+
+	return e.term, nil
+}
+
+// Vote decides whether to vote for a candidate standing in term, returning the decision and the current term
+// a vote is refused if the term is old, the master has responded within Timeout, the candidate's chain is behind ours, or we already voted for someone else
+// it is also refused if the vote can't be persisted, in which case an error is returned too
+func (e *Election) Vote(term int, candidateNID string, upToDate bool) (bool, int, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if term < e.term {
+		return false, e.term, nil
+	}
+
+	observed := e.observe(term)
+
+	granted := time.Since(e.heard) >= e.Timeout && upToDate && (e.votedFor == "" || e.votedFor == candidateNID)
+	if granted {
+		e.votedFor = candidateNID
+	}
+
+	if observed || granted {
+		if err := e.save(); err != nil {
+			return false, e.term, errors.Wrap(err, "Vote failed to save")
+		}
+	}
+
+	return granted, e.term, nil
+}
+
+func (e *Election) observe(term int) bool {
+	if term <= e.term {
+		return false
+	}
+
+	e.term = term
+	e.votedFor = ""
+
+	return true
+}
+
+// save persists the term and vote if there is a data dir, the lock must be held
+func (e *Election) save() error {
+	if e.dataDir == nil {
+		return nil
+	}
+
+	state := &ElectionState{
+		Term:     e.term,
+		VotedFor: e.votedFor,
+	}
+
+	return e.dataDir.SaveElection(state)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestElectionVote(t *testing.T) {
+	e := &Election{Timeout: time.Minute}
+
+	if granted, term, err := e.Vote(1, "first", true); err != nil || !granted || term != 1 {
+		t.Fatalf("expected a vote in a new term to be granted, got %t in term %d (%v)", granted, term, err)
+	}
+
+	if granted, _, _ := e.Vote(1, "second", true); granted {
+		t.Error("expected a second candidate in the same term to be refused")
+	}
+
+	if granted, _, _ := e.Vote(1, "first", true); !granted {
+		t.Error("expected the same candidate asking again to be granted")
+	}
+
+	if granted, term, _ := e.Vote(0, "second", true); granted || term != 1 {
+		t.Errorf("expected a candidate in an old term to be refused with the current term, got %t in term %d", granted, term)
+	}
+
+	// a newer term starts without a vote, even when it is refused for being behind
+	if granted, term, _ := e.Vote(2, "second", false); granted || term != 2 {
+		t.Errorf("expected a candidate whose chain is behind to be refused in term 2, got %t in term %d", granted, term)
+	}
+
+	if term, votedFor := e.Status(); term != 2 || votedFor != "" {
+		t.Errorf("expected no vote in term 2, got %q", votedFor)
+	}
+
+	if granted, _, _ := e.Vote(2, "third", true); !granted {
+		t.Error("expected an up to date candidate to be granted once no vote has been cast in the term")
+	}
+}
+
+func TestElectionVoteWhileMasterResponds(t *testing.T) {
+	e := &Election{Timeout: time.Minute}
+	e.Heard()
+
+	if granted, term, _ := e.Vote(1, "candidate", true); granted || term != 1 {
+		t.Errorf("expected a vote to be refused while the master responds, got %t in term %d", granted, term)
+	}
+
+	if !e.HeardWithin(time.Minute) {
+		t.Error("expected the master to have been heard within the timeout")
+	}
+}
+
+func TestElectionStartCandidacy(t *testing.T) {
+	e := &Election{Timeout: time.Minute}
+
+	if observed, _ := e.Observe(3); !observed {
+		t.Fatal("expected a newer term to be observed")
+	}
+
+	if observed, _ := e.Observe(3); observed {
+		t.Error("expected the current term not to be observed again")
+	}
+
+	term, err := e.StartCandidacy("self")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if term != 4 {
+		t.Errorf("expected candidacy in term 4, got %d", term)
+	}
+
+	if granted, _, _ := e.Vote(4, "other", true); granted {
+		t.Error("expected a candidate to refuse to vote for anyone else in its own term")
+	}
+}
+
+func TestElectionRestore(t *testing.T) {
+	dataDir, err := OpenDataDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Election{Timeout: time.Minute}
+	if err := e.Restore(dataDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if granted, _, err := e.Vote(5, "first", true); err != nil || !granted {
+		t.Fatalf("expected the vote to be granted, got %t (%v)", granted, err)
+	}
+
+	// as if the node restarted
+	restored := &Election{Timeout: time.Minute}
+	if err := restored.Restore(dataDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if term, votedFor := restored.Status(); term != 5 || votedFor != "first" {
+		t.Errorf("expected term 5 voted for %q, got term %d voted for %q", "first", term, votedFor)
+	}
+
+	if granted, _, _ := restored.Vote(5, "second", true); granted {
+		t.Error("expected a restarted node not to vote twice in the same term")
+	}
+}
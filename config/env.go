@@ -0,0 +1,271 @@
+package config
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/pkg/errors"
+)
+
+// EnvCacheMaxEntries and others are environment variables used to configure a node
+const (
+	EnvCacheMaxEntries = "ASTRO_CACHE_MAX_ENTRIES"
+	EnvCacheMaxBytes   = "ASTRO_CACHE_MAX_BYTES"
+	EnvCachePolicy     = "ASTRO_CACHE_POLICY"
+
+	EnvBatchMaxSize  = "ASTRO_BATCH_MAX_SIZE"
+	EnvBatchLingerMS = "ASTRO_BATCH_LINGER_MS"
+
+	EnvAdminToken = "ASTRO_ADMIN_TOKEN"
+
+	EnvWatchHistory = "ASTRO_WATCH_HISTORY"
+
+	EnvMasterEligible    = "ASTRO_MASTER_ELIGIBLE"
+	EnvElectionTimeoutMS = "ASTRO_ELECTION_TIMEOUT_MS"
+
+	EnvQuorum = "ASTRO_QUORUM"
+
+	EnvHeartbeatIntervalMS = "ASTRO_HEARTBEAT_INTERVAL_MS"
+	EnvEvictionGraceMS     = "ASTRO_EVICTION_GRACE_MS"
+)
+
+// QuorumMajority and others are the policies for how many verifiers must accept a block
+// a quorum can also be a number of verifiers, which is capped at the number there are so that a small network can still grow
+// only a majority or more is safe, since two smaller quorums could accept different blocks, so a number below a majority is raised to one
+const (
+	QuorumMajority = "majority"
+	QuorumAll      = "all"
+)
+
+// defaultBatchMaxSize and others are used when the batch options are not set in the environment
+const (
+	defaultBatchMaxSize  = 100
+	defaultBatchLingerMS = 2
+
+	defaultElectionTimeoutMS = 3000
+
+	defaultHeartbeatIntervalMS = 1000
+	defaultEvictionGraceMS     = 30000
+)
+
+// BatchOptions control how a verifier groups writes into blocks
+// MaxSize is the most actions put in one block, and Linger is how long to wait for more actions after the first arrives
+type BatchOptions struct {
+	MaxSize int
+	Linger  time.Duration
+}
+
+// ElectionOptions control how a verifier watches the master and stands for election when it stops responding
+// Eligible verifiers start an election once the master hasn't responded for between Timeout and twice Timeout, picked at random for each check
+type ElectionOptions struct {
+	Eligible bool
+	Timeout  time.Duration
+}
+
+// HeartbeatOptions control how the master checks that the nodes it knows are still running
+// a node is sent a heartbeat every Interval, and is evicted once it hasn't responded for Grace
+type HeartbeatOptions struct {
+	Interval time.Duration
+	Grace    time.Duration
+}
+
+// Quorum decides how many verifiers must accept a block before it is committed
+// Count is only used when Policy is empty, and is the number of verifiers needed
+type Quorum struct {
+	Policy string
+	Count  int
+}
+
+// Required returns how many of total verifiers must accept a block
+// a Count below a majority of total is raised to a majority, since verifiers can join after the count is chosen
+func (q *Quorum) Required(total int) int {
+	majority := total/2 + 1
+
+	switch q.Policy {
+	case QuorumAll:
+		return total
+	case QuorumMajority:
+		return majority
+	}
+
+	if q.Count > total {
+		return total
+	}
+
+	if q.Count < majority {
+		return majority
+	}
+
+	return q.Count
+}
+
+// CacheOptionsFromEnv loads the cache limits and eviction policy from the environment
+func CacheOptionsFromEnv() (*cache.Options, error) {
+	maxEntries, err := envInt(EnvCacheMaxEntries, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes, err := envInt(EnvCacheMaxBytes, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &cache.Options{
+		MaxEntries: int(maxEntries),
+		MaxBytes:   maxBytes,
+		Policy:     os.Getenv(EnvCachePolicy),
+	}
+
+	return options, nil
+}
+
+// BatchOptionsFromEnv loads the write batching options from the environment
+func BatchOptionsFromEnv() (*BatchOptions, error) {
+	maxSize, err := envInt(EnvBatchMaxSize, defaultBatchMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize == 0 {
+		return nil, fmt.Errorf("%s must be at least 1", EnvBatchMaxSize)
+	}
+
+	lingerMS, err := envInt(EnvBatchLingerMS, defaultBatchLingerMS)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &BatchOptions{
+		MaxSize: int(maxSize),
+		Linger:  time.Duration(lingerMS) * time.Millisecond,
+	}
+
+	return options, nil
+}
+
+// WatchHistoryFromEnv loads the number of blocks whose changes are kept for resuming watches from the environment
+func WatchHistoryFromEnv() (int, error) {
+	history, err := envInt(EnvWatchHistory, defaultWatchHistory)
+	if err != nil {
+		return 0, err
+	}
+
+	if history == 0 {
+		return 0, fmt.Errorf("%s must be at least 1", EnvWatchHistory)
+	}
+
+	return int(history), nil
+}
+
+// ElectionOptionsFromEnv loads whether this node may become master, and how long the master can be unresponsive, from the environment
+func ElectionOptionsFromEnv() (*ElectionOptions, error) {
+	timeoutMS, err := envInt(EnvElectionTimeoutMS, defaultElectionTimeoutMS)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeoutMS == 0 {
+		return nil, fmt.Errorf("%s must be at least 1", EnvElectionTimeoutMS)
+	}
+
+	eligible := false
+	if str := os.Getenv(EnvMasterEligible); str != "" {
+		eligible, err = strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean, got %q", EnvMasterEligible, str)
+		}
+	}
+
+	options := &ElectionOptions{
+		Eligible: eligible,
+		Timeout:  time.Duration(timeoutMS) * time.Millisecond,
+	}
+
+	return options, nil
+}
+
+// HeartbeatOptionsFromEnv loads the heartbeat interval and eviction grace period from the environment
+func HeartbeatOptionsFromEnv() (*HeartbeatOptions, error) {
+	intervalMS, err := envInt(EnvHeartbeatIntervalMS, defaultHeartbeatIntervalMS)
+	if err != nil {
+		return nil, err
+	}
+
+	if intervalMS == 0 {
+		return nil, fmt.Errorf("%s must be at least 1", EnvHeartbeatIntervalMS)
+	}
+
+	graceMS, err := envInt(EnvEvictionGraceMS, defaultEvictionGraceMS)
+	if err != nil {
+		return nil, err
+	}
+
+	if graceMS < intervalMS {
+		return nil, fmt.Errorf("%s must be at least %s (%d)", EnvEvictionGraceMS, EnvHeartbeatIntervalMS, intervalMS)
+	}
+
+	options := &HeartbeatOptions{
+		Interval: time.Duration(intervalMS) * time.Millisecond,
+		Grace:    time.Duration(graceMS) * time.Millisecond,
+	}
+
+	return options, nil
+}
+
+// QuorumFromEnv loads the quorum policy from the environment, which is "majority", "all" or a number of verifiers
+// a number is a minimum that gets raised to a majority whenever it is less, see Quorum.Required
+func QuorumFromEnv() (*Quorum, error) {
+	str := os.Getenv(EnvQuorum)
+
+	switch str {
+	case "", QuorumMajority:
+		return &Quorum{Policy: QuorumMajority}, nil
+	case QuorumAll:
+		return &Quorum{Policy: QuorumAll}, nil
+	}
+
+	count, err := strconv.Atoi(str)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("%s must be %q, %q or a number of verifiers, got %q", EnvQuorum, QuorumMajority, QuorumAll, str)
+	}
+
+	return &Quorum{Count: count}, nil
+}
+
+// AuthorizeAdmin checks that token matches the admin token in the environment
+// admin requests are refused entirely if no admin token is set
+func AuthorizeAdmin(token string) error {
+	adminToken := os.Getenv(EnvAdminToken)
+	if adminToken == "" {
+		return errors.Wrap(ErrNotAuthorized, EnvAdminToken+" is not set, admin requests are disabled")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		return errors.Wrap(ErrNotAuthorized, "admin token does not match")
+	}
+
+	return nil
+}
+
+func envInt(name string, def int64) (int64, error) {
+	str := os.Getenv(name)
+	if str == "" {
+		return def, nil
+	}
+
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer, got %q", name, str)
+	}
+
+	if val < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %d", name, val)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestQuorumRequired(t *testing.T) {
+	tests := []struct {
+		quorum   *Quorum
+		total    int
+		required int
+	}{
+		{&Quorum{Policy: QuorumAll}, 5, 5},
+		{&Quorum{Policy: QuorumMajority}, 5, 3},
+		{&Quorum{Policy: QuorumMajority}, 4, 3},
+		{&Quorum{Policy: QuorumMajority}, 1, 1},
+		{&Quorum{Count: 4}, 5, 4},
+		{&Quorum{Count: 1}, 5, 3},
+		{&Quorum{Count: 7}, 5, 5},
+	}
+
+	for _, test := range tests {
+		if required := test.quorum.Required(test.total); required != test.required {
+			t.Errorf("expected %+v to require %d of %d, got %d", *test.quorum, test.required, test.total, required)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// LivenessAlive and others are the states a node can be in, as seen by the master's heartbeats
+// a node that misses a heartbeat is suspect, and one that stays suspect for the grace period is dead and gets evicted
+const (
+	LivenessAlive   = "alive"
+	LivenessSuspect = "suspect"
+	LivenessDead    = "dead"
+)
+
+// Liveness tracks whether the nodes in the nodeList are responding to heartbeats
+type Liveness struct {
+	nodes map[string]*nodeLiveness
+	lock  sync.Mutex
+}
+
+type nodeLiveness struct {
+	state    string
+	lastSeen time.Time
+}
+
+// State returns the state of the node with NID, nodes that haven't been sent a heartbeat yet are alive
+func (l *Liveness) State(nid string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	node, ok := l.nodes[nid]
+	if !ok {
+		return LivenessAlive
+	}
+
+	return node.state
+}
+
+// Alive records that the node with NID responded just now, returning its previous state
+func (l *Liveness) Alive(nid string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	node := l.node(nid)
+
+	previous := node.state
+	node.state = LivenessAlive
+	node.lastSeen = time.Now()
+
+	return previous
+}
+
+// Missed records that the node with NID didn't respond, returning its new state
+// it is dead once it hasn't responded for grace, counted from the last response or from the first heartbeat it missed
+func (l *Liveness) Missed(nid string, grace time.Duration) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	node := l.node(nid)
+
+	if time.Since(node.lastSeen) >= grace {
+		node.state = LivenessDead
+	} else {
+		node.state = LivenessSuspect
+	}
+
+	return node.state
+}
+
+// Forget stops tracking the node with NID, such as once it has been removed from the network
+func (l *Liveness) Forget(nid string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.nodes, nid)
+}
+
+// Membership tracks whether this node is still part of the network, which it leaves once it executes a NodeRemoved block for itself
+// heartbeats don't decide membership, since a node must keep serving while the master is down and no verifier can replace it,
+// but it remembers the last heartbeat each master sent so that a recorded one can't be replayed
+type Membership struct {
+	removed       bool
+	heartbeatNID  string
+	heartbeatSent int64
+	lock          sync.Mutex
+}
+
+// Heartbeat records a heartbeat that the master with masterNID sent at sent, returning false if it isn't newer than the last one that master sent
+func (m *Membership) Heartbeat(masterNID string, sent int64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if masterNID == m.heartbeatNID && sent <= m.heartbeatSent {
+		return false
+	}
+
+	m.heartbeatNID = masterNID
+	m.heartbeatSent = sent
+
+	return true
+}
+
+// Remove records that this node has been removed from the network, which it never rejoins as the same node
+func (m *Membership) Remove() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.removed = true
+}
+
+// Active returns false once this node has been removed
+func (m *Membership) Active() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return !m.removed
+}
+
+func (l *Liveness) node(nid string) *nodeLiveness {
+	if l.nodes == nil {
+		l.nodes = make(map[string]*nodeLiveness)
+	}
+
+	node, ok := l.nodes[nid]
+	if !ok {
+		node = &nodeLiveness{
+			state:    LivenessAlive,
+			lastSeen: time.Now(),
+		}
+
+		l.nodes[nid] = node
+	}
+
+	return node
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMembershipActiveUntilRemoved(t *testing.T) {
+	m := &Membership{}
+
+	if !m.Active() {
+		t.Error("expected a node that was never removed to be active, whether or not it has heard a heartbeat")
+	}
+
+	m.Remove()
+
+	if m.Active() {
+		t.Error("expected a removed node not to be active")
+	}
+
+	if !m.Heartbeat("master", 1) || m.Active() {
+		t.Error("expected a heartbeat not to make a removed node active again")
+	}
+}
+
+func TestMembershipHeartbeatReplay(t *testing.T) {
+	m := &Membership{}
+
+	if !m.Heartbeat("master", 10) {
+		t.Error("expected the first heartbeat to be accepted")
+	}
+
+	if m.Heartbeat("master", 10) {
+		t.Error("expected a replayed heartbeat to be refused")
+	}
+
+	if m.Heartbeat("master", 5) {
+		t.Error("expected an older heartbeat to be refused")
+	}
+
+	// a newly elected master's clock may be behind the old one's
+	if !m.Heartbeat("elected", 1) {
+		t.Error("expected the first heartbeat from another master to be accepted")
+	}
+
+	if !m.Heartbeat("elected", 2) {
+		t.Error("expected a newer heartbeat to be accepted")
+	}
+}
+
+func TestLivenessMissed(t *testing.T) {
+	l := &Liveness{}
+
+	if state := l.State("node"); state != LivenessAlive {
+		t.Errorf("expected a node that was never sent a heartbeat to be %s, got %s", LivenessAlive, state)
+	}
+
+	if state := l.Missed("node", time.Hour); state != LivenessSuspect {
+		t.Errorf("expected a node that missed a heartbeat to be %s, got %s", LivenessSuspect, state)
+	}
+
+	if previous := l.Alive("node"); previous != LivenessSuspect {
+		t.Errorf("expected the previous state to be %s, got %s", LivenessSuspect, previous)
+	}
+
+	if state := l.Missed("node", 0); state != LivenessDead {
+		t.Errorf("expected a node that missed heartbeats for the grace period to be %s, got %s", LivenessDead, state)
+	}
+
+	l.Forget("node")
+
+	if state := l.State("node"); state != LivenessAlive {
+		t.Errorf("expected a forgotten node to be %s, got %s", LivenessAlive, state)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/pkg/errors"
+)
+
+// ErrNoNamespace and others are returned when a namespace can't be used
+var (
+	ErrNoNamespace     = errors.New("namespace does not exist")
+	ErrNamespaceExists = errors.New("namespace already exists")
+	ErrNotAuthorized   = errors.New("not authorized")
+)
+
+// Namespace is a set of keys with its own cache, quota and access token
+// TokenHash is the hex encoded sha256 of the namespace's token, or "" if anyone can use it
+// the quota is enforced when writes are executed rather than by evicting, so every node holds every key in a namespace
+type Namespace struct {
+	Name       string
+	MaxEntries int
+	MaxBytes   int64
+	TokenHash  string
+	Cache      *cache.Cache
+	usage      quotaUsage
+}
+
+// NamespaceList holds the namespaces that have been created through the chain
+// verifiers set KeepValue so that their namespaces only keep the values they need, the same as their default cache
+type NamespaceList struct {
+	KeepValue  func(contentType string, val []byte) bool
+	namespaces map[string]*Namespace
+	lock       sync.RWMutex
+}
+
+// Create creates a namespace and its cache
+func (nl *NamespaceList) Create(name string, maxEntries int, maxBytes int64, tokenHash string) error {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	if nl.namespaces == nil {
+		nl.namespaces = make(map[string]*Namespace)
+	}
+
+	if _, ok := nl.namespaces[name]; ok {
+		return ErrNamespaceExists
+	}
+
+	options := &cache.Options{
+		KeepValue: nl.KeepValue,
+	}
+
+	nsCache, err := cache.NewCache(options)
+	if err != nil {
+		return errors.Wrap(err, "Create failed to NewCache")
+	}
+
+	nl.namespaces[name] = &Namespace{
+		Name:       name,
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+		TokenHash:  tokenHash,
+		Cache:      nsCache,
+	}
+
+	return nil
+}
+
+// Delete removes a namespace and everything in it
+func (nl *NamespaceList) Delete(name string) error {
+	nl.lock.Lock()
+	defer nl.lock.Unlock()
+
+	if _, ok := nl.namespaces[name]; !ok {
+		return ErrNoNamespace
+	}
+
+	delete(nl.namespaces, name)
+
+	return nil
+}
+
+// Get returns the namespace with name, or nil if it doesn't exist
+func (nl *NamespaceList) Get(name string) *Namespace {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	return nl.namespaces[name]
+}
+
+// All returns every namespace, sorted by name
+func (nl *NamespaceList) All() []*Namespace {
+	nl.lock.RLock()
+	defer nl.lock.RUnlock()
+
+	all := make([]*Namespace, 0, len(nl.namespaces))
+	for _, ns := range nl.namespaces {
+		all = append(all, ns)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+// Use counts changes against the namespace's quota as of at, returning ErrQuotaExceeded if they would take it over
+// it must be called by actions in chain order before they change the namespace's cache
+func (ns *Namespace) Use(at time.Time, changes ...*KeyUsage) error {
+	return ns.usage.use(at, changes, ns.MaxEntries, ns.MaxBytes)
+}
+
+// Usage returns the number of entries and bytes counted against the namespace's quota
+func (ns *Namespace) Usage() (entries int, bytes int64) {
+	return ns.usage.usage()
+}
+
+// ResetUsage stops counting every key against the namespace's quota, such as when its cache is flushed
+func (ns *Namespace) ResetUsage() {
+	ns.usage.reset()
+}
+
+// Flush removes every key in the namespace and returns the number removed
+func (ns *Namespace) Flush() (int, error) {
+	ns.ResetUsage()
+
+	return ns.Cache.Flush()
+}
+
+// Authorize checks that token grants access to the namespace
+func (ns *Namespace) Authorize(token string) error {
+	if ns.TokenHash == "" {
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(ns.TokenHash)) != 1 {
+		return ErrNotAuthorized
+	}
+
+	return nil
+}
+
+// HashToken returns the hex encoded sha256 of a token, or "" if token is empty
+func HashToken(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
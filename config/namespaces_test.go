@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func newTestNamespace(t *testing.T, maxEntries int, maxBytes int64) *Namespace {
+	nl := &NamespaceList{}
+
+	if err := nl.Create("test", maxEntries, maxBytes, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	return nl.Get("test")
+}
+
+func TestNamespaceQuotaEntries(t *testing.T) {
+	ns := newTestNamespace(t, 2, 0)
+	now := time.Now()
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 10}, &KeyUsage{Key: "b", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "c", Size: 10}); errors.Cause(err) != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// replacing a key that is already counted doesn't add an entry
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 20}); err != nil {
+		t.Errorf("expected replacing a key to be allowed, got %v", err)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "b", Deleted: true}, &KeyUsage{Key: "c", Size: 10}); err != nil {
+		t.Errorf("expected deleting a key to make room in the same batch, got %v", err)
+	}
+
+	if entries, bytes := ns.Usage(); entries != 2 || bytes != 30 {
+		t.Errorf("expected 2 entries and 30 bytes, got %d and %d", entries, bytes)
+	}
+}
+
+func TestNamespaceQuotaBytes(t *testing.T) {
+	ns := newTestNamespace(t, 0, 100)
+	now := time.Now()
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "b", Size: 30}, &KeyUsage{Key: "c", Size: 30}); errors.Cause(err) != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// a batch over the quota changes nothing
+	if entries, bytes := ns.Usage(); entries != 1 || bytes != 60 {
+		t.Errorf("expected 1 entry and 60 bytes after the batch was refused, got %d and %d", entries, bytes)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 90}); err != nil {
+		t.Errorf("expected growing a key within the quota to be allowed, got %v", err)
+	}
+}
+
+func TestNamespaceQuotaOverLimit(t *testing.T) {
+	ns := newTestNamespace(t, 0, 100)
+	now := time.Now()
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 80}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the quota is lowered below what the namespace already holds
+	ns.MaxBytes = 50
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 70}); err != nil {
+		t.Errorf("expected shrinking a key over the quota to be allowed, got %v", err)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "b", Size: 1}); errors.Cause(err) != ErrQuotaExceeded {
+		t.Errorf("expected adding to a namespace over its quota to fail, got %v", err)
+	}
+}
+
+func TestNamespaceQuotaExpiry(t *testing.T) {
+	ns := newTestNamespace(t, 1, 0)
+	start := time.Unix(1000, 0)
+
+	if err := ns.Use(start, &KeyUsage{Key: "a", Size: 10, ExpiresAt: start.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ns.Use(start.Add(time.Second), &KeyUsage{Key: "b", Size: 10}); errors.Cause(err) != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded before the key expires, got %v", err)
+	}
+
+	// expiry is decided by the time each write is executed at, not the local clock
+	if err := ns.Use(start.Add(time.Minute), &KeyUsage{Key: "b", Size: 10}); err != nil {
+		t.Errorf("expected the expired key to stop counting, got %v", err)
+	}
+
+	if entries, _ := ns.Usage(); entries != 1 {
+		t.Errorf("expected 1 entry, got %d", entries)
+	}
+}
+
+func TestNamespaceQuotaReplacedExpiry(t *testing.T) {
+	ns := newTestNamespace(t, 0, 0)
+	start := time.Unix(1000, 0)
+
+	if err := ns.Use(start, &KeyUsage{Key: "a", Size: 10, ExpiresAt: start.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ns.Use(start, &KeyUsage{Key: "a", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the key no longer expires, so its old expiry must not remove it
+	if err := ns.Use(start.Add(time.Minute), &KeyUsage{Key: "b", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := ns.Usage(); entries != 2 {
+		t.Errorf("expected 2 entries, got %d", entries)
+	}
+}
+
+func TestNamespaceQuotaCompact(t *testing.T) {
+	ns := newTestNamespace(t, 0, 0)
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 3*minExpiriesCompacted; i++ {
+		change := &KeyUsage{Key: fmt.Sprintf("key-%d", i%10), Size: 1, ExpiresAt: start.Add(time.Hour + time.Duration(i)*time.Second)}
+
+		if err := ns.Use(start, change); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(ns.usage.expiries) > 2*10+minExpiriesCompacted {
+		t.Errorf("expected the expiry heap to be compacted, it holds %d changes", len(ns.usage.expiries))
+	}
+
+	if err := ns.Use(start.Add(2*time.Hour+3*minExpiriesCompacted*time.Second), &KeyUsage{Key: "last", Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, bytes := ns.Usage(); entries != 1 || bytes != 1 {
+		t.Errorf("expected every key but the last to have expired, got %d entries and %d bytes", entries, bytes)
+	}
+}
+
+func TestNamespaceFlushResetsUsage(t *testing.T) {
+	ns := newTestNamespace(t, 1, 0)
+	now := time.Now()
+
+	if err := ns.Use(now, &KeyUsage{Key: "a", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	ns.Cache.SetValueForKey([]byte("value"), "a")
+
+	if _, err := ns.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, bytes := ns.Usage(); entries != 0 || bytes != 0 {
+		t.Errorf("expected no usage after a flush, got %d entries and %d bytes", entries, bytes)
+	}
+
+	if err := ns.Use(now, &KeyUsage{Key: "b", Size: 10}); err != nil {
+		t.Errorf("expected a flushed namespace to have room, got %v", err)
+	}
+}
+
+func TestNamespaceListCreate(t *testing.T) {
+	nl := &NamespaceList{}
+
+	if err := nl.Create("b", 0, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nl.Create("a", 0, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nl.Create("a", 0, 0, ""); err != ErrNamespaceExists {
+		t.Errorf("expected ErrNamespaceExists, got %v", err)
+	}
+
+	if all := nl.All(); len(all) != 2 || all[0].Name != "a" || all[1].Name != "b" {
+		t.Errorf("expected namespaces a and b in order, got %d", len(all))
+	}
+
+	if err := nl.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nl.Delete("a"); err != ErrNoNamespace {
+		t.Errorf("expected ErrNoNamespace, got %v", err)
+	}
+}
+
+func TestNamespaceAuthorize(t *testing.T) {
+	ns := &Namespace{TokenHash: HashToken("secret")}
+
+	if err := ns.Authorize("secret"); err != nil {
+		t.Errorf("expected the right token to be authorized, got %v", err)
+	}
+
+	if err := ns.Authorize("wrong"); err != ErrNotAuthorized {
+		t.Errorf("expected ErrNotAuthorized, got %v", err)
+	}
+
+	if err := (&Namespace{}).Authorize(""); err != nil {
+		t.Errorf("expected a namespace without a token to be open, got %v", err)
+	}
+}
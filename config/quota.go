@@ -0,0 +1,175 @@
+package config
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQuotaExceeded is returned when a write would take a namespace over its quota
+var ErrQuotaExceeded = errors.New("namespace quota exceeded")
+
+// minExpiriesCompacted is how many replaced changes the expiry heap can hold before it is compacted, beyond twice the number of keys
+const minExpiriesCompacted = 1024
+
+// KeyUsage is what a key counts against its namespace's quota once a write is applied
+// Size is the length of the key and its value, and a Deleted key no longer counts at all
+type KeyUsage struct {
+	Key       string
+	Size      int64
+	ExpiresAt time.Time
+	Deleted   bool
+}
+
+// quotaUsage counts the keys in a namespace against its quota
+// it is only changed by actions in chain order and keys expire as of each action's timestamp, so every node makes the same decisions
+// sweeping and eviction are local to each node, so the cache itself can't be used to count
+type quotaUsage struct {
+	keys     map[string]*KeyUsage
+	expiries expiryHeap
+	entries  int
+	bytes    int64
+	lock     sync.Mutex
+}
+
+// use applies changes if they keep the usage within maxEntries and maxBytes as of at, a limit of 0 means there is none
+// changes that don't add to the usage are always applied, so that a namespace over its quota can still be cleaned up
+func (qu *quotaUsage) use(at time.Time, changes []*KeyUsage, maxEntries int, maxBytes int64) error {
+	qu.lock.Lock()
+	defer qu.lock.Unlock()
+
+	qu.expire(at)
+
+	// only the last change to each key counts
+	latest := make(map[string]*KeyUsage, len(changes))
+	for _, change := range changes {
+		latest[change.Key] = change
+	}
+
+	entries, bytes := qu.entries, qu.bytes
+
+	for _, change := range latest {
+		if existing, ok := qu.keys[change.Key]; ok {
+			entries--
+			bytes -= existing.Size
+		}
+
+		if !change.Deleted {
+			entries++
+			bytes += change.Size
+		}
+	}
+
+	if maxEntries > 0 && entries > maxEntries && entries > qu.entries {
+		return errors.Wrapf(ErrQuotaExceeded, "%d entries is over the limit of %d", entries, maxEntries)
+	}
+
+	if maxBytes > 0 && bytes > maxBytes && bytes > qu.bytes {
+		return errors.Wrapf(ErrQuotaExceeded, "%d bytes is over the limit of %d", bytes, maxBytes)
+	}
+
+	for _, change := range changes {
+		qu.remove(change.Key)
+
+		if change.Deleted {
+			continue
+		}
+
+		qu.keys[change.Key] = change
+		qu.entries++
+		qu.bytes += change.Size
+
+		if !change.ExpiresAt.IsZero() {
+			heap.Push(&qu.expiries, change)
+		}
+	}
+
+	if len(qu.expiries) > 2*len(qu.keys)+minExpiriesCompacted {
+		qu.compact()
+	}
+
+	return nil
+}
+
+// usage returns the number of entries and bytes counted as of the last write
+func (qu *quotaUsage) usage() (int, int64) {
+	qu.lock.Lock()
+	defer qu.lock.Unlock()
+
+	return qu.entries, qu.bytes
+}
+
+// reset forgets every key, such as when the namespace is flushed
+func (qu *quotaUsage) reset() {
+	qu.lock.Lock()
+	defer qu.lock.Unlock()
+
+	qu.keys = make(map[string]*KeyUsage)
+	qu.expiries = nil
+	qu.entries = 0
+	qu.bytes = 0
+}
+
+// expire removes keys that have expired as of at, the lock must be held
+// the heap can hold changes that have since been replaced, which are skipped
+func (qu *quotaUsage) expire(at time.Time) {
+	if qu.keys == nil {
+		qu.keys = make(map[string]*KeyUsage)
+	}
+
+	for len(qu.expiries) > 0 && !at.Before(qu.expiries[0].ExpiresAt) {
+		expired := heap.Pop(&qu.expiries).(*KeyUsage)
+
+		if qu.keys[expired.Key] == expired {
+			qu.remove(expired.Key)
+		}
+	}
+}
+
+// compact rebuilds the expiry heap without the changes that have been replaced, the lock must be held
+func (qu *quotaUsage) compact() {
+	expiries := expiryHeap{}
+
+	for _, usage := range qu.keys {
+		if !usage.ExpiresAt.IsZero() {
+			expiries = append(expiries, usage)
+		}
+	}
+
+	heap.Init(&expiries)
+
+	qu.expiries = expiries
+}
+
+// remove stops counting key, the lock must be held
+func (qu *quotaUsage) remove(key string) {
+	existing, ok := qu.keys[key]
+	if !ok {
+		return
+	}
+
+	delete(qu.keys, key)
+	qu.entries--
+	qu.bytes -= existing.Size
+}
+
+// expiryHeap is a heap of key usages with the first to expire at its root
+type expiryHeap []*KeyUsage
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].ExpiresAt.Before(h[j].ExpiresAt) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x interface{}) {
+	*h = append(*h, x.(*KeyUsage))
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return last
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrWatchTooOld is returned when a watch is resumed from a block that is no longer in the history
+var ErrWatchTooOld = errors.New("block is not in the watch history")
+
+// ChangeOpSet and others describe how a key was changed
+// a flush removes every key in its namespace, and flushall removes every key in every namespace
+const (
+	ChangeOpSet      = "set"
+	ChangeOpDelete   = "delete"
+	ChangeOpFlush    = "flush"
+	ChangeOpFlushAll = "flushall"
+)
+
+// defaultWatchHistory and others control how watchers are kept up to date
+const (
+	defaultWatchHistory = 1024
+	watcherBuffer       = 256
+)
+
+// Change describes a key that was changed by an action, Key is empty for flushes
+type Change struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+	Op        string `json:"op"`
+}
+
+// BlockChanges are the changes made by the actions in a single block
+type BlockChanges struct {
+	BlockID string    `json:"blockId"`
+	Changes []*Change `json:"changes"`
+}
+
+// Watchers sends the changes made by each block to anyone watching for them
+// the changes from the last HistorySize blocks are kept so that a watch can resume from a block it has already seen
+type Watchers struct {
+	HistorySize int
+	history     []*BlockChanges
+	watching    map[*Watcher]bool
+	lock        sync.Mutex
+}
+
+// Watcher receives the changes to keys in Namespace starting with Prefix
+// Events is closed if the watcher falls too far behind, it should resume from the last block it received
+type Watcher struct {
+	Namespace string
+	Prefix    string
+	Events    chan *BlockChanges
+}
+
+// Publish records the changes made by a block and sends them to every watcher, it must be called in chain order
+// blocks with no changes are recorded too, so that a watch can resume from any block
+func (ws *Watchers) Publish(blockID string, changes []*Change) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	published := &BlockChanges{
+		BlockID: blockID,
+		Changes: changes,
+	}
+
+	ws.history = append(ws.history, published)
+
+	historySize := ws.HistorySize
+	if historySize <= 0 {
+		historySize = defaultWatchHistory
+	}
+
+	if len(ws.history) > historySize {
+		ws.history = ws.history[len(ws.history)-historySize:]
+	}
+
+	for w := range ws.watching {
+		filtered := w.filter(published)
+		if filtered == nil {
+			continue
+		}
+
+		select {
+		case w.Events <- filtered:
+		default:
+			// the watcher can't keep up, so drop it rather than hold up the chain
+			close(w.Events)
+			delete(ws.watching, w)
+		}
+	}
+}
+
+// Watch starts watching for changes to keys in ns starting with prefix
+// if fromBlock is set, the changes from every block after it are sent first
+func (ws *Watchers) Watch(ns, prefix, fromBlock string) (*Watcher, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	backlog := []*BlockChanges{}
+
+	if fromBlock != "" {
+		index := -1
+		for i, published := range ws.history {
+			if published.BlockID == fromBlock {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			return nil, ErrWatchTooOld
+		}
+
+		backlog = ws.history[index+1:]
+	}
+
+	w := &Watcher{
+		Namespace: ns,
+		Prefix:    prefix,
+		Events:    make(chan *BlockChanges, len(backlog)+watcherBuffer),
+	}
+
+	for _, published := range backlog {
+		if filtered := w.filter(published); filtered != nil {
+			w.Events <- filtered
+		}
+	}
+
+	if ws.watching == nil {
+		ws.watching = make(map[*Watcher]bool)
+	}
+
+	ws.watching[w] = true
+
+	return w, nil
+}
+
+// Unwatch stops sending changes to w
+func (ws *Watchers) Unwatch(w *Watcher) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if ws.watching[w] {
+		close(w.Events)
+		delete(ws.watching, w)
+	}
+}
+
+// filter returns the changes in published that w is watching, or nil if there are none
+func (w *Watcher) filter(published *BlockChanges) *BlockChanges {
+	filtered := &BlockChanges{
+		BlockID: published.BlockID,
+		Changes: []*Change{},
+	}
+
+	for _, change := range published.Changes {
+		if change.Op == ChangeOpFlushAll {
+			filtered.Changes = append(filtered.Changes, change)
+			continue
+		}
+
+		if change.Namespace != w.Namespace {
+			continue
+		}
+
+		if change.Op == ChangeOpFlush || strings.HasPrefix(change.Key, w.Prefix) {
+			filtered.Changes = append(filtered.Changes, change)
+		}
+	}
+
+	if len(filtered.Changes) == 0 {
+		return nil
+	}
+
+	return filtered
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+// receiveBlocks reads the events already sent to w and returns the ID of each block
+func receiveBlocks(w *Watcher) []string {
+	blockIDs := []string{}
+
+	for true {
+		select {
+		case changes := <-w.Events:
+			blockIDs = append(blockIDs, changes.BlockID)
+		default:
+			return blockIDs
+		}
+	}
+
+	return blockIDs
+}
+
+func expectBlocks(t *testing.T, w *Watcher, expected ...string) {
+	t.Helper()
+
+	if received := receiveBlocks(w); fmt.Sprint(received) != fmt.Sprint(expected) {
+		t.Errorf("expected blocks %v, got %v", expected, received)
+	}
+}
+
+func TestWatchResume(t *testing.T) {
+	ws := &Watchers{HistorySize: 3}
+
+	for i := 1; i <= 5; i++ {
+		ws.Publish(fmt.Sprintf("b%d", i), []*Change{{Key: fmt.Sprintf("key%d", i), Op: ChangeOpSet}})
+	}
+
+	// only b3 to b5 are kept, so resuming from b3 still sees every block after it
+	w, err := ws.Watch("", "", "b3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBlocks(t, w, "b4", "b5")
+
+	ws.Publish("b6", []*Change{{Key: "key6", Op: ChangeOpSet}})
+	expectBlocks(t, w, "b6")
+
+	ws.Unwatch(w)
+
+	// b3 has just left the history, and skipping b4 would go unnoticed, so the watch must be refused
+	for _, fromBlock := range []string{"b1", "b3", "unknown"} {
+		if _, err := ws.Watch("", "", fromBlock); err != ErrWatchTooOld {
+			t.Errorf("expected resuming from %q to fail with %q, got %v", fromBlock, ErrWatchTooOld, err)
+		}
+	}
+
+	latest, err := ws.Watch("", "", "b6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBlocks(t, latest)
+}
+
+// blocks without changes are still recorded, so a watch can resume from one
+func TestWatchResumeFromEmptyBlock(t *testing.T) {
+	ws := &Watchers{}
+
+	ws.Publish("b1", []*Change{})
+	ws.Publish("b2", []*Change{{Key: "key", Op: ChangeOpDelete}})
+
+	w, err := ws.Watch("", "", "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBlocks(t, w, "b2")
+}
+
+func TestWatchFilter(t *testing.T) {
+	ws := &Watchers{}
+
+	inNamespace, _ := ws.Watch("ns", "a/", "")
+	inDefault, _ := ws.Watch("", "a/", "")
+
+	ws.Publish("b1", []*Change{{Namespace: "ns", Key: "a/1", Op: ChangeOpSet}})
+	ws.Publish("b2", []*Change{{Key: "a/1", Op: ChangeOpSet}})
+	ws.Publish("b3", []*Change{{Namespace: "ns", Key: "b/1", Op: ChangeOpSet}, {Namespace: "other", Key: "a/1", Op: ChangeOpSet}})
+	ws.Publish("b4", []*Change{{Namespace: "ns", Op: ChangeOpFlush}})
+	ws.Publish("b5", []*Change{{Op: ChangeOpFlushAll}})
+
+	expectBlocks(t, inNamespace, "b1", "b4", "b5")
+	expectBlocks(t, inDefault, "b2", "b5")
+
+	// a block's changes in other namespaces or outside the prefix are left out of its event
+	ws.Publish("b6", []*Change{{Namespace: "ns", Key: "a/2", Op: ChangeOpDelete}, {Key: "a/2", Op: ChangeOpSet}, {Namespace: "ns", Key: "b/2", Op: ChangeOpSet}})
+
+	changes := <-inNamespace.Events
+	if len(changes.Changes) != 1 || changes.Changes[0].Namespace != "ns" || changes.Changes[0].Key != "a/2" {
+		t.Errorf("expected only the change to ns a/2, got %+v", changes.Changes)
+	}
+}
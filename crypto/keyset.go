@@ -1,14 +1,20 @@
 package crypto
 
+import "sync"
+
 // KeySet represents all the keys a node needs to operate
 type KeySet struct {
 	GlobalKey *SymKey
 	KeyPair   *KeyPair
 	pairs     map[string]*KeyPair
+	lock      sync.RWMutex
 }
 
 // AddKeyPair adds a keyPair to the keySet
 func (aks *KeySet) AddKeyPair(pair *KeyPair) {
+	aks.lock.Lock()
+	defer aks.lock.Unlock()
+
 	if aks.pairs == nil {
 		aks.pairs = make(map[string]*KeyPair)
 	}
@@ -22,6 +28,9 @@ func (aks *KeySet) KeyPairWithKID(kid string) *KeyPair {
 		return aks.KeyPair
 	}
 
+	aks.lock.RLock()
+	defer aks.lock.RUnlock()
+
 	pair, ok := aks.pairs[kid]
 	if !ok {
 		return nil
@@ -29,3 +38,11 @@ func (aks *KeySet) KeyPairWithKID(kid string) *KeyPair {
 
 	return pair
 }
+
+// RemoveKeyPair removes the keyPair with KID from the keySet, so that nothing signed by it verifies anymore
+func (aks *KeySet) RemoveKeyPair(kid string) {
+	aks.lock.Lock()
+	defer aks.lock.Unlock()
+
+	delete(aks.pairs, kid)
+}
@@ -0,0 +1,134 @@
+package crypto
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// nodes join and are removed through the action worker while handlers look up the keys that signed their requests
+func TestKeySetVerifyWhileMembershipChanges(t *testing.T) {
+	const numPairs = 6
+
+	self, err := GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("request")
+
+	pubKeys := make([]*KeyPair, numPairs)
+	signatures := make([]*Signature, numPairs)
+
+	for i := range pubKeys {
+		pair, err := GenerateNewKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if signatures[i], err = pair.Sign(message); err != nil {
+			t.Fatal(err)
+		}
+
+		// other nodes only ever know each other's public keys
+		if pubKeys[i], err = KeyPairFromPubKeyJSON(pair.PubKeyJSON()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keySet := &KeySet{KeyPair: self}
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if keySet.KeyPairWithKID(self.KID) != self {
+					t.Error("expected the keySet's own keyPair to always be found")
+					return
+				}
+
+				for i, sig := range signatures {
+					pair := keySet.KeyPairWithKID(sig.KID)
+					if pair != nil && !pair.Verify(message, sig) {
+						t.Errorf("expected the key found for KID %s to verify its signature", sig.KID)
+						return
+					}
+
+					if pair != nil && pair != pubKeys[i] {
+						t.Errorf("expected KID %s to find the key that was added for it", sig.KID)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for round := 0; round < 200; round++ {
+		for _, pair := range pubKeys {
+			keySet.AddKeyPair(pair)
+		}
+
+		for i := 1; i < numPairs; i += 2 {
+			keySet.RemoveKeyPair(pubKeys[i].KID)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	for i, pair := range pubKeys {
+		removed := i%2 == 1
+
+		if (keySet.KeyPairWithKID(pair.KID) == nil) != removed {
+			t.Errorf("expected key %d removed to be %t", i, removed)
+		}
+	}
+}
+
+// the first keys a new node learns are added from several goroutines at once, before its map of keys exists
+func TestKeySetConcurrentFirstAdd(t *testing.T) {
+	const numAdders = 16
+
+	keySet := &KeySet{KeyPair: &KeyPair{KID: "self"}}
+	start := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < numAdders; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			<-start
+
+			// the keys are never used to sign anything, so they don't need to be generated
+			keySet.AddKeyPair(&KeyPair{KID: fmt.Sprintf("kid-%d", g)})
+		}(g)
+	}
+
+	close(start)
+	wg.Wait()
+
+	if len(keySet.pairs) != numAdders {
+		t.Errorf("expected %d keys, got %d", numAdders, len(keySet.pairs))
+	}
+
+	for g := 0; g < numAdders; g++ {
+		if keySet.KeyPairWithKID(fmt.Sprintf("kid-%d", g)) == nil {
+			t.Errorf("expected keyPair with KID kid-%d to be in the keySet", g)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -172,6 +174,65 @@ func (akp *KeyPair) PubKeyJSON() []byte {
 	return json
 }
 
+// Fingerprint returns a SHA-256 of the pubKey's modulus and exponent, which unlike its KID can't be changed by whoever holds the key
+func (akp *KeyPair) Fingerprint() string {
+	exponent := make([]byte, 8)
+	binary.BigEndian.PutUint64(exponent, uint64(akp.Public.E))
+
+	hasher := sha256.New()
+	hasher.Write(akp.Public.N.Bytes())
+	hasher.Write(exponent)
+
+	return Base64URLEncode(hasher.Sum(nil))
+}
+
+// PrivKeyJSON exports the KeyPair's private key to JSON using serializablePrivKey
+// this is only meant for persisting a node's own keyPair, never send it over the network
+func (akp *KeyPair) PrivKeyJSON() ([]byte, error) {
+	if akp.Private == nil {
+		return nil, errors.New("attempted to export nil private key")
+	}
+
+	serializable := serializablePrivKey{
+		Key: Base64URLEncode(x509.MarshalPKCS1PrivateKey(akp.Private)),
+		KID: akp.KID,
+	}
+
+	return json.Marshal(serializable)
+}
+
+// KeyPairFromPrivKeyJSON unmarshals a keyPair previously exported with PrivKeyJSON
+func KeyPairFromPrivKeyJSON(src []byte) (*KeyPair, error) {
+	serialized := &serializablePrivKey{}
+	if err := json.Unmarshal(src, serialized); err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := Base64URLDecode(serialized.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := x509.ParsePKCS1PrivateKey(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair := &KeyPair{
+		Private: priv,
+		Public:  &priv.PublicKey,
+		KID:     serialized.KID,
+	}
+
+	return keyPair, nil
+}
+
+// serializablePrivKey is a JSON-marshal-able version of rsa.PrivateKey
+type serializablePrivKey struct {
+	Key string `json:"key"`
+	KID string `json:"KID"`
+}
+
 // serializablePubKey is a JSON-marshal-able version of rsa.Publickey
 type serializablePubKey struct {
 	N   string `json:"N"`
@@ -0,0 +1,35 @@
+package crypto
+
+import "testing"
+
+func TestKeyPairFingerprint(t *testing.T) {
+	keyPair, err := GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a node picks its own KID, so sending the same key under a new one must not change the fingerprint
+	renamed := &KeyPair{Public: keyPair.Public, KID: generateNewKID()}
+
+	if keyPair.Fingerprint() != renamed.Fingerprint() {
+		t.Error("expected the same key under a new KID to have the same fingerprint")
+	}
+
+	received, err := KeyPairFromPubKeyJSON(keyPair.PubKeyJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyPair.Fingerprint() != received.Fingerprint() {
+		t.Error("expected a key sent as JSON to keep its fingerprint")
+	}
+
+	if keyPair.Fingerprint() == other.Fingerprint() {
+		t.Error("expected different keys to have different fingerprints")
+	}
+}
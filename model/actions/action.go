@@ -3,22 +3,124 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/pkg/errors"
 )
 
 // Action defines structs that can be called actions
+// Execute is passed the committed block the action came from
 type Action interface {
 	ActionType() string
 	JSON() []byte
-	Execute(*config.App) error
+	Execute(*config.App, *blockchain.Block) error
 }
 
+// ResultAction is an action that produces a value when it is executed, such as an increment
+type ResultAction interface {
+	Action
+	Result() string
+}
+
+// ChangeAction is an action that changes keys, which are sent to watchers once it has been executed successfully
+type ChangeAction interface {
+	Action
+	Changes() []*config.Change
+}
+
+// TimedAction is an action that reads and writes keys as of the time it was created, such as to decide whether a key has expired
+// SetTime lets it be executed as of a later time, since it must not be executed earlier than an action before it in the chain
+type TimedAction interface {
+	Action
+	Time() int64
+	SetTime(int64)
+}
+
+// AdvanceClock moves the app's clock forward to a timed action's timestamp, and has it execute no earlier than the clock
+// every node applies the same blocks in the same order, so every node executes the action as of the same time
+func AdvanceClock(app *config.App, action Action) {
+	if timed, ok := action.(TimedAction); ok {
+		timed.SetTime(app.Clock.Advance(timed.Time()))
+	}
+}
+
+// keyChange returns a change to a single key, or nothing if op is empty because the action didn't execute
+func keyChange(ns, key, op string) []*config.Change {
+	if op == "" {
+		return nil
+	}
+
+	change := &config.Change{
+		Namespace: ns,
+		Key:       key,
+		Op:        op,
+	}
+
+	return []*config.Change{change}
+}
+
+// useQuota counts changes against the quota of namespace ns as of timestamp, the default namespace has no quota
+// it fails with config.ErrQuotaExceeded on every node alike, since it is only ever called in chain order
+func useQuota(app *config.App, ns string, timestamp int64, changes ...*config.KeyUsage) error {
+	namespace := app.Namespaces.Get(ns)
+	if namespace == nil {
+		return nil
+	}
+
+	return namespace.Use(time.Unix(0, timestamp), changes...)
+}
+
+// keyUsage returns what key holding val counts against its namespace's quota
+func keyUsage(key string, val []byte, expiresAt time.Time) *config.KeyUsage {
+	return &config.KeyUsage{
+		Key:       key,
+		Size:      int64(len(key) + len(val)),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// deletedKeyUsage returns the usage of a key that has been deleted, which no longer counts against its namespace's quota
+func deletedKeyUsage(key string) *config.KeyUsage {
+	return &config.KeyUsage{
+		Key:     key,
+		Deleted: true,
+	}
+}
+
+// ErrVersionMismatch is returned from Execute when a conditional action's expected version does not match
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// VersionAny can be used as an expected version to match any existing version
+const VersionAny = "*"
+
 // ActionTypeNodeAdded and others represent different types of actions
 const (
-	ActionTypeNodeAdded = "astro.action.nodeadded"
-	ActionTypeSetValue  = "astro.action.setvalue"
+	ActionTypeNodeAdded     = "astro.action.nodeadded"
+	ActionTypeNodeRemoved   = "astro.action.noderemoved"
+	ActionTypeMasterElected = "astro.action.masterelected"
+	ActionTypeSetValue      = "astro.action.setvalue"
+	ActionTypeDeleteValue   = "astro.action.deletevalue"
+
+	ActionTypeIncrementValue = "astro.action.incrementvalue"
+	ActionTypeBatchSet       = "astro.action.batchset"
+
+	ActionTypeActionBatch = "astro.action.batch"
+
+	ActionTypeNamespaceCreated = "astro.action.namespacecreated"
+	ActionTypeNamespaceDeleted = "astro.action.namespacedeleted"
+	ActionTypeFlushNamespace   = "astro.action.flushnamespace"
+	ActionTypeFlushAll         = "astro.action.flushall"
+
+	ActionTypeHashSet    = "astro.action.hashset"
+	ActionTypeHashDelete = "astro.action.hashdelete"
+	ActionTypeListPush   = "astro.action.listpush"
+	ActionTypeListPop    = "astro.action.listpop"
+	ActionTypeSetAdd     = "astro.action.setadd"
+	ActionTypeSetRemove  = "astro.action.setremove"
+
+	ActionTypeWorkerReassigned = "astro.action.workerreassigned"
 )
 
 // UnmarshalAction unmarshals an action from JSON
@@ -29,6 +131,27 @@ func UnmarshalAction(actionJSON []byte, actionType string) (Action, error) {
 			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
 		}
 
+		return action, nil
+	} else if actionType == ActionTypeNodeRemoved {
+		action := &NodeRemoved{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeMasterElected {
+		action := &MasterElected{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeWorkerReassigned {
+		action := &WorkerReassigned{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
 		return action, nil
 	} else if actionType == ActionTypeSetValue {
 		action := &SetValue{}
@@ -36,6 +159,104 @@ func UnmarshalAction(actionJSON []byte, actionType string) (Action, error) {
 			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
 		}
 
+		return action, nil
+	} else if actionType == ActionTypeDeleteValue {
+		action := &DeleteValue{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeIncrementValue {
+		action := &IncrementValue{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeBatchSet {
+		action := &BatchSet{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeActionBatch {
+		action := &ActionBatch{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeNamespaceCreated {
+		action := &NamespaceCreated{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeNamespaceDeleted {
+		action := &NamespaceDeleted{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeFlushNamespace {
+		action := &FlushNamespace{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeFlushAll {
+		action := &FlushAll{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeHashSet {
+		action := &HashSet{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeHashDelete {
+		action := &HashDelete{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeListPush {
+		action := &ListPush{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeListPop {
+		action := &ListPop{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeSetAdd {
+		action := &SetAdd{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
+		return action, nil
+	} else if actionType == ActionTypeSetRemove {
+		action := &SetRemove{}
+		if err := json.Unmarshal(actionJSON, action); err != nil {
+			return nil, errors.Wrap(err, "UnmarshalAction failed to Unmarshal")
+		}
+
 		return action, nil
 	}
 
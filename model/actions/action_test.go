@@ -0,0 +1,28 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+)
+
+// newTestApp returns an app for a node of nodeType with an empty cache, which is enough to execute most actions
+func newTestApp(nodeType string) *config.App {
+	return &config.App{
+		Self:     &model.Node{NID: "self", Type: nodeType},
+		Cache:    cache.EmptyCache(),
+		NodeList: &config.NodeList{},
+	}
+}
+
+// execute executes action as if it had been committed in a block with id, advancing the app's clock as the action worker does
+func execute(t *testing.T, app *config.App, action Action, id string) error {
+	t.Helper()
+
+	AdvanceClock(app, action)
+
+	return action.Execute(app, &blockchain.Block{ID: id})
+}
@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// ActionBatch is a block value holding several independent actions that were grouped into one block
+// unlike BatchSet, each action succeeds or fails on its own, and they are executed in order
+// each action versions the keys it writes with its own version rather than the block's ID, so two writes to a key in one batch don't share an ETag
+type ActionBatch struct {
+	Actions []*BatchedAction `json:"actions"`
+
+	results []*blockchain.ActionResult
+	changes []*config.Change
+}
+
+// BatchedAction is a single action in an ActionBatch
+type BatchedAction struct {
+	ActionType string          `json:"actionType"`
+	Action     json.RawMessage `json:"action"`
+}
+
+// NewActionBatch creates an ActionBatch
+func NewActionBatch(batched []*BatchedAction) *ActionBatch {
+	return &ActionBatch{
+		Actions: batched,
+	}
+}
+
+// ActionType defines this action's type
+func (ab *ActionBatch) ActionType() string {
+	return ActionTypeActionBatch
+}
+
+// JSON returns json for the action
+func (ab *ActionBatch) JSON() []byte {
+	abJSON, _ := json.Marshal(ab)
+
+	return abJSON
+}
+
+// Results returns the result of each action in the batch after it was executed
+func (ab *ActionBatch) Results() []*blockchain.ActionResult {
+	return ab.results
+}
+
+// Changes returns the keys changed by every action in the batch that succeeded
+func (ab *ActionBatch) Changes() []*config.Change {
+	return ab.changes
+}
+
+// Execute executes every action in the batch, a failed action does not stop the ones after it
+func (ab *ActionBatch) Execute(app *config.App, block *blockchain.Block) error {
+	ab.results = make([]*blockchain.ActionResult, len(ab.Actions))
+	ab.changes = []*config.Change{}
+
+	for i, batched := range ab.Actions {
+		result := &blockchain.ActionResult{}
+		ab.results[i] = result
+
+		if batched.ActionType == ActionTypeActionBatch {
+			result.Err = fmt.Errorf("ActionBatch.Execute found nested batch at index %d", i)
+			continue
+		}
+
+		action, err := UnmarshalAction(batched.Action, batched.ActionType)
+		if err != nil {
+			result.Err = errors.Wrapf(err, "ActionBatch.Execute failed to UnmarshalAction at index %d", i)
+			continue
+		}
+
+		AdvanceClock(app, action)
+
+		// actions use the ID of the block they are executed with as the version of what they write
+		versioned := *block
+		versioned.ID = batchedVersion(block.ID, i)
+
+		result.Version = versioned.ID
+
+		if err := action.Execute(app, &versioned); err != nil {
+			result.Err = errors.Wrapf(err, "ActionBatch.Execute failed to Execute at index %d", i)
+			continue
+		}
+
+		if resultAction, ok := action.(ResultAction); ok {
+			result.Value = resultAction.Result()
+		}
+
+		if changeAction, ok := action.(ChangeAction); ok {
+			ab.changes = append(ab.changes, changeAction.Changes()...)
+		}
+	}
+
+	return nil
+}
+
+// batchedVersion returns the version of keys written by the action at index in a batch committed in the block with blockID
+// block IDs are base64url encoded, so they never contain the separator and no two actions get the same version
+func batchedVersion(blockID string, index int) string {
+	return fmt.Sprintf("%s.%d", blockID, index)
+}
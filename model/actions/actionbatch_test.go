@@ -0,0 +1,130 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/model"
+	"github.com/pkg/errors"
+)
+
+func batchedAction(action Action) *BatchedAction {
+	return &BatchedAction{
+		ActionType: action.ActionType(),
+		Action:     action.JSON(),
+	}
+}
+
+func TestActionBatchResults(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	batch := NewActionBatch([]*BatchedAction{
+		batchedAction(NewSetValue("", "a", []byte("first"), "", 0, "")),
+		batchedAction(NewSetValue("", "b", []byte("stale"), "", 0, "other")),
+		batchedAction(NewIncrementValue("", "counter", 5, 0)),
+		batchedAction(NewActionBatch(nil)),
+		{ActionType: "unknown", Action: []byte("{}")},
+		batchedAction(NewIncrementValue("", "counter", 2, 0)),
+	})
+
+	if err := execute(t, app, batch, "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	results := batch.Results()
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+
+	// a failed action doesn't stop the ones after it
+	for i, failed := range []bool{false, true, false, true, true, false} {
+		if failed && results[i].Err == nil {
+			t.Errorf("expected action at index %d to fail", i)
+		} else if !failed && results[i].Err != nil {
+			t.Errorf("expected action at index %d to succeed, got %v", i, results[i].Err)
+		}
+	}
+
+	if errors.Cause(results[1].Err) != ErrVersionMismatch {
+		t.Errorf("expected %q for the conditional write, got %v", ErrVersionMismatch, results[1].Err)
+	}
+
+	if results[2].Value != "5" || results[5].Value != "7" {
+		t.Errorf("expected each increment to see the one before it, got %q and %q", results[2].Value, results[5].Value)
+	}
+
+	changed := []string{}
+	for _, change := range batch.Changes() {
+		changed = append(changed, change.Key)
+	}
+
+	if len(changed) != 3 || changed[0] != "a" || changed[1] != "counter" || changed[2] != "counter" {
+		t.Errorf("expected only the actions that succeeded to report changes, got %v", changed)
+	}
+
+	if _, ok := app.Cache.ItemForKey("b"); ok {
+		t.Error("expected the failed conditional write not to set its key")
+	}
+
+	if item, ok := app.Cache.ItemForKey("a"); !ok || item.Version != "block.0" || results[0].Version != item.Version {
+		t.Error("expected the batched write to be versioned by its block's ID and its index, and to report that version")
+	}
+}
+
+func TestActionBatchSameKey(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	batch := NewActionBatch([]*BatchedAction{
+		batchedAction(NewSetValue("", "key", []byte("first"), "", 0, "")),
+		batchedAction(NewSetValue("", "key", []byte("second"), "", 0, "")),
+	})
+
+	if err := execute(t, app, batch, "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := batch.Results()[0], batch.Results()[1]
+
+	if first.Version == second.Version {
+		t.Fatalf("expected two writes to one key in a batch to get different versions, both got %q", first.Version)
+	}
+
+	item, ok := app.Cache.ItemForKey("key")
+	if !ok || string(item.Value) != "second" || item.Version != second.Version {
+		t.Fatalf("expected the key to hold the second write under its version, got %+v", item)
+	}
+
+	// a conditional write with the intermediate value's version must not overwrite the newer value
+	stale := NewSetValue("", "key", []byte("stale"), "", 0, first.Version)
+	if err := execute(t, app, stale, "next"); errors.Cause(err) != ErrVersionMismatch {
+		t.Errorf("expected %q for a write conditional on the first write's version, got %v", ErrVersionMismatch, err)
+	}
+
+	current := NewSetValue("", "key", []byte("current"), "", 0, second.Version)
+	if err := execute(t, app, current, "next"); err != nil {
+		t.Errorf("expected a write conditional on the second write's version to succeed, got %v", err)
+	}
+}
+
+func TestActionBatchAdvancesClock(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	start := time.Now().UnixNano()
+
+	later := NewSetValue("", "later", []byte("value"), "", 0, "")
+	later.Timestamp = start + int64(time.Second)
+
+	earlier := NewSetValue("", "earlier", []byte("value"), "", 0, "")
+	earlier.Timestamp = start
+
+	batch := NewActionBatch([]*BatchedAction{batchedAction(later), batchedAction(earlier)})
+
+	if err := execute(t, app, batch, "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	// each action in the batch moves the clock on, and one created earlier than the one before it doesn't move it back
+	if !app.Clock.Now().Equal(time.Unix(0, later.Timestamp)) {
+		t.Errorf("expected the clock to be at %d, got %s", later.Timestamp, app.Clock.Now())
+	}
+}
@@ -0,0 +1,145 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// BatchSet is a block value representing many keys being set and deleted at once
+// every condition is checked before anything is changed, so either the whole batch is applied or none of it is
+// every key is in Namespace, which is empty for the default namespace
+type BatchSet struct {
+	Sets      []*BatchSetValue `json:"sets,omitempty"`
+	Deletes   []string         `json:"deletes,omitempty"`
+	Timestamp int64            `json:"timestamp"`
+	Namespace string           `json:"namespace,omitempty"`
+}
+
+// BatchSetValue is a single key being set in a BatchSet, its fields behave the same as SetValue's
+type BatchSetValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	TTL         int64  `json:"ttl,omitempty"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+}
+
+// Bytes returns the value being set
+func (bsv *BatchSetValue) Bytes() []byte {
+	return valueBytes(bsv.Data, bsv.Value)
+}
+
+// NewBatchSet creates a BatchSet
+func NewBatchSet(ns string, sets []*BatchSetValue, deletes []string) *BatchSet {
+	return &BatchSet{
+		Namespace: ns,
+		Sets:      sets,
+		Deletes:   deletes,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (bs *BatchSet) ActionType() string {
+	return ActionTypeBatchSet
+}
+
+// JSON returns json for the action
+func (bs *BatchSet) JSON() []byte {
+	bsJSON, _ := json.Marshal(bs)
+
+	return bsJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (bs *BatchSet) Time() int64 {
+	return bs.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (bs *BatchSet) SetTime(timestamp int64) {
+	bs.Timestamp = timestamp
+}
+
+// Changes returns every key set and deleted by the action
+func (bs *BatchSet) Changes() []*config.Change {
+	changes := make([]*config.Change, 0, len(bs.Sets)+len(bs.Deletes))
+
+	for _, set := range bs.Sets {
+		changes = append(changes, keyChange(bs.Namespace, set.Key, config.ChangeOpSet)...)
+	}
+
+	for _, key := range bs.Deletes {
+		changes = append(changes, keyChange(bs.Namespace, key, config.ChangeOpDelete)...)
+	}
+
+	return changes
+}
+
+// Execute sets and deletes every key in the batch, with the block's ID as the version of each key set
+func (bs *BatchSet) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(bs.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "BatchSet.Execute failed to CacheForNamespace")
+	}
+
+	for _, set := range bs.Sets {
+		if err := checkVersion(c, set.Key, set.IfMatch, bs.Timestamp); err == ErrVersionUnknown {
+			bs.forget(c)
+			return errors.Wrapf(err, "BatchSet.Execute forgot every key in the batch because of key %q", set.Key)
+		} else if err != nil {
+			return errors.Wrap(err, "BatchSet.Execute failed to checkVersion")
+		}
+	}
+
+	usage := []*config.KeyUsage{}
+	for _, set := range bs.Sets {
+		usage = append(usage, keyUsage(set.Key, set.Bytes(), expiresAt(bs.Timestamp, set.TTL)))
+	}
+
+	for _, key := range bs.Deletes {
+		usage = append(usage, deletedKeyUsage(key))
+	}
+
+	if err := useQuota(app, bs.Namespace, bs.Timestamp, usage...); err != nil {
+		return errors.Wrap(err, "BatchSet.Execute failed to useQuota")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Setting %d values and deleting %d keys", len(bs.Sets), len(bs.Deletes)))
+
+	for _, set := range bs.Sets {
+		c.SetVersionedValueForKey(set.Bytes(), set.ContentType, set.Key, block.ID, expiresAt(bs.Timestamp, set.TTL))
+	}
+
+	for _, key := range bs.Deletes {
+		c.DeleteValueForKey(key)
+	}
+
+	return nil
+}
+
+// forget forgets every key in the batch, for when this node can't tell whether the batch was applied
+func (bs *BatchSet) forget(c *cache.Cache) {
+	logger.LogInfo(fmt.Sprintf("Version of a key in batch is unknown, forgetting %d keys", len(bs.Sets)+len(bs.Deletes)))
+
+	for _, set := range bs.Sets {
+		c.ForgetKey(set.Key)
+	}
+
+	for _, key := range bs.Deletes {
+		c.ForgetKey(key)
+	}
+}
@@ -0,0 +1,113 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/model"
+)
+
+func TestTimedActionsExecuteInOrder(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	start := time.Now().UnixNano()
+
+	later := NewSetValue("", "later", []byte("value"), "", 0, "")
+	later.Timestamp = start + int64(time.Second)
+
+	earlier := NewSetValue("", "earlier", []byte("value"), "", 1, "")
+	earlier.Timestamp = start
+
+	if err := execute(t, app, later, "later"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, earlier, "earlier"); err != nil {
+		t.Fatal(err)
+	}
+
+	if earlier.Timestamp != later.Timestamp {
+		t.Errorf("expected an action created earlier to be executed as of %d, got %d", later.Timestamp, earlier.Timestamp)
+	}
+
+	if expected := time.Unix(0, later.Timestamp).Add(time.Second); !earlier.ExpiresAt().Equal(expected) {
+		t.Errorf("expected the value to expire at %s, got %s", expected, earlier.ExpiresAt())
+	}
+
+	if !app.Clock.Now().Equal(time.Unix(0, later.Timestamp)) {
+		t.Errorf("expected the clock to be at %d, got %s", later.Timestamp, app.Clock.Now())
+	}
+}
+
+// replicas sweep at different times, but a block applied after a sweep must give the same result on each of them
+func TestSweepDoesNotDivergeReplicas(t *testing.T) {
+	start := time.Now().UnixNano()
+
+	results := []string{}
+
+	for _, sweep := range []bool{false, true} {
+		app := newTestApp(model.NodeTypeWorker)
+
+		set := NewSetValue("", "counter", []byte("5"), "", 1, "")
+		set.Timestamp = start
+
+		other := NewSetValue("", "other", []byte("value"), "", 0, "")
+		other.Timestamp = start + int64(5*time.Second)
+
+		// created before the counter expired, but committed after the other write
+		incr := NewIncrementValue("", "counter", 1, 0)
+		incr.Timestamp = start + int64(500*time.Millisecond)
+
+		if err := execute(t, app, set, "set"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := execute(t, app, other, "other"); err != nil {
+			t.Fatal(err)
+		}
+
+		if sweep {
+			if removed := app.Cache.Sweep(app.Clock.Now()); removed != 1 {
+				t.Errorf("expected the sweep to remove 1 value, removed %d", removed)
+			}
+		}
+
+		if err := execute(t, app, incr, "incr"); err != nil {
+			t.Fatal(err)
+		}
+
+		results = append(results, incr.Result())
+	}
+
+	if results[0] != results[1] {
+		t.Errorf("expected every replica to increment to the same value, got %q without sweeping and %q after", results[0], results[1])
+	}
+
+	if results[0] != "1" {
+		t.Errorf("expected the counter to have expired before the increment was executed, got %q", results[0])
+	}
+}
+
+func TestSweepKeepsValuesAliveAsOfTheClock(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if !app.Clock.Now().IsZero() {
+		t.Error("expected the clock to start at the zero time, so nothing is swept before a block is applied")
+	}
+
+	set := NewSetValue("", "key", []byte("value"), "", 60, "")
+	set.Timestamp = time.Now().Add(-2 * time.Minute).UnixNano()
+
+	if err := execute(t, app, set, "set"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the value has expired by now, but no block has been applied as of then
+	if removed := app.Cache.Sweep(app.Clock.Now()); removed != 0 {
+		t.Errorf("expected a value that hasn't expired as of the last applied action not to be swept, removed %d", removed)
+	}
+
+	if removed := app.Cache.Sweep(time.Now()); removed != 1 {
+		t.Fatalf("expected the value to have expired by now, removed %d", removed)
+	}
+}
@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// DeleteValue is a block value representing the removal of a key from the cache
+// IfMatch, if set, is the version the key must have for it to be deleted, as of Timestamp (in unix nanoseconds)
+// Namespace is empty for the default namespace
+type DeleteValue struct {
+	Key       string `json:"key"`
+	IfMatch   string `json:"ifMatch,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// NewDeleteValue creates a DeleteValue
+func NewDeleteValue(ns, key, ifMatch string) *DeleteValue {
+	return &DeleteValue{
+		Key:       key,
+		IfMatch:   ifMatch,
+		Timestamp: time.Now().UnixNano(),
+		Namespace: ns,
+	}
+}
+
+// ActionType defines this action's type
+func (dv *DeleteValue) ActionType() string {
+	return ActionTypeDeleteValue
+}
+
+// JSON returns json for the action
+func (dv *DeleteValue) JSON() []byte {
+	dvJSON, _ := json.Marshal(dv)
+
+	return dvJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (dv *DeleteValue) Time() int64 {
+	return dv.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (dv *DeleteValue) SetTime(timestamp int64) {
+	dv.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (dv *DeleteValue) Changes() []*config.Change {
+	return keyChange(dv.Namespace, dv.Key, config.ChangeOpDelete)
+}
+
+// Execute removes the key from the cache, checking its version first the same way SetValue.Execute does
+func (dv *DeleteValue) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(dv.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "DeleteValue.Execute failed to CacheForNamespace")
+	}
+
+	if err := checkVersion(c, dv.Key, dv.IfMatch, dv.Timestamp); err == ErrVersionUnknown {
+		logger.LogInfo(fmt.Sprintf("Version of key %q is unknown, forgetting it", dv.Key))
+
+		c.ForgetKey(dv.Key)
+		return errors.Wrapf(err, "DeleteValue.Execute forgot key %q", dv.Key)
+	} else if err != nil {
+		return errors.Wrap(err, "DeleteValue.Execute failed to checkVersion")
+	}
+
+	// a delete never takes a namespace over its quota, so it doesn't expire other keys to make room
+	if err := useQuota(app, dv.Namespace, 0, deletedKeyUsage(dv.Key)); err != nil {
+		return errors.Wrap(err, "DeleteValue.Execute failed to useQuota")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Deleting value for key %q", dv.Key))
+
+	c.DeleteValueForKey(dv.Key)
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// FlushNamespace is a block value representing every key in a namespace being removed
+// the namespace itself is kept, and an empty Namespace is the default namespace
+type FlushNamespace struct {
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// NewFlushNamespace creates a FlushNamespace
+func NewFlushNamespace(ns string) *FlushNamespace {
+	return &FlushNamespace{
+		Namespace: ns,
+	}
+}
+
+// ActionType defines this action's type
+func (fn *FlushNamespace) ActionType() string {
+	return ActionTypeFlushNamespace
+}
+
+// JSON returns json for the action
+func (fn *FlushNamespace) JSON() []byte {
+	fnJSON, _ := json.Marshal(fn)
+
+	return fnJSON
+}
+
+// Changes returns a flush of the namespace
+func (fn *FlushNamespace) Changes() []*config.Change {
+	return keyChange(fn.Namespace, "", config.ChangeOpFlush)
+}
+
+// Execute removes every key in the namespace
+// actions are executed while no reads can View the cache, so a flush is never seen half done
+func (fn *FlushNamespace) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(fn.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "FlushNamespace.Execute failed to CacheForNamespace")
+	}
+
+	// the default namespace has no quota
+	if namespace := app.Namespaces.Get(fn.Namespace); namespace != nil {
+		namespace.ResetUsage()
+	}
+
+	removed, err := c.Flush()
+	if err != nil {
+		return errors.Wrap(err, "FlushNamespace.Execute failed to Flush")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Flushed %d values from namespace %q at block with ID %s", removed, fn.Namespace, block.ID))
+
+	return nil
+}
+
+// FlushAll is a block value representing every key in every namespace being removed
+// namespaces themselves are kept
+type FlushAll struct{}
+
+// NewFlushAll creates a FlushAll
+func NewFlushAll() *FlushAll {
+	return &FlushAll{}
+}
+
+// ActionType defines this action's type
+func (fa *FlushAll) ActionType() string {
+	return ActionTypeFlushAll
+}
+
+// JSON returns json for the action
+func (fa *FlushAll) JSON() []byte {
+	faJSON, _ := json.Marshal(fa)
+
+	return faJSON
+}
+
+// Changes returns a flush of every namespace
+func (fa *FlushAll) Changes() []*config.Change {
+	return keyChange("", "", config.ChangeOpFlushAll)
+}
+
+// Execute removes every key from the default cache and every namespace
+func (fa *FlushAll) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	removed, err := app.Cache.Flush()
+	if err != nil {
+		return errors.Wrap(err, "FlushAll.Execute failed to Flush")
+	}
+
+	for _, ns := range app.Namespaces.All() {
+		nsRemoved, err := ns.Flush()
+		if err != nil {
+			return errors.Wrap(err, "FlushAll.Execute failed to Flush namespace "+ns.Name)
+		}
+
+		removed += nsRemoved
+	}
+
+	logger.LogInfo(fmt.Sprintf("Flushed %d values from all namespaces at block with ID %s", removed, block.ID))
+
+	return nil
+}
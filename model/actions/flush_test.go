@@ -0,0 +1,62 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model"
+	"github.com/pkg/errors"
+)
+
+func TestFlushDefaultNamespace(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	app.Cache.SetValueForKey([]byte("value"), "key")
+
+	if err := execute(t, app, NewFlushNamespace(""), "flush"); err != nil {
+		t.Fatalf("expected the default namespace to be flushed, got %s", err)
+	}
+
+	if app.Cache.ValueForKey("key") != nil {
+		t.Error("expected key to be flushed from the default cache")
+	}
+}
+
+func TestFlushNamedNamespaceResetsQuota(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewNamespaceCreated("ns", 1, 0, ""), "create"); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Cache.SetValueForKey([]byte("value"), "key")
+
+	if err := execute(t, app, NewSetValue("ns", "a", []byte("1"), "", 0, ""), "set-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewFlushNamespace("ns"), "flush"); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := app.Namespaces.Get("ns").Usage(); entries != 0 {
+		t.Errorf("expected no entries to count against the quota after a flush, got %d", entries)
+	}
+
+	if app.Cache.ValueForKey("key") == nil {
+		t.Error("expected flushing a namespace to leave the default cache alone")
+	}
+
+	// the quota of 1 has room again
+	if err := execute(t, app, NewSetValue("ns", "b", []byte("2"), "", 0, ""), "set-b"); err != nil {
+		t.Errorf("expected a write to fit after the flush, got %s", err)
+	}
+}
+
+func TestFlushUnknownNamespace(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewFlushNamespace("nope"), "flush"); errors.Cause(err) != config.ErrNoNamespace {
+		t.Errorf("expected ErrNoNamespace, got %v", err)
+	}
+}
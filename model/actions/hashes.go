@@ -0,0 +1,177 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// HashSet is a block value representing fields being set in a hash, other fields are left alone
+// the hash is created with TTL if it doesn't exist yet
+type HashSet struct {
+	Key       string            `json:"key"`
+	Fields    map[string]string `json:"fields"`
+	TTL       int64             `json:"ttl,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+	Namespace string            `json:"namespace,omitempty"`
+
+	op string
+}
+
+// NewHashSet creates a HashSet
+func NewHashSet(ns, key string, fields map[string]string, ttl int64) *HashSet {
+	return &HashSet{
+		Namespace: ns,
+		Key:       key,
+		Fields:    fields,
+		TTL:       ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (hs *HashSet) ActionType() string {
+	return ActionTypeHashSet
+}
+
+// JSON returns json for the action
+func (hs *HashSet) JSON() []byte {
+	hsJSON, _ := json.Marshal(hs)
+
+	return hsJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (hs *HashSet) Time() int64 {
+	return hs.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (hs *HashSet) SetTime(timestamp int64) {
+	hs.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (hs *HashSet) Changes() []*config.Change {
+	return keyChange(hs.Namespace, hs.Key, hs.op)
+}
+
+// Execute sets the fields in the hash, with the block's ID as its new version
+func (hs *HashSet) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(hs.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "HashSet.Execute failed to CacheForNamespace")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Setting %d fields in hash %q", len(hs.Fields), hs.Key))
+
+	hs.op, _, err = updateStructure(app, hs.Namespace, c, hs.Key, ContentTypeHash, hs.Timestamp, hs.TTL, block.ID, func(current []byte) ([]byte, error) {
+		hash, err := DecodeHash(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for field, val := range hs.Fields {
+			hash[field] = val
+		}
+
+		return encodeHash(hash)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "HashSet.Execute failed to updateStructure")
+	}
+
+	return nil
+}
+
+// HashDelete is a block value representing fields being removed from a hash
+// the hash is deleted if it has no fields left
+type HashDelete struct {
+	Key       string   `json:"key"`
+	Fields    []string `json:"fields"`
+	Timestamp int64    `json:"timestamp"`
+	Namespace string   `json:"namespace,omitempty"`
+
+	op string
+}
+
+// NewHashDelete creates a HashDelete
+func NewHashDelete(ns, key string, fields []string) *HashDelete {
+	return &HashDelete{
+		Namespace: ns,
+		Key:       key,
+		Fields:    fields,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (hd *HashDelete) ActionType() string {
+	return ActionTypeHashDelete
+}
+
+// JSON returns json for the action
+func (hd *HashDelete) JSON() []byte {
+	hdJSON, _ := json.Marshal(hd)
+
+	return hdJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (hd *HashDelete) Time() int64 {
+	return hd.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (hd *HashDelete) SetTime(timestamp int64) {
+	hd.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (hd *HashDelete) Changes() []*config.Change {
+	return keyChange(hd.Namespace, hd.Key, hd.op)
+}
+
+// Execute removes the fields from the hash, with the block's ID as its new version
+func (hd *HashDelete) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(hd.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "HashDelete.Execute failed to CacheForNamespace")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Deleting %d fields from hash %q", len(hd.Fields), hd.Key))
+
+	hd.op, _, err = updateStructure(app, hd.Namespace, c, hd.Key, ContentTypeHash, hd.Timestamp, 0, block.ID, func(current []byte) ([]byte, error) {
+		hash, err := DecodeHash(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range hd.Fields {
+			delete(hash, field)
+		}
+
+		return encodeHash(hash)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "HashDelete.Execute failed to updateStructure")
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// ErrNotNumeric is returned from Execute when a key being incremented holds a value that isn't an integer
+var ErrNotNumeric = errors.New("value is not an integer")
+
+// ErrOverflow is returned from Execute when an increment would overflow an int64
+var ErrOverflow = errors.New("increment would overflow")
+
+// IncrementValue is a block value representing a key's integer value being changed by Delta
+// a missing (or expired) key is treated as 0, and is created with TTL if one is set
+// an existing key keeps its expiry, and its value must be a base 10 int64 or the increment fails and changes nothing
+type IncrementValue struct {
+	Key       string `json:"key"`
+	Delta     int64  `json:"delta"`
+	TTL       int64  `json:"ttl,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Namespace string `json:"namespace,omitempty"`
+
+	result string
+}
+
+// NewIncrementValue creates an IncrementValue
+func NewIncrementValue(ns, key string, delta, ttl int64) *IncrementValue {
+	return &IncrementValue{
+		Namespace: ns,
+		Key:       key,
+		Delta:     delta,
+		TTL:       ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (iv *IncrementValue) ActionType() string {
+	return ActionTypeIncrementValue
+}
+
+// JSON returns json for the action
+func (iv *IncrementValue) JSON() []byte {
+	ivJSON, _ := json.Marshal(iv)
+
+	return ivJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (iv *IncrementValue) Time() int64 {
+	return iv.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (iv *IncrementValue) SetTime(timestamp int64) {
+	iv.Timestamp = timestamp
+}
+
+// Result returns the value of the key after the increment was executed
+func (iv *IncrementValue) Result() string {
+	return iv.result
+}
+
+// Changes returns the key changed by the action
+func (iv *IncrementValue) Changes() []*config.Change {
+	return keyChange(iv.Namespace, iv.Key, config.ChangeOpSet)
+}
+
+// Execute changes the key's value by Delta, with the block's ID as its new version
+// the key is read as of Timestamp rather than now, so that every node computes the same result
+func (iv *IncrementValue) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(iv.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "IncrementValue.Execute failed to CacheForNamespace")
+	}
+
+	at := time.Unix(0, iv.Timestamp)
+
+	item, expiresAt, ok := c.ItemForKeyAt(iv.Key, at)
+	if !ok {
+		// this node evicted the key so it can't know the result, it stays missing here
+		logger.LogInfo(fmt.Sprintf("Incrementing evicted key %q, value stays unknown", iv.Key))
+
+		c.SetEvictedVersionForKey(iv.Key, block.ID, expiresAt)
+		return nil
+	}
+
+	var current []byte
+	if item != nil {
+		// values are only dropped when they aren't numbers, see KeepsValue
+		if item.Dropped {
+			return errors.Wrapf(ErrNotNumeric, "key %q", iv.Key)
+		}
+
+		current = item.Value
+	}
+
+	next, err := incrementedValue(string(current), iv.Delta)
+	if err != nil {
+		return errors.Wrapf(err, "IncrementValue.Execute failed to increment key %q", iv.Key)
+	}
+
+	if len(current) == 0 && iv.TTL > 0 {
+		expiresAt = at.Add(time.Duration(iv.TTL) * time.Second)
+	}
+
+	if err := useQuota(app, iv.Namespace, iv.Timestamp, keyUsage(iv.Key, []byte(next), expiresAt)); err != nil {
+		return errors.Wrap(err, "IncrementValue.Execute failed to useQuota")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Incrementing value for key %q by %d to %s", iv.Key, iv.Delta, next))
+
+	c.SetVersionedValueForKey([]byte(next), "", iv.Key, block.ID, expiresAt)
+
+	iv.result = next
+
+	return nil
+}
+
+func incrementedValue(current string, delta int64) (string, error) {
+	value := int64(0)
+
+	if current != "" {
+		parsed, err := strconv.ParseInt(current, 10, 64)
+		if err != nil {
+			return "", ErrNotNumeric
+		}
+
+		value = parsed
+	}
+
+	next := value + delta
+	if (delta > 0 && next < value) || (delta < 0 && next > value) {
+		return "", ErrOverflow
+	}
+
+	return strconv.FormatInt(next, 10), nil
+}
+
+// KeepsValue returns whether a value may be needed to execute a later action, verifiers only keep these values
+// increments need numbers and structure actions need structures, every other value is only ever read from workers
+func KeepsValue(contentType string, val []byte) bool {
+	switch contentType {
+	case ContentTypeHash, ContentTypeList, ContentTypeSet:
+		return true
+	}
+
+	_, err := incrementedValue(string(val), 0)
+
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/model"
+	"github.com/pkg/errors"
+)
+
+func TestIncrementValue(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+
+	for i, expected := range []string{"2", "4", "1"} {
+		delta := int64(2)
+		if i == 2 {
+			delta = -3
+		}
+
+		incr := NewIncrementValue("", "counter", delta, 0)
+
+		if err := execute(t, app, incr, "incr"); err != nil {
+			t.Fatal(err)
+		}
+
+		if incr.Result() != expected {
+			t.Errorf("expected %q after increment %d, got %q", expected, i, incr.Result())
+		}
+	}
+
+	if err := execute(t, app, NewSetValue("", "text", []byte("abc"), "", 0, ""), "set"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewIncrementValue("", "text", 1, 0), "incr"); errors.Cause(err) != ErrNotNumeric {
+		t.Errorf("expected %q incrementing a value that isn't a number, got %v", ErrNotNumeric, err)
+	}
+}
+
+// a node with a bounded cache can't know the result of incrementing a key it evicted, so the verifier replies without a value
+func TestIncrementEvictedKey(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+
+	c, err := cache.NewCache(&cache.Options{MaxEntries: 1, Policy: cache.PolicyLRU})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Cache = c
+
+	for _, key := range []string{"counter", "other"} {
+		if err := execute(t, app, NewIncrementValue("", key, 1, 0), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	incr := NewIncrementValue("", "counter", 1, 0)
+
+	if err := execute(t, app, incr, "evicted"); err != nil {
+		t.Fatal(err)
+	}
+
+	if incr.Result() != "" {
+		t.Errorf("expected no result incrementing an evicted key, got %q", incr.Result())
+	}
+
+	// its version is still tracked so that conditional writes agree with the other nodes
+	if version, _ := c.VersionForKey("counter", time.Now()); version != "evicted" {
+		t.Errorf("expected the evicted key to have the increment's version, got %q", version)
+	}
+
+	if _, ok := c.ItemForKey("counter"); ok {
+		t.Error("expected the evicted key's value to stay missing")
+	}
+}
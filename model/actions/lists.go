@@ -0,0 +1,214 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// ListPush is a block value representing values being pushed onto the right (end) of a list, or the left (start) if Left is set
+// values are pushed one at a time, so pushing a then b onto the left leaves b first
+// the list is created with TTL if it doesn't exist yet
+type ListPush struct {
+	Key       string   `json:"key"`
+	Values    []string `json:"values"`
+	Left      bool     `json:"left,omitempty"`
+	TTL       int64    `json:"ttl,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+	Namespace string   `json:"namespace,omitempty"`
+
+	op string
+}
+
+// NewListPush creates a ListPush
+func NewListPush(ns, key string, values []string, left bool, ttl int64) *ListPush {
+	return &ListPush{
+		Namespace: ns,
+		Key:       key,
+		Values:    values,
+		Left:      left,
+		TTL:       ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (lp *ListPush) ActionType() string {
+	return ActionTypeListPush
+}
+
+// JSON returns json for the action
+func (lp *ListPush) JSON() []byte {
+	lpJSON, _ := json.Marshal(lp)
+
+	return lpJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (lp *ListPush) Time() int64 {
+	return lp.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (lp *ListPush) SetTime(timestamp int64) {
+	lp.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (lp *ListPush) Changes() []*config.Change {
+	return keyChange(lp.Namespace, lp.Key, lp.op)
+}
+
+// Execute pushes the values onto the list, with the block's ID as its new version
+func (lp *ListPush) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(lp.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "ListPush.Execute failed to CacheForNamespace")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Pushing %d values onto list %q", len(lp.Values), lp.Key))
+
+	lp.op, _, err = updateStructure(app, lp.Namespace, c, lp.Key, ContentTypeList, lp.Timestamp, lp.TTL, block.ID, func(current []byte) ([]byte, error) {
+		list, err := DecodeList(current)
+		if err != nil {
+			return nil, err
+		}
+
+		if lp.Left {
+			pushed := make([]string, 0, len(lp.Values)+len(list))
+			for i := len(lp.Values) - 1; i >= 0; i-- {
+				pushed = append(pushed, lp.Values[i])
+			}
+
+			list = append(pushed, list...)
+		} else {
+			list = append(list, lp.Values...)
+		}
+
+		return encodeList(list)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "ListPush.Execute failed to updateStructure")
+	}
+
+	return nil
+}
+
+// ListPop is a block value representing up to Count values being removed from the right (end) of a list, or the left (start) if Left is set
+// the list is deleted if it has no values left
+type ListPop struct {
+	Key       string `json:"key"`
+	Count     int    `json:"count"`
+	Left      bool   `json:"left,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Namespace string `json:"namespace,omitempty"`
+
+	result string
+	op     string
+}
+
+// NewListPop creates a ListPop
+func NewListPop(ns, key string, count int, left bool) *ListPop {
+	return &ListPop{
+		Namespace: ns,
+		Key:       key,
+		Count:     count,
+		Left:      left,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (lp *ListPop) ActionType() string {
+	return ActionTypeListPop
+}
+
+// JSON returns json for the action
+func (lp *ListPop) JSON() []byte {
+	lpJSON, _ := json.Marshal(lp)
+
+	return lpJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (lp *ListPop) Time() int64 {
+	return lp.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (lp *ListPop) SetTime(timestamp int64) {
+	lp.Timestamp = timestamp
+}
+
+// Result returns a JSON array of the values popped, in the order they were popped
+// it is empty if the value of the list wasn't known to this node
+func (lp *ListPop) Result() string {
+	return lp.result
+}
+
+// Changes returns the key changed by the action
+func (lp *ListPop) Changes() []*config.Change {
+	return keyChange(lp.Namespace, lp.Key, lp.op)
+}
+
+// Execute pops the values from the list, with the block's ID as its new version
+func (lp *ListPop) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(lp.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "ListPop.Execute failed to CacheForNamespace")
+	}
+
+	popped := []string{}
+
+	op, known, err := updateStructure(app, lp.Namespace, c, lp.Key, ContentTypeList, lp.Timestamp, 0, block.ID, func(current []byte) ([]byte, error) {
+		list, err := DecodeList(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for len(popped) < lp.Count && len(list) > 0 {
+			if lp.Left {
+				popped = append(popped, list[0])
+				list = list[1:]
+			} else {
+				popped = append(popped, list[len(list)-1])
+				list = list[:len(list)-1]
+			}
+		}
+
+		return encodeList(list)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "ListPop.Execute failed to updateStructure")
+	}
+
+	lp.op = op
+
+	if !known {
+		logger.LogInfo(fmt.Sprintf("Popping from evicted list %q, value stays unknown", lp.Key))
+		return nil
+	}
+
+	logger.LogInfo(fmt.Sprintf("Popped %d values from list %q", len(popped), lp.Key))
+
+	poppedJSON, _ := json.Marshal(popped)
+	lp.result = string(poppedJSON)
+
+	return nil
+}
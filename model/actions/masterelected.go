@@ -0,0 +1,78 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// MasterElected is a block value representing a verifier taking over as master after winning the election for Term
+// the new master commits it itself, so every node finds out about the change by executing it
+type MasterElected struct {
+	Node *model.Node `json:"node"`
+	Term int         `json:"term"`
+}
+
+// NewMasterElected creates a MasterElected
+func NewMasterElected(node *model.Node, term int) *MasterElected {
+	return &MasterElected{
+		Node: node,
+		Term: term,
+	}
+}
+
+// ActionType defines this action's type
+func (me *MasterElected) ActionType() string {
+	return ActionTypeMasterElected
+}
+
+// JSON returns json for the action
+func (me *MasterElected) JSON() []byte {
+	meJSON, _ := json.Marshal(me)
+
+	return meJSON
+}
+
+// Execute replaces the master in the node list
+// the block must be signed by the elected node, so that no other node can hand the role out
+func (me *MasterElected) Execute(app *config.App, block *blockchain.Block) error {
+	logger.LogInfo(fmt.Sprintf("Electing node with NID %s as master for term %d", me.Node.NID, me.Term))
+
+	if me.Node.Type != model.NodeTypeVerifier {
+		return fmt.Errorf("MasterElected.Execute tried to elect node with type %q, only verifiers can be elected", me.Node.Type)
+	}
+
+	pubKey, err := me.Node.KeyPair()
+	if err != nil {
+		return errors.Wrap(err, "MasterElected.Execute failed to KeyPair")
+	}
+
+	if block.Signature == nil || block.Signature.KID != pubKey.KID {
+		return fmt.Errorf("MasterElected.Execute found block with ID %s not signed by the elected node", block.ID)
+	}
+
+	if _, err := app.Election.Observe(me.Term); err != nil {
+		return errors.Wrap(err, "MasterElected.Execute failed to Observe")
+	}
+
+	// workers may not know about the new master's key yet
+	app.KeySet.AddKeyPair(pubKey)
+
+	// the new master gets every block as a verifier, so nobody needs to distribute to it
+	master := *me.Node
+	master.ParentNID = ""
+
+	app.NodeList.SetMaster(&master)
+
+	// so that if we restart, we catch up from the new master rather than one that may be gone
+	if err := app.SaveIdentity(); err != nil {
+		return errors.Wrap(err, "MasterElected.Execute failed to SaveIdentity")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package actions
+
+import (
+	"testing"
+
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+)
+
+func TestMasterElected(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster})
+
+	selfKeyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.KeySet = &acrypto.KeySet{KeyPair: selfKeyPair}
+
+	elected := &model.Node{NID: "verifier", Type: model.NodeTypeVerifier, PubKey: keyPair.PubKeyJSON(), ParentNID: "other"}
+
+	// only the elected node can sign the block that makes it master
+	forged := &blockchain.Block{ID: "forged", Signature: &acrypto.Signature{KID: "other"}}
+	if err := NewMasterElected(elected, 2).Execute(app, forged); err == nil {
+		t.Error("expected a block not signed by the elected node to fail")
+	}
+
+	if master := app.NodeList.CurrentMaster(); master.NID != "master" {
+		t.Errorf("expected the master to stay %q, got %q", "master", master.NID)
+	}
+
+	block := &blockchain.Block{ID: "elected", Signature: &acrypto.Signature{KID: keyPair.KID}}
+	if err := NewMasterElected(elected, 2).Execute(app, block); err != nil {
+		t.Fatal(err)
+	}
+
+	master := app.NodeList.CurrentMaster()
+	if master.NID != "verifier" || master.ParentNID != "" {
+		t.Errorf("expected the elected verifier to be master with no parent, got %q with parent %q", master.NID, master.ParentNID)
+	}
+
+	if term := app.Election.Term(); term != 2 {
+		t.Errorf("expected the election to move to term 2, got %d", term)
+	}
+
+	if app.KeySet.KeyPairWithKID(keyPair.KID) == nil {
+		t.Error("expected the new master's key to be added")
+	}
+}
+
+func TestMasterElectedOnlyVerifiers(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker := &model.Node{NID: "worker", Type: model.NodeTypeWorker, PubKey: keyPair.PubKeyJSON()}
+	block := &blockchain.Block{ID: "elected", Signature: &acrypto.Signature{KID: keyPair.KID}}
+
+	if err := NewMasterElected(worker, 1).Execute(app, block); err == nil {
+		t.Error("expected electing a worker to fail")
+	}
+}
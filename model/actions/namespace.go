@@ -0,0 +1,93 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// NamespaceCreated is a block value representing a new namespace
+// TokenHash is the hash of the namespace's token (see config.HashToken), the token itself never goes into the chain
+type NamespaceCreated struct {
+	Name       string `json:"name"`
+	MaxEntries int    `json:"maxEntries,omitempty"`
+	MaxBytes   int64  `json:"maxBytes,omitempty"`
+	TokenHash  string `json:"tokenHash,omitempty"`
+}
+
+// NewNamespaceCreated creates a NamespaceCreated
+func NewNamespaceCreated(name string, maxEntries int, maxBytes int64, tokenHash string) *NamespaceCreated {
+	return &NamespaceCreated{
+		Name:       name,
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+		TokenHash:  tokenHash,
+	}
+}
+
+// ActionType defines this action's type
+func (nc *NamespaceCreated) ActionType() string {
+	return ActionTypeNamespaceCreated
+}
+
+// JSON returns json for the action
+func (nc *NamespaceCreated) JSON() []byte {
+	ncJSON, _ := json.Marshal(nc)
+
+	return ncJSON
+}
+
+// Execute adds the namespace to the namespace list, every node keeps the list so they agree on which namespaces exist
+func (nc *NamespaceCreated) Execute(app *config.App, block *blockchain.Block) error {
+	logger.LogInfo(fmt.Sprintf("Creating namespace %q", nc.Name))
+
+	if err := app.Namespaces.Create(nc.Name, nc.MaxEntries, nc.MaxBytes, nc.TokenHash); err != nil {
+		return errors.Wrap(err, "NamespaceCreated.Execute failed to Create")
+	}
+
+	return nil
+}
+
+// NamespaceDeleted is a block value representing a namespace and everything in it being removed
+type NamespaceDeleted struct {
+	Name string `json:"name"`
+}
+
+// NewNamespaceDeleted creates a NamespaceDeleted
+func NewNamespaceDeleted(name string) *NamespaceDeleted {
+	return &NamespaceDeleted{
+		Name: name,
+	}
+}
+
+// ActionType defines this action's type
+func (nd *NamespaceDeleted) ActionType() string {
+	return ActionTypeNamespaceDeleted
+}
+
+// JSON returns json for the action
+func (nd *NamespaceDeleted) JSON() []byte {
+	ndJSON, _ := json.Marshal(nd)
+
+	return ndJSON
+}
+
+// Changes returns a flush of the namespace, since every key in it is gone
+func (nd *NamespaceDeleted) Changes() []*config.Change {
+	return keyChange(nd.Name, "", config.ChangeOpFlush)
+}
+
+// Execute removes the namespace from the namespace list
+func (nd *NamespaceDeleted) Execute(app *config.App, block *blockchain.Block) error {
+	logger.LogInfo(fmt.Sprintf("Deleting namespace %q", nd.Name))
+
+	if err := app.Namespaces.Delete(nd.Name); err != nil {
+		return errors.Wrap(err, "NamespaceDeleted.Execute failed to Delete")
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	acrypto "github.com/astromechio/astrocache/crypto"
 	"github.com/astromechio/astrocache/logger"
 	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/pkg/errors"
 )
 
@@ -39,7 +40,7 @@ func (na *NodeAdded) JSON() []byte {
 }
 
 // Execute adds the node to the node list
-func (na *NodeAdded) Execute(app *config.App) error {
+func (na *NodeAdded) Execute(app *config.App, block *blockchain.Block) error {
 	logger.LogInfo("Adding node with NID " + na.Node.NID)
 
 	if na.Node.NID == app.Self.NID {
@@ -64,8 +65,8 @@ func (na *NodeAdded) Execute(app *config.App) error {
 	} else if na.Node.Type == model.NodeTypeWorker {
 		app.NodeList.AddWorker(na.Node)
 	} else if na.Node.Type == model.NodeTypeMaster {
-		if app.NodeList.Master == nil {
-			app.NodeList.Master = na.Node
+		if app.NodeList.CurrentMaster() == nil {
+			app.NodeList.SetMaster(na.Node)
 		} else {
 			logger.LogWarn("NodeAdded.Execute tried to set a master node when one already exists, skipping...")
 		}
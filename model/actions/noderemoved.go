@@ -0,0 +1,89 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// NodeRemoved is a block value representing a node being evicted from the network
+// KID is the node's keyPair KID, since workers don't keep every node in their node list but still need to forget its key
+type NodeRemoved struct {
+	NID string `json:"nid"`
+	KID string `json:"kid"`
+}
+
+// NewNodeRemoved creates a new NodeRemoved
+func NewNodeRemoved(nid, kid string) *NodeRemoved {
+	return &NodeRemoved{
+		NID: nid,
+		KID: kid,
+	}
+}
+
+// ActionType defines this action's type
+func (nr *NodeRemoved) ActionType() string {
+	return ActionTypeNodeRemoved
+}
+
+// JSON returns json for the action
+func (nr *NodeRemoved) JSON() []byte {
+	nrJSON, _ := json.Marshal(nr)
+
+	return nrJSON
+}
+
+// Execute removes the node from the node list and its key from the keySet, so that any later block signed by it fails to verify
+// neither its NID nor its key can join the network again
+func (nr *NodeRemoved) Execute(app *config.App, block *blockchain.Block) error {
+	logger.LogInfo("Removing node with NID " + nr.NID)
+
+	if master := app.NodeList.CurrentMaster(); master != nil && master.NID == nr.NID {
+		return fmt.Errorf("NodeRemoved.Execute tried to remove the master with NID %s", nr.NID)
+	}
+
+	if nr.KID == acrypto.MasterKeyPairKID {
+		return errors.New("NodeRemoved.Execute tried to remove the genesis key")
+	}
+
+	if nr.NID == app.Self.NID {
+		logger.LogWarn("NodeRemoved.Execute found this node has been removed from the network, it will no longer be sent blocks or serve requests")
+
+		app.NodeList.Forbid(nr.NID, "")
+		app.Membership.Remove()
+		return nil
+	}
+
+	// workers don't keep every node in their node list, but never admit nodes either, so only need its NID
+	fingerprint := ""
+
+	if node := app.NodeList.NodeWithNID(nr.NID); node != nil {
+		pubKey, err := node.KeyPair()
+		if err != nil {
+			return errors.Wrap(err, "NodeRemoved.Execute failed to KeyPair")
+		}
+
+		if pubKey.KID != nr.KID {
+			return fmt.Errorf("NodeRemoved.Execute found KID %q for node with NID %s, expected %q", pubKey.KID, nr.NID, nr.KID)
+		}
+
+		fingerprint = pubKey.Fingerprint()
+
+		// a worker keeps forwarding writes to its verifier until it is reassigned, rather than having nowhere to send them
+		if app.Self.Type != model.NodeTypeWorker || nr.NID != app.NodeList.ParentNID() {
+			app.NodeList.RemoveNode(nr.NID)
+		}
+	}
+
+	app.NodeList.Forbid(nr.NID, fingerprint)
+	app.KeySet.RemoveKeyPair(nr.KID)
+	app.Liveness.Forget(nr.NID)
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"testing"
+
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+)
+
+func TestNodeRemovedSelf(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewNodeRemoved("self", "kid"), "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Membership.Active() {
+		t.Error("expected a node that executed a NodeRemoved block for itself to stop being active")
+	}
+}
+
+func TestNodeRemovedVerifier(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+
+	selfKeyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.KeySet = &acrypto.KeySet{KeyPair: selfKeyPair}
+	app.KeySet.AddKeyPair(keyPair)
+
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster})
+	app.NodeList.AddVerifier(&model.Node{NID: "verifier", Type: model.NodeTypeVerifier, PubKey: keyPair.PubKeyJSON()})
+
+	if err := execute(t, app, NewNodeRemoved("verifier", keyPair.KID), "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.NodeList.VerifierWithNID("verifier") != nil {
+		t.Error("expected the verifier to be removed from the node list")
+	}
+
+	if app.KeySet.KeyPairWithKID(keyPair.KID) != nil {
+		t.Error("expected the verifier's key to be removed from the keySet")
+	}
+
+	if !app.NodeList.Forbidden("verifier", "") || !app.NodeList.Forbidden("", keyPair.Fingerprint()) {
+		t.Error("expected the verifier's NID and key to be remembered as removed")
+	}
+
+	// the same key sent under a new KID has the same fingerprint
+	rejoining := &acrypto.KeyPair{Public: keyPair.Public, KID: "new-kid"}
+
+	if !app.NodeList.Forbidden("new-nid", rejoining.Fingerprint()) {
+		t.Error("expected the verifier's key to be remembered as removed under any KID")
+	}
+
+	if !app.Membership.Active() {
+		t.Error("expected removing another node not to change this node's membership")
+	}
+}
+
+func TestNodeRemovedRefusesMaster(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster})
+
+	if err := execute(t, app, NewNodeRemoved("master", "kid"), "block"); err == nil {
+		t.Error("expected removing the master to fail")
+	}
+
+	if err := execute(t, app, NewNodeRemoved("other", acrypto.MasterKeyPairKID), "block"); err == nil {
+		t.Error("expected removing the genesis key to fail")
+	}
+
+	if app.NodeList.Forbidden("master", "kid") || app.NodeList.Forbidden("other", acrypto.MasterKeyPairKID) {
+		t.Error("expected a refused NodeRemoved not to be remembered")
+	}
+}
@@ -0,0 +1,177 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// SetAdd is a block value representing members being added to a set, members already in it are ignored
+// the set is created with TTL if it doesn't exist yet
+type SetAdd struct {
+	Key       string   `json:"key"`
+	Members   []string `json:"members"`
+	TTL       int64    `json:"ttl,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+	Namespace string   `json:"namespace,omitempty"`
+
+	op string
+}
+
+// NewSetAdd creates a SetAdd
+func NewSetAdd(ns, key string, members []string, ttl int64) *SetAdd {
+	return &SetAdd{
+		Namespace: ns,
+		Key:       key,
+		Members:   members,
+		TTL:       ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (sa *SetAdd) ActionType() string {
+	return ActionTypeSetAdd
+}
+
+// JSON returns json for the action
+func (sa *SetAdd) JSON() []byte {
+	saJSON, _ := json.Marshal(sa)
+
+	return saJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (sa *SetAdd) Time() int64 {
+	return sa.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (sa *SetAdd) SetTime(timestamp int64) {
+	sa.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (sa *SetAdd) Changes() []*config.Change {
+	return keyChange(sa.Namespace, sa.Key, sa.op)
+}
+
+// Execute adds the members to the set, with the block's ID as its new version
+func (sa *SetAdd) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(sa.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "SetAdd.Execute failed to CacheForNamespace")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Adding %d members to set %q", len(sa.Members), sa.Key))
+
+	sa.op, _, err = updateStructure(app, sa.Namespace, c, sa.Key, ContentTypeSet, sa.Timestamp, sa.TTL, block.ID, func(current []byte) ([]byte, error) {
+		set, err := DecodeSet(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range sa.Members {
+			set[member] = true
+		}
+
+		return encodeSet(set)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "SetAdd.Execute failed to updateStructure")
+	}
+
+	return nil
+}
+
+// SetRemove is a block value representing members being removed from a set
+// the set is deleted if it has no members left
+type SetRemove struct {
+	Key       string   `json:"key"`
+	Members   []string `json:"members"`
+	Timestamp int64    `json:"timestamp"`
+	Namespace string   `json:"namespace,omitempty"`
+
+	op string
+}
+
+// NewSetRemove creates a SetRemove
+func NewSetRemove(ns, key string, members []string) *SetRemove {
+	return &SetRemove{
+		Namespace: ns,
+		Key:       key,
+		Members:   members,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// ActionType defines this action's type
+func (sr *SetRemove) ActionType() string {
+	return ActionTypeSetRemove
+}
+
+// JSON returns json for the action
+func (sr *SetRemove) JSON() []byte {
+	srJSON, _ := json.Marshal(sr)
+
+	return srJSON
+}
+
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (sr *SetRemove) Time() int64 {
+	return sr.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (sr *SetRemove) SetTime(timestamp int64) {
+	sr.Timestamp = timestamp
+}
+
+// Changes returns the key changed by the action
+func (sr *SetRemove) Changes() []*config.Change {
+	return keyChange(sr.Namespace, sr.Key, sr.op)
+}
+
+// Execute removes the members from the set, with the block's ID as its new version
+func (sr *SetRemove) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(sr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "SetRemove.Execute failed to CacheForNamespace")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Removing %d members from set %q", len(sr.Members), sr.Key))
+
+	sr.op, _, err = updateStructure(app, sr.Namespace, c, sr.Key, ContentTypeSet, sr.Timestamp, 0, block.ID, func(current []byte) ([]byte, error) {
+		set, err := DecodeSet(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range sr.Members {
+			delete(set, member)
+		}
+
+		return encodeSet(set)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "SetRemove.Execute failed to updateStructure")
+	}
+
+	return nil
+}
@@ -3,24 +3,43 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/astromechio/astrocache/cache"
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
 	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
 )
 
-// SetValue is a block value representing a new node in the network
-// GlobalKey is the global key encrypted with the new node's pubKey
+// SetValue is a block value representing a value being set for a key
+// TTL is the number of seconds the value lives for after Timestamp, 0 means forever
+// Timestamp is set (in unix nanoseconds) when the block is created, so that every node expires the value at the same time
+// IfMatch, if set, is the version the key must have for the value to be set
+// Namespace is empty for the default namespace
+// Data holds the value's bytes, Value is only set by blocks created before values could be binary
 type SetValue struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	TTL         int64  `json:"ttl,omitempty"`
+	Timestamp   int64  `json:"timestamp"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
 }
 
-// NewSetValue creates a nSetValue
-func NewSetValue(key, value string) *SetValue {
+// NewSetValue creates a SetValue
+func NewSetValue(ns, key string, value []byte, contentType string, ttl int64, ifMatch string) *SetValue {
 	return &SetValue{
-		Key:   key,
-		Value: value,
+		Namespace:   ns,
+		Key:         key,
+		Data:        value,
+		ContentType: contentType,
+		TTL:         ttl,
+		Timestamp:   time.Now().UnixNano(),
+		IfMatch:     ifMatch,
 	}
 }
 
@@ -36,12 +55,107 @@ func (sv *SetValue) JSON() []byte {
 	return naJSON
 }
 
-// Execute adds the node to the node list
-func (sv *SetValue) Execute(app *config.App) error {
-	if app.Self.Type == model.NodeTypeWorker {
-		logger.LogInfo(fmt.Sprintf("Setting value %q for key %q", sv.Value, sv.Key))
+// Time returns the time the action is executed as of, in unix nanoseconds
+func (sv *SetValue) Time() int64 {
+	return sv.Timestamp
+}
+
+// SetTime sets the time the action is executed as of
+func (sv *SetValue) SetTime(timestamp int64) {
+	sv.Timestamp = timestamp
+}
+
+// ExpiresAt returns the time the value expires, or the zero time if it never does
+func (sv *SetValue) ExpiresAt() time.Time {
+	return expiresAt(sv.Timestamp, sv.TTL)
+}
+
+// Changes returns the key changed by the action
+func (sv *SetValue) Changes() []*config.Change {
+	return keyChange(sv.Namespace, sv.Key, config.ChangeOpSet)
+}
+
+// Bytes returns the value being set
+func (sv *SetValue) Bytes() []byte {
+	return valueBytes(sv.Data, sv.Value)
+}
+
+// valueBytes returns data, or value for actions from blocks created before values could be binary
+func valueBytes(data []byte, value string) []byte {
+	if data != nil {
+		return data
+	}
+
+	return []byte(value)
+}
+
+// expiresAt returns the time a value set at timestamp with ttl expires, or the zero time if it never does
+func expiresAt(timestamp, ttl int64) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, timestamp).Add(time.Duration(ttl) * time.Second)
+}
+
+// Execute sets the value in the cache, with the block's ID as its version
+// the version check happens here so that every node makes the same decision in chain order
+func (sv *SetValue) Execute(app *config.App, block *blockchain.Block) error {
+	if app.Self.Type == model.NodeTypeMaster {
+		return nil
+	}
+
+	c, err := app.CacheForNamespace(sv.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "SetValue.Execute failed to CacheForNamespace")
+	}
+
+	if err := checkVersion(c, sv.Key, sv.IfMatch, sv.Timestamp); err == ErrVersionUnknown {
+		// this node can't make the decision, so it forgets the key rather than risk disagreeing with other nodes
+		logger.LogInfo(fmt.Sprintf("Version of key %q is unknown, forgetting it", sv.Key))
+
+		c.ForgetKey(sv.Key)
+		return errors.Wrapf(err, "SetValue.Execute forgot key %q", sv.Key)
+	} else if err != nil {
+		return errors.Wrap(err, "SetValue.Execute failed to checkVersion")
+	}
+
+	value := sv.Bytes()
+
+	if err := useQuota(app, sv.Namespace, sv.Timestamp, keyUsage(sv.Key, value, sv.ExpiresAt())); err != nil {
+		return errors.Wrap(err, "SetValue.Execute failed to useQuota")
+	}
+
+	logger.LogInfo(fmt.Sprintf("Setting %d byte value for key %q", len(value), sv.Key))
+
+	c.SetVersionedValueForKey(value, sv.ContentType, sv.Key, block.ID, sv.ExpiresAt())
+
+	return nil
+}
+
+// ErrVersionUnknown is returned from Execute when this node evicted a conditionally set key and dropped its tombstone
+// the node forgets the key instead of setting it, so it doesn't report the key as set to watchers
+// it only happens on nodes with a bounded cache, which don't reply to writes
+var ErrVersionUnknown = errors.New("version unknown")
+
+// checkVersion returns ErrVersionMismatch if key's version at timestamp does not match expected
+// an empty expected version always matches
+func checkVersion(c *cache.Cache, key, expected string, timestamp int64) error {
+	if expected == "" {
+		return nil
+	}
+
+	current, known := c.VersionForKey(key, time.Unix(0, timestamp))
+	if !known {
+		return ErrVersionUnknown
+	}
+
+	if expected == VersionAny && current != "" {
+		return nil
+	}
 
-		app.Cache.SetValueForKey(sv.Value, sv.Key)
+	if current != expected {
+		return errors.Wrapf(ErrVersionMismatch, "expected version %q for key %q, found %q", expected, key, current)
 	}
 
 	return nil
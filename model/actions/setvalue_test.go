@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/model"
+	"github.com/pkg/errors"
+)
+
+func TestSetValueIfMatch(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("first"), "", 0, VersionAny), "create"); errors.Cause(err) != ErrVersionMismatch {
+		t.Errorf("expected %q setting a missing key that must exist, got %v", ErrVersionMismatch, err)
+	}
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("first"), "", 0, ""), "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("stale"), "", 0, "other"), "stale"); errors.Cause(err) != ErrVersionMismatch {
+		t.Errorf("expected %q setting a key with the wrong version, got %v", ErrVersionMismatch, err)
+	}
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("second"), "", 0, "first"), "second"); err != nil {
+		t.Errorf("expected setting a key with its current version to succeed, got %v", err)
+	}
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("third"), "", 0, VersionAny), "third"); err != nil {
+		t.Errorf("expected setting an existing key that must exist to succeed, got %v", err)
+	}
+
+	item, ok := app.Cache.ItemForKey("key")
+	if !ok || string(item.Value) != "third" || item.Version != "third" {
+		t.Errorf("expected the key to hold the last value set, with its block's ID as its version")
+	}
+}
+
+func TestSetValueVersionUnknown(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewSetValue("", "key", []byte("first"), "", 0, ""), "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// as if the key was evicted and its tombstone dropped
+	app.Cache.ForgetKey("key")
+
+	set := NewSetValue("", "key", []byte("second"), "", 0, "first")
+
+	if err := execute(t, app, set, "second"); errors.Cause(err) != ErrVersionUnknown {
+		t.Fatalf("expected %q setting a key whose version is unknown, got %v", ErrVersionUnknown, err)
+	}
+
+	if _, ok := app.Cache.ItemForKey("key"); ok {
+		t.Error("expected the key to stay forgotten rather than be set")
+	}
+
+	// an unconditional write doesn't need to know the version
+	if err := execute(t, app, NewSetValue("", "key", []byte("third"), "", 0, ""), "third"); err != nil {
+		t.Errorf("expected an unconditional write to succeed, got %v", err)
+	}
+}
+
+func TestBatchSetVersionUnknown(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewSetValue("", "known", []byte("value"), "", 0, ""), "known"); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Cache.ForgetKey("forgotten")
+
+	batch := NewBatchSet("", []*BatchSetValue{
+		{Key: "known", Value: "next", IfMatch: "known"},
+		{Key: "forgotten", Value: "next", IfMatch: "anything"},
+	}, nil)
+
+	if err := execute(t, app, batch, "batch"); errors.Cause(err) != ErrVersionUnknown {
+		t.Fatalf("expected %q when a key in the batch has an unknown version, got %v", ErrVersionUnknown, err)
+	}
+
+	if _, ok := app.Cache.ItemForKey("known"); ok {
+		t.Error("expected every key in the batch to be forgotten")
+	}
+}
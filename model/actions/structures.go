@@ -0,0 +1,150 @@
+package actions
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/pkg/errors"
+)
+
+// ErrWrongType is returned from Execute when a structure action is applied to a key holding a different kind of value
+var ErrWrongType = errors.New("value is the wrong type")
+
+// ContentTypeHash and others mark values holding structures, which are stored as JSON
+// a hash is an object of fields, a list is an array, and a set is a sorted array of unique members
+const (
+	ContentTypeHash = "application/vnd.astrocache.hash+json"
+	ContentTypeList = "application/vnd.astrocache.list+json"
+	ContentTypeSet  = "application/vnd.astrocache.set+json"
+)
+
+// structureUpdate changes a structure's JSON, current is nil if the key doesn't exist
+// returning nil next deletes the key, which is done when a structure becomes empty
+type structureUpdate func(current []byte) (next []byte, err error)
+
+// updateStructure reads the structure at key in c as of timestamp, passes it to update and stores the result with blockID as its version
+// a key holding any other kind of value fails with ErrWrongType, a new key is created with ttl if one is set and an existing key keeps its expiry
+// the result is counted against the quota of namespace ns, which c is the cache for
+// it returns the change that was made to the key for watchers, which is empty if the key didn't exist and still doesn't
+// if this node evicted the key its value is unknown, so only its version is updated (the same as IncrementValue) and known is false
+func updateStructure(app *config.App, ns string, c *cache.Cache, key, contentType string, timestamp, ttl int64, blockID string, update structureUpdate) (op string, known bool, err error) {
+	at := time.Unix(0, timestamp)
+
+	item, expiresAt, ok := c.ItemForKeyAt(key, at)
+	if !ok {
+		c.SetEvictedVersionForKey(key, blockID, expiresAt)
+		return config.ChangeOpSet, false, nil
+	}
+
+	var current []byte
+	if item != nil {
+		if item.ContentType != contentType {
+			return "", true, errors.Wrapf(ErrWrongType, "key %q holds %q", key, item.ContentType)
+		}
+
+		current = item.Value
+	} else if ttl > 0 {
+		expiresAt = at.Add(time.Duration(ttl) * time.Second)
+	}
+
+	next, err := update(current)
+	if err != nil {
+		return "", true, err
+	}
+
+	if next == nil {
+		// emptying a structure that didn't exist, such as popping from a missing list, changes nothing
+		if current == nil {
+			return "", true, nil
+		}
+
+		if err := useQuota(app, ns, timestamp, deletedKeyUsage(key)); err != nil {
+			return "", true, err
+		}
+
+		c.DeleteValueForKey(key)
+		return config.ChangeOpDelete, true, nil
+	}
+
+	if err := useQuota(app, ns, timestamp, keyUsage(key, next, expiresAt)); err != nil {
+		return "", true, err
+	}
+
+	c.SetVersionedValueForKey(next, contentType, key, blockID, expiresAt)
+
+	return config.ChangeOpSet, true, nil
+}
+
+// DecodeHash decodes a hash stored in the cache, raw is nil for a missing hash
+func DecodeHash(raw []byte) (map[string]string, error) {
+	hash := map[string]string{}
+
+	if raw != nil {
+		if err := json.Unmarshal(raw, &hash); err != nil {
+			return nil, errors.Wrap(err, "DecodeHash failed to Unmarshal")
+		}
+	}
+
+	return hash, nil
+}
+
+// DecodeList decodes a list stored in the cache, raw is nil for a missing list
+func DecodeList(raw []byte) ([]string, error) {
+	list := []string{}
+
+	if raw != nil {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, errors.Wrap(err, "DecodeList failed to Unmarshal")
+		}
+	}
+
+	return list, nil
+}
+
+// DecodeSet decodes a set stored in the cache into a map of its members, raw is nil for a missing set
+func DecodeSet(raw []byte) (map[string]bool, error) {
+	members, err := DecodeList(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "DecodeSet failed to DecodeList")
+	}
+
+	set := make(map[string]bool, len(members))
+	for _, member := range members {
+		set[member] = true
+	}
+
+	return set, nil
+}
+
+// encodeHash encodes a hash for the cache, an empty hash encodes to nil so that its key is deleted
+func encodeHash(hash map[string]string) ([]byte, error) {
+	if len(hash) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(hash)
+}
+
+// encodeList encodes a list for the cache, an empty list encodes to nil so that its key is deleted
+func encodeList(list []string) ([]byte, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(list)
+}
+
+// encodeSet encodes a set for the cache as a sorted array, so that every node stores the same bytes
+func encodeSet(set map[string]bool) ([]byte, error) {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+
+	sort.Strings(members)
+
+	return encodeList(members)
+}
@@ -0,0 +1,193 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model"
+	"github.com/pkg/errors"
+)
+
+// listAt returns the list stored at key in the default cache, or nil if there is none
+func listAt(t *testing.T, app *config.App, key string) []string {
+	t.Helper()
+
+	item, ok := app.Cache.ItemForKey(key)
+	if !ok {
+		return nil
+	}
+
+	list, err := DecodeList(item.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return list
+}
+
+func equalLists(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestListPushOrder(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewListPush("", "list", []string{"a", "b"}, false, 0), "right"); err != nil {
+		t.Fatal(err)
+	}
+
+	// values are pushed onto the left one at a time, so the last one ends up first
+	if err := execute(t, app, NewListPush("", "list", []string{"c", "d"}, true, 0), "left"); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := listAt(t, app, "list"); !equalLists(list, []string{"d", "c", "a", "b"}) {
+		t.Errorf("expected [d c a b], got %v", list)
+	}
+
+	if item, _ := app.Cache.ItemForKey("list"); item.ContentType != ContentTypeList || item.Version != "left" {
+		t.Errorf("expected a list versioned by the last push, got %q with version %q", item.ContentType, item.Version)
+	}
+}
+
+func TestListPopBeyondLength(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewListPush("", "list", []string{"a", "b", "c"}, false, 0), "push"); err != nil {
+		t.Fatal(err)
+	}
+
+	left := NewListPop("", "list", 1, true)
+	if err := execute(t, app, left, "left"); err != nil {
+		t.Fatal(err)
+	}
+
+	if left.Result() != `["a"]` {
+		t.Errorf("expected the first value popped from the left, got %s", left.Result())
+	}
+
+	right := NewListPop("", "list", 5, false)
+	if err := execute(t, app, right, "right"); err != nil {
+		t.Fatal(err)
+	}
+
+	// popping more than the list holds pops what there is, in the order it was popped
+	if right.Result() != `["c","b"]` {
+		t.Errorf("expected the rest of the list popped from the right, got %s", right.Result())
+	}
+
+	if _, ok := app.Cache.ItemForKey("list"); ok {
+		t.Error("expected an emptied list to be deleted")
+	}
+
+	if changes := right.Changes(); len(changes) != 1 || changes[0].Op != config.ChangeOpDelete {
+		t.Errorf("expected emptying the list to be reported as a delete, got %v", changes)
+	}
+}
+
+func TestHashAndSetEmptied(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewHashSet("", "hash", map[string]string{"a": "1", "b": "2"}, 0), "hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewSetAdd("", "set", []string{"b", "a", "b"}, 0), "set"); err != nil {
+		t.Fatal(err)
+	}
+
+	// members are stored sorted and unique, so every node stores the same bytes
+	if list := listAt(t, app, "set"); !equalLists(list, []string{"a", "b"}) {
+		t.Errorf("expected set [a b], got %v", list)
+	}
+
+	if err := execute(t, app, NewHashDelete("", "hash", []string{"a", "b", "c"}), "hash-delete"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewSetRemove("", "set", []string{"a", "b"}), "set-remove"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"hash", "set"} {
+		if _, ok := app.Cache.ItemForKey(key); ok {
+			t.Errorf("expected emptied %s to be deleted", key)
+		}
+	}
+}
+
+func TestStructureWrongType(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewSetValue("", "plain", []byte("value"), "", 0, ""), "plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewListPush("", "list", []string{"a"}, false, 0), "list"); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := []Action{
+		NewListPush("", "plain", []string{"a"}, false, 0),
+		NewListPop("", "plain", 1, false),
+		NewHashSet("", "list", map[string]string{"a": "1"}, 0),
+		NewSetAdd("", "list", []string{"a"}, 0),
+	}
+
+	for _, action := range wrong {
+		if err := execute(t, app, action, "wrong"); errors.Cause(err) != ErrWrongType {
+			t.Errorf("expected %q for %s on a key of another type, got %v", ErrWrongType, action.ActionType(), err)
+		}
+	}
+
+	if item, _ := app.Cache.ItemForKey("plain"); string(item.Value) != "value" || item.Version != "plain" {
+		t.Error("expected a structure action of the wrong type to leave the key alone")
+	}
+}
+
+func TestStructureMissingKeyUnchanged(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	if err := execute(t, app, NewNamespaceCreated("ns", 1, 0, ""), "create"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, app, NewSetValue("ns", "other", []byte("value"), "", 0, ""), "set"); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := []ChangeAction{
+		NewListPop("ns", "missing", 1, false),
+		NewHashDelete("ns", "missing", []string{"a"}),
+		NewSetRemove("ns", "missing", []string{"a"}),
+	}
+
+	for _, action := range missing {
+		if err := execute(t, app, action, "missing"); err != nil {
+			t.Fatalf("expected %s on a missing key to succeed, got %s", action.ActionType(), err)
+		}
+
+		if changes := action.Changes(); len(changes) != 0 {
+			t.Errorf("expected %s on a missing key not to be reported to watchers, got %v", action.ActionType(), changes)
+		}
+	}
+
+	if entries, _ := app.Namespaces.Get("ns").Usage(); entries != 1 {
+		t.Errorf("expected the namespace to still count 1 entry, got %d", entries)
+	}
+
+	// the quota of 1 is still full
+	if err := execute(t, app, NewListPush("ns", "list", []string{"a"}, false, 0), "push"); errors.Cause(err) != config.ErrQuotaExceeded {
+		t.Errorf("expected %q once the quota is full, got %v", config.ErrQuotaExceeded, err)
+	}
+}
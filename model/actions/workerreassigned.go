@@ -0,0 +1,94 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// WorkerReassigned is a block value representing a worker being adopted by a new verifier, such as when its verifier is removed
+// Verifier is the whole node rather than its NID, since the worker only knows about the verifier it was assigned to
+// the original master is sent blocks by its primary verifier like a worker, and is given a new one the same way
+type WorkerReassigned struct {
+	NID      string      `json:"nid"`
+	Verifier *model.Node `json:"verifier"`
+}
+
+// NewWorkerReassigned creates a new WorkerReassigned
+func NewWorkerReassigned(nid string, verifier *model.Node) *WorkerReassigned {
+	return &WorkerReassigned{
+		NID:      nid,
+		Verifier: verifier,
+	}
+}
+
+// ActionType defines this action's type
+func (wr *WorkerReassigned) ActionType() string {
+	return ActionTypeWorkerReassigned
+}
+
+// JSON returns json for the action
+func (wr *WorkerReassigned) JSON() []byte {
+	wrJSON, _ := json.Marshal(wr)
+
+	return wrJSON
+}
+
+// Execute changes the worker's parent in the node list, so that its new verifier starts distributing blocks to it
+// the worker itself switches to forwarding writes to its new verifier
+func (wr *WorkerReassigned) Execute(app *config.App, block *blockchain.Block) error {
+	logger.LogInfo(fmt.Sprintf("Reassigning worker with NID %s to verifier with NID %s", wr.NID, wr.Verifier.NID))
+
+	if wr.Verifier.Type != model.NodeTypeVerifier {
+		return fmt.Errorf("WorkerReassigned.Execute tried to assign worker to node with type %q, only verifiers can have workers", wr.Verifier.Type)
+	}
+
+	pubKey, err := wr.Verifier.KeyPair()
+	if err != nil {
+		return errors.Wrap(err, "WorkerReassigned.Execute failed to KeyPair")
+	}
+
+	if app.Self.Type == model.NodeTypeWorker {
+		// other workers don't need to know about it
+		if wr.NID != app.Self.NID {
+			return nil
+		}
+
+		app.KeySet.AddKeyPair(pubKey)
+		app.NodeList.SetParent(wr.Verifier)
+
+		// so that if we restart, we catch up from our new verifier rather than the one that was removed
+		if err := app.SaveIdentity(); err != nil {
+			return errors.Wrap(err, "WorkerReassigned.Execute failed to SaveIdentity")
+		}
+
+		return nil
+	}
+
+	if master := app.NodeList.CurrentMaster(); master != nil && master.NID == wr.NID {
+		// an elected master is a verifier and gets every block without one
+		if master.Type != model.NodeTypeMaster {
+			return fmt.Errorf("WorkerReassigned.Execute tried to reassign master with NID %s, which is a %s", wr.NID, master.Type)
+		}
+
+		app.NodeList.ReassignMaster(wr.Verifier.NID)
+
+		// so that if we restart as the new primary verifier, we keep distributing blocks to the master
+		if err := app.SaveIdentity(); err != nil {
+			return errors.Wrap(err, "WorkerReassigned.Execute failed to SaveIdentity")
+		}
+
+		return nil
+	}
+
+	if !app.NodeList.ReassignWorker(wr.NID, wr.Verifier.NID) {
+		return fmt.Errorf("WorkerReassigned.Execute tried to reassign unknown worker with NID %s", wr.NID)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package actions
+
+import (
+	"testing"
+
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+)
+
+// newTestVerifier returns a verifier with a fresh keyPair, which a WorkerReassigned block needs to name
+func newTestVerifier(t *testing.T, nid string) (*model.Node, *acrypto.KeyPair) {
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &model.Node{NID: nid, Type: model.NodeTypeVerifier, PubKey: keyPair.PubKeyJSON()}, keyPair
+}
+
+func TestWorkerReassignedOnVerifier(t *testing.T) {
+	app := newTestApp(model.NodeTypeVerifier)
+
+	removed, _ := newTestVerifier(t, "removed")
+	adopting, _ := newTestVerifier(t, "adopting")
+
+	app.NodeList.AddVerifier(adopting)
+	app.NodeList.AddWorker(&model.Node{NID: "orphan", Type: model.NodeTypeWorker, ParentNID: removed.NID})
+	app.NodeList.AddWorker(&model.Node{NID: "sibling", Type: model.NodeTypeWorker, ParentNID: removed.NID})
+
+	if err := execute(t, app, NewWorkerReassigned("orphan", adopting), "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	if workers := app.NodeList.WorkersForVerifierWithNID(adopting.NID); len(workers) != 1 || workers[0].NID != "orphan" {
+		t.Errorf("expected the adopting verifier to distribute blocks to the orphan, got %v", workers)
+	}
+
+	if workers := app.NodeList.WorkersForVerifierWithNID(removed.NID); len(workers) != 1 || workers[0].NID != "sibling" {
+		t.Errorf("expected only the sibling to be left with the removed verifier, got %v", workers)
+	}
+
+	if err := execute(t, app, NewWorkerReassigned("unknown", adopting), "block"); err == nil {
+		t.Error("expected reassigning a worker that isn't in the node list to fail")
+	}
+
+	worker := &model.Node{NID: "sibling", Type: model.NodeTypeWorker, PubKey: adopting.PubKey}
+	if err := execute(t, app, NewWorkerReassigned("orphan", worker), "block"); err == nil {
+		t.Error("expected reassigning a worker to another worker to fail")
+	}
+}
+
+func TestWorkerReassignedOnWorker(t *testing.T) {
+	app := newTestApp(model.NodeTypeWorker)
+
+	selfKeyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.KeySet = &acrypto.KeySet{KeyPair: selfKeyPair}
+
+	removed, removedKeyPair := newTestVerifier(t, "removed")
+	adopting, adoptingKeyPair := newTestVerifier(t, "adopting")
+
+	app.NodeList.SetParent(removed)
+
+	// the worker keeps its verifier when it is removed, so it has somewhere to forward writes until it is reassigned
+	if err := execute(t, app, NewNodeRemoved(removed.NID, removedKeyPair.KID), "removed"); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.NodeList.ParentNID() != removed.NID || app.NodeList.VerifierWithNID(removed.NID) == nil {
+		t.Fatal("expected the worker to keep its removed verifier until it is reassigned")
+	}
+
+	if err := execute(t, app, NewWorkerReassigned("other", adopting), "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.NodeList.ParentNID() != removed.NID {
+		t.Error("expected another worker being reassigned to leave this one alone")
+	}
+
+	if err := execute(t, app, NewWorkerReassigned("self", adopting), "reassigned"); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.NodeList.ParentNID() != adopting.NID || app.NodeList.VerifierWithNID(removed.NID) != nil {
+		t.Errorf("expected the worker to forward writes to its new verifier, got %q", app.NodeList.ParentNID())
+	}
+
+	// blocks from the new verifier are signed with its key
+	if app.KeySet.KeyPairWithKID(adoptingKeyPair.KID) == nil {
+		t.Error("expected the new verifier's key to be added to the keySet")
+	}
+}
@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/astromechio/astrocache/logger"
 
@@ -10,9 +11,13 @@ import (
 )
 
 // Chain represents a blockchain
+// blocks and proposed are read by HTTP handlers while the workers change them, so they are only accessed with lock held
 type Chain struct {
-	Blocks   []*Block
-	Proposed *Block
+	blocks   []*Block
+	proposed *Block
+	lock     sync.RWMutex
+
+	Store Store // Store persists committed blocks so the chain can be reopened
 
 	ReserveChan chan (*ReserveIDJob) // ReserveChan is used by reserveworker as the synchronization method for reserving block IDs
 	ProposeChan chan (*NewBlockJob)  // ProposeChan is used by proposeworker as the synchronization method for proposing blocks
@@ -23,15 +28,42 @@ type Chain struct {
 	ProposedChan  chan (*Block)        // ProposedChan is used when a goroutine needs to know the next time a block is proposed.
 	CommittedChan chan (*Block)        // CommittedChan is used when a goroutine needs to know the next time a block is committed.
 
-	ActionChan     chan (*Block) // ActionChan decrypts blocks and applies actions
-	DistributeChan chan (*Block) // DistributeChan loads blocks needed to be distributed to workers
+	ActionChan     chan (*NewBlockJob) // ActionChan decrypts blocks and applies actions
+	DistributeChan chan (*Block)       // DistributeChan loads blocks needed to be distributed to workers
+
+	PendingChan chan (*PendingAction) // PendingChan is used by batchworker to group actions into blocks
 }
 
 // NewBlockJob represents the intent to add a new block
+// ResultChan receives the result of committing the block
+// AppliedChan, if set, receives the result of executing the block's action after it is committed
 type NewBlockJob struct {
 	Block        *Block
 	ProposingNID string
 	ResultChan   chan (error)
+	AppliedChan  chan (*ActionResult)
+}
+
+// ActionResult is the result of executing a block's action
+// Value is only set by actions that produce one, such as an increment
+// Version is the version of the keys the action wrote, which is BlockID unless the action was one of a batch
+// Batch is set when the block held a batch of actions, with the result of each one in order
+// BlockID and Committed are set by the batch worker, Err is from committing the block if Committed is false
+type ActionResult struct {
+	Value     string
+	Version   string
+	Err       error
+	Batch     []*ActionResult
+	BlockID   string
+	Committed bool
+}
+
+// PendingAction is an action waiting to be put into a block by the batch worker
+// ResultChan receives the result of executing the action once its block is committed, or the error that stopped it being committed
+type PendingAction struct {
+	ActionType string
+	ActionJSON []byte
+	ResultChan chan (*ActionResult)
 }
 
 // ReserveIDJob respresents a reserved block ID
@@ -42,18 +74,36 @@ type ReserveIDJob struct {
 }
 
 // AddNewBlock checks and then sets the proposed block
-func (c *Chain) AddNewBlock(block *Block, propNID string) chan error {
+// the first channel receives the result of committing the block, the second the result of executing its action
+func (c *Chain) AddNewBlock(block *Block, propNID string) (chan error, chan *ActionResult) {
 	errChan := make(chan error, 1)
+	appliedChan := make(chan *ActionResult, 1)
 
 	job := &NewBlockJob{
 		Block:        block,
 		ProposingNID: propNID,
 		ResultChan:   errChan,
+		AppliedChan:  appliedChan,
 	}
 
 	c.sendProposeJob(job)
 
-	return errChan
+	return errChan, appliedChan
+}
+
+// SubmitAction queues an action to be added to the chain, possibly in the same block as other actions
+func (c *Chain) SubmitAction(actionType string, actionJSON []byte) chan *ActionResult {
+	resultChan := make(chan *ActionResult, 1)
+
+	pending := &PendingAction{
+		ActionType: actionType,
+		ActionJSON: actionJSON,
+		ResultChan: resultChan,
+	}
+
+	c.PendingChan <- pending
+
+	return resultChan
 }
 
 // VerifyProposedBlock checks and then sets the proposed block
@@ -98,11 +148,31 @@ func (c *Chain) sendReserveJob(job *ReserveIDJob) {
 }
 
 // LoadFromBlocks loads a chain from a block array
+// if the chain was reopened from a store, blocks it already has are checked and skipped
 func (c *Chain) LoadFromBlocks(blocks []*Block) error {
-	if len(c.Blocks) > 0 {
-		return fmt.Errorf("LoadFromBlocks attempted to load chain with %d existing blocks", len(c.Blocks))
+	existing := c.Blocks()
+
+	for i := range blocks {
+		if i < len(existing) {
+			if !existing[i].IsSameAsBlock(blocks[i]) {
+				return fmt.Errorf("LoadFromBlocks found block %d with ID %q that does not match existing block with ID %q", i, blocks[i].ID, existing[i].ID)
+			}
+
+			continue
+		}
+
+		if err := c.AppendBlocks(blocks[i:]); err != nil {
+			return err
+		}
+
+		break
 	}
 
+	return nil
+}
+
+// AppendBlocks verifies and commits blocks that follow the last block in the chain, in order
+func (c *Chain) AppendBlocks(blocks []*Block) error {
 	for i := range blocks {
 		errChan := c.VerifyProposedBlock(blocks[i], "")
 		if err := <-errChan; err != nil {
@@ -143,13 +213,17 @@ func (c *Chain) GetNextCommitted() chan *Block {
 
 // HasProposedOrCommittedBlock checks if a block is proposed or previously committed
 func (c *Chain) HasProposedOrCommittedBlock(block *Block) bool {
-	if c.Proposed != nil && c.Proposed.IsSameAsBlock(block) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.proposed != nil && c.proposed.IsSameAsBlock(block) {
 		return true
 	}
 
 	// check if the block being checked is the next to be committed
-	last := c.LastBlock()
-	if last != nil {
+	if len(c.blocks) > 0 {
+		last := c.blocks[len(c.blocks)-1]
+
 		hash, err := last.Hash()
 		if err != nil {
 			return false
@@ -162,8 +236,8 @@ func (c *Chain) HasProposedOrCommittedBlock(block *Block) bool {
 	}
 
 	// if that fails, work backwards in the chain to see if any of them match
-	for i := len(c.Blocks) - 1; i > 0; i-- {
-		if c.Blocks[i].IsSameAsBlock(block) {
+	for i := len(c.blocks) - 1; i > 0; i-- {
+		if c.blocks[i].IsSameAsBlock(block) {
 			return true
 		}
 	}
@@ -171,36 +245,129 @@ func (c *Chain) HasProposedOrCommittedBlock(block *Block) bool {
 	return false
 }
 
+// HasCommittedBlock checks if a block has been committed
+func (c *Chain) HasCommittedBlock(block *Block) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].ID == block.ID {
+			return c.blocks[i].IsSameAsBlock(block)
+		}
+	}
+
+	return false
+}
+
 // BlocksAfterID returns all the committed blocks after id
 func (c *Chain) BlocksAfterID(id string) []*Block {
-	for i := len(c.Blocks) - 1; i > 0; i-- {
-		if c.Blocks[i].ID == id {
-			return c.Blocks[i+1:]
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].ID == id {
+			return copyBlocks(c.blocks[i+1:])
+		}
+	}
+
+	return nil
+}
+
+// IndexOfBlock returns the index of the committed block with id, or -1 if it hasn't been committed
+func (c *Chain) IndexOfBlock(id string) int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].ID == id {
+			return i
 		}
 	}
 
+	return -1
+}
+
+// Blocks returns a copy of all the committed blocks
+func (c *Chain) Blocks() []*Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return copyBlocks(c.blocks)
+}
+
+// Height returns the number of committed blocks
+func (c *Chain) Height() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.blocks)
+}
+
+// Proposed returns the block currently proposed to be committed next, if any
+func (c *Chain) Proposed() *Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.proposed
+}
+
+// SetProposed sets the block proposed to be committed next, nil clears it
+func (c *Chain) SetProposed(block *Block) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.proposed = block
+}
+
+func copyBlocks(blocks []*Block) []*Block {
+	blocksCopy := make([]*Block, len(blocks))
+	copy(blocksCopy, blocks)
+
+	return blocksCopy
+}
+
+// Commit persists a block to the store and then appends it to the chain
+// only the commit worker calls this, so the store is written without holding the lock
+// if the store fails to append, it has been rolled back and the block is not added, so it can be committed again later
+func (c *Chain) Commit(block *Block) error {
+	if err := c.Store.Append(block); err != nil {
+		return errors.Wrap(err, "Commit failed to Store.Append")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.blocks = append(c.blocks, block)
+
 	return nil
 }
 
-// EmptyChain creates an enpty chain
+// EmptyChain creates an enpty chain that is not persisted
 func EmptyChain() *Chain {
+	return EmptyChainWithStore(NewMemoryStore())
+}
+
+// EmptyChainWithStore creates an empty chain that persists committed blocks to store
+func EmptyChainWithStore(store Store) *Chain {
 	chain := &Chain{
-		Blocks:         []*Block{},
+		blocks:         []*Block{},
+		Store:          store,
 		ReserveChan:    make(chan *ReserveIDJob, 2),
 		ProposeChan:    make(chan *NewBlockJob, 2),
 		VerifyChan:     make(chan *NewBlockJob, 2),
 		CommitChan:     make(chan *NewBlockJob, 2),
 		ReservedChan:   make(chan *ReserveIDJob, 2),
 		CommittedChan:  make(chan *Block, 2),
-		ActionChan:     make(chan *Block),
+		ActionChan:     make(chan *NewBlockJob),
+		PendingChan:    make(chan *PendingAction, 1024),
 		DistributeChan: make(chan *Block),
 	}
 
 	return chain
 }
 
-// BrandNewChain creates a fresh chain using the master keyPair
-func BrandNewChain(masterKeyPair *acrypto.KeyPair, globalKey *acrypto.SymKey, blockData []byte, actionType string) (*Chain, error) {
+// BrandNewChain creates a fresh chain using the master keyPair and persists its genesis block to store
+func BrandNewChain(masterKeyPair *acrypto.KeyPair, globalKey *acrypto.SymKey, blockData []byte, actionType string, store Store) (*Chain, error) {
 	if masterKeyPair.KID != acrypto.MasterKeyPairKID {
 		return nil, fmt.Errorf("attempted to create new chain with non-master keyPair")
 	}
@@ -218,19 +385,80 @@ func BrandNewChain(masterKeyPair *acrypto.KeyPair, globalKey *acrypto.SymKey, bl
 		return nil, errors.Wrap(err, "BrandNewChain failed to PrepareForCommit")
 	}
 
-	chain := EmptyChain()
+	existing, err := store.Blocks()
+	if err != nil {
+		return nil, errors.Wrap(err, "BrandNewChain failed to store.Blocks")
+	}
+
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("BrandNewChain attempted to create new chain in a store with %d existing blocks", len(existing))
+	}
+
+	chain := EmptyChainWithStore(store)
 
 	// if this fails in the worker, we'll have to catch it and fatal
-	chain.Blocks = append(chain.Blocks, genesis)
+	if err := chain.Commit(genesis); err != nil {
+		return nil, errors.Wrap(err, "BrandNewChain failed to Commit")
+	}
 
 	return chain, nil
 }
 
+// ReopenChain creates a chain from the blocks previously committed to store
+// signatures were verified when the blocks were committed, so only the links between blocks are checked here
+func ReopenChain(store Store) (*Chain, error) {
+	blocks, err := store.Blocks()
+	if err != nil {
+		return nil, errors.Wrap(err, "ReopenChain failed to store.Blocks")
+	}
+
+	for i := range blocks {
+		if err := checkLink(blocks, i); err != nil {
+			return nil, errors.Wrap(err, "ReopenChain failed to checkLink")
+		}
+	}
+
+	chain := EmptyChainWithStore(store)
+	chain.blocks = blocks
+
+	logger.LogInfo(fmt.Sprintf("ReopenChain reopened chain with %d blocks", len(blocks)))
+
+	return chain, nil
+}
+
+func checkLink(blocks []*Block, index int) error {
+	block := blocks[index]
+
+	if index == 0 {
+		if block.ID != genesisBlockID {
+			return fmt.Errorf("checkLink found first block with non-genesis ID %q", block.ID)
+		}
+
+		return nil
+	}
+
+	prev := blocks[index-1]
+
+	prevHash, err := prev.Hash()
+	if err != nil {
+		return errors.Wrap(err, "checkLink failed to prev.Hash")
+	}
+
+	if block.ID != acrypto.Base64URLEncode(prevHash) || block.PrevID != prev.ID {
+		return fmt.Errorf("checkLink found block with ID %q that does not follow block with ID %q", block.ID, prev.ID)
+	}
+
+	return nil
+}
+
 // LastBlock returns the last block in the chain
 func (c *Chain) LastBlock() *Block {
-	if len(c.Blocks) == 0 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if len(c.blocks) == 0 {
 		return nil
 	}
 
-	return c.Blocks[len(c.Blocks)-1]
+	return c.blocks[len(c.blocks)-1]
 }
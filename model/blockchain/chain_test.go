@@ -0,0 +1,196 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	acrypto "github.com/astromechio/astrocache/crypto"
+)
+
+func newTestChain(t *testing.T) (*Chain, *acrypto.KeyPair, *acrypto.SymKey) {
+	masterKeyPair, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := BrandNewChain(masterKeyPair, globalKey, []byte("{}"), "test.genesis", NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain, masterKeyPair, globalKey
+}
+
+// newTestBlocks prepares count blocks that follow the chain's last block
+// they are prepared up front, since signing them is much slower than committing
+func newTestBlocks(t *testing.T, chain *Chain, keyPair *acrypto.KeyPair, globalKey *acrypto.SymKey, count int) []*Block {
+	blocks := []*Block{}
+	prev := chain.LastBlock()
+
+	for i := 0; i < count; i++ {
+		block, err := NewBlockWithData(globalKey, []byte(fmt.Sprintf(`{"i":%d}`, i)), "test.action")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := block.PrepareForCommit(keyPair, prev); err != nil {
+			t.Fatal(err)
+		}
+
+		blocks = append(blocks, block)
+		prev = block
+	}
+
+	return blocks
+}
+
+// verifiers serve the blocks after the last one a node has while the commit worker adds more
+func TestChainCatchUpWhileCommitting(t *testing.T) {
+	const numBlocks = 100
+
+	chain, keyPair, globalKey := newTestChain(t)
+	blocks := newTestBlocks(t, chain, keyPair, globalKey, numBlocks)
+
+	followers := make([][]*Block, 4)
+
+	var wg sync.WaitGroup
+
+	for f := range followers {
+		wg.Add(1)
+
+		go func(f int) {
+			defer wg.Done()
+
+			local := chain.Blocks()
+
+			for len(local) < numBlocks+1 {
+				after := chain.BlocksAfterID(local[len(local)-1].ID)
+
+				for _, block := range after {
+					local = append(local, block)
+
+					if err := checkLink(local, len(local)-1); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+
+			followers[f] = local
+		}(f)
+	}
+
+	// only the commit worker commits, so blocks are committed from a single goroutine
+	for _, block := range blocks {
+		if err := chain.Commit(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wg.Wait()
+
+	committed := chain.Blocks()
+
+	if len(committed) != numBlocks+1 {
+		t.Fatalf("expected height %d, got %d", numBlocks+1, len(committed))
+	}
+
+	for f, local := range followers {
+		if len(local) != len(committed) {
+			t.Errorf("expected follower %d to catch up on %d blocks, got %d", f, len(committed), len(local))
+			continue
+		}
+
+		for i := range local {
+			if local[i] != committed[i] {
+				t.Errorf("expected follower %d to have block %q at index %d, got %q", f, committed[i].ID, i, local[i].ID)
+				break
+			}
+		}
+	}
+}
+
+// verifiers answer checks for blocks while their own proposed block is set, committed and cleared
+func TestChainCheckBlockWhileCommitting(t *testing.T) {
+	const numBlocks = 100
+
+	chain, keyPair, globalKey := newTestChain(t)
+	blocks := newTestBlocks(t, chain, keyPair, globalKey, numBlocks)
+
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// the block at index i in blocks is at index i+1 in the chain, after the genesis block
+				height := chain.Height()
+
+				for i := 0; i < height-1; i++ {
+					if !chain.HasCommittedBlock(blocks[i]) || !chain.HasProposedOrCommittedBlock(blocks[i]) {
+						t.Errorf("expected block %d to be committed once the height is %d", i, height)
+						return
+					}
+				}
+
+				if proposed := chain.Proposed(); proposed != nil && !chain.HasProposedOrCommittedBlock(proposed) {
+					t.Errorf("expected proposed block %q to be found", proposed.ID)
+					return
+				}
+			}
+		}()
+	}
+
+	for _, block := range blocks {
+		chain.SetProposed(block)
+
+		if err := chain.Commit(block); err != nil {
+			t.Fatal(err)
+		}
+
+		chain.SetProposed(nil)
+	}
+
+	close(done)
+	wg.Wait()
+
+	if chain.Proposed() != nil {
+		t.Error("expected no block to be left proposed")
+	}
+
+	if height := chain.Height(); height != numBlocks+1 {
+		t.Errorf("expected height %d, got %d", numBlocks+1, height)
+	}
+
+	if index := chain.IndexOfBlock(blocks[numBlocks-1].ID); index != numBlocks {
+		t.Errorf("expected the last block at index %d, got %d", numBlocks, index)
+	}
+}
+
+func TestChainBlocksIsACopy(t *testing.T) {
+	chain, _, _ := newTestChain(t)
+
+	blocks := chain.Blocks()
+	blocks[0] = nil
+
+	if chain.Blocks()[0] == nil {
+		t.Error("expected changing the returned blocks not to change the chain")
+	}
+}
@@ -0,0 +1,262 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/astromechio/astrocache/logger"
+	"github.com/pkg/errors"
+)
+
+// Notes:
+// The file store is an append-only log of JSON encoded blocks
+// Every record is framed as [4 byte big endian length][4 byte big endian crc32 of the JSON][JSON]
+// Every Append is fsync'd before returning, so a committed block survives the process dying
+// If an Append fails, the file is truncated back to where it started so that later records don't follow a partial one
+// If the process dies partway through an Append, the final record will be short, fail its checksum, or be left as zeros;
+// OpenFileStore detects this and truncates the file back to the end of the last complete record
+
+const (
+	recordHeaderSize = 8
+	maxRecordSize    = 64 * 1024 * 1024
+)
+
+// FileStore is a Store backed by an append-only, fsync'd file
+type FileStore struct {
+	path string
+	file *os.File
+	lock sync.Mutex
+}
+
+// OpenFileStore opens (or creates) the block log at path, repairing a torn final write if needed
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenFileStore failed to OpenFile")
+	}
+
+	_, goodSize, err := readRecords(file)
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "OpenFileStore failed to readRecords")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "OpenFileStore failed to Stat")
+	}
+
+	if info.Size() > goodSize {
+		logger.LogWarn(fmt.Sprintf("OpenFileStore found a torn write at the end of %s, truncating %d bytes", path, info.Size()-goodSize))
+
+		if err := file.Truncate(goodSize); err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "OpenFileStore failed to Truncate")
+		}
+
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "OpenFileStore failed to Sync")
+		}
+	}
+
+	if _, err := file.Seek(goodSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "OpenFileStore failed to Seek")
+	}
+
+	store := &FileStore{
+		path: path,
+		file: file,
+	}
+
+	return store, nil
+}
+
+// Append writes a block to the end of the log and fsyncs it
+func (fs *FileStore) Append(block *Block) error {
+	blockJSON, err := json.Marshal(block)
+	if err != nil {
+		return errors.Wrap(err, "Append failed to Marshal")
+	}
+
+	record := make([]byte, recordHeaderSize+len(blockJSON))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(blockJSON)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(blockJSON))
+	copy(record[recordHeaderSize:], blockJSON)
+
+	if len(blockJSON) > maxRecordSize {
+		return fmt.Errorf("Append refused block of %d bytes, the most a record can hold is %d", len(blockJSON), maxRecordSize)
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	offset, err := fs.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "Append failed to Seek")
+	}
+
+	if _, err := fs.file.Write(record); err != nil {
+		return fs.rollback(offset, errors.Wrap(err, "Append failed to Write"))
+	}
+
+	if err := fs.file.Sync(); err != nil {
+		return fs.rollback(offset, errors.Wrap(err, "Append failed to Sync"))
+	}
+
+	return nil
+}
+
+// rollback truncates the log back to offset after a failed Append, so that the next Append doesn't land after a partial record
+// cause is returned, wrapped with the truncate error if that fails too
+func (fs *FileStore) rollback(offset int64, cause error) error {
+	if err := fs.file.Truncate(offset); err != nil {
+		return errors.Wrap(cause, "rollback failed to Truncate: "+err.Error())
+	}
+
+	if _, err := fs.file.Seek(offset, io.SeekStart); err != nil {
+		return errors.Wrap(cause, "rollback failed to Seek: "+err.Error())
+	}
+
+	return cause
+}
+
+// Blocks reads every block in the log
+func (fs *FileStore) Blocks() ([]*Block, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	file, err := os.Open(fs.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Blocks failed to Open")
+	}
+	defer file.Close()
+
+	blocks, _, err := readRecords(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Blocks failed to readRecords")
+	}
+
+	return blocks, nil
+}
+
+// Close closes the underlying file
+func (fs *FileStore) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return fs.file.Close()
+}
+
+// readRecords reads blocks from the start of file until the end or the first record that was torn
+// it returns the blocks and the offset of the end of the last complete record
+// a torn record is one cut short, or one with a header Append would never write (such as the zeros a crash can leave in a file's tail)
+// or that fails its checksum, and is either the last in the file or followed only by zeros
+// a bad header or checksum with anything else after it means the log is corrupt rather than torn, and is an error
+func readRecords(file *os.File) ([]*Block, int64, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, errors.Wrap(err, "readRecords failed to Seek")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "readRecords failed to Stat")
+	}
+
+	reader := bufio.NewReader(file)
+
+	blocks := []*Block{}
+	offset := int64(0)
+	header := make([]byte, recordHeaderSize)
+
+	for true {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+
+			return nil, 0, errors.Wrap(err, "readRecords failed to read header")
+		}
+
+		length := binary.BigEndian.Uint32(header[0:4])
+		checksum := binary.BigEndian.Uint32(header[4:8])
+
+		recordEnd := offset + recordHeaderSize + int64(length)
+
+		if length == 0 || length > maxRecordSize {
+			// Append never writes a record like this, and there's no telling where the next record would start
+			torn, err := onlyZerosLeft(reader)
+			if err != nil {
+				return nil, 0, errors.Wrap(err, "readRecords failed to onlyZerosLeft")
+			}
+
+			if torn {
+				// the final record's header never reached the disk, or only part of it did
+				break
+			}
+
+			return nil, 0, fmt.Errorf("readRecords found corrupt record header at offset %d", offset)
+		}
+
+		if recordEnd > info.Size() {
+			// the header was written but not all of the data, so this is the final record
+			break
+		}
+
+		blockJSON := make([]byte, length)
+		if _, err := io.ReadFull(reader, blockJSON); err != nil {
+			return nil, 0, errors.Wrap(err, "readRecords failed to read record")
+		}
+
+		block := &Block{}
+		if crc32.ChecksumIEEE(blockJSON) != checksum || json.Unmarshal(blockJSON, block) != nil {
+			torn, err := onlyZerosLeft(reader)
+			if err != nil {
+				return nil, 0, errors.Wrap(err, "readRecords failed to onlyZerosLeft")
+			}
+
+			if torn {
+				// the final record was partially flushed
+				break
+			}
+
+			return nil, 0, fmt.Errorf("readRecords found corrupt record at offset %d", offset)
+		}
+
+		blocks = append(blocks, block)
+		offset = recordEnd
+	}
+
+	return blocks, offset, nil
+}
+
+// onlyZerosLeft reads the rest of reader and returns true if it holds nothing but zeros, which includes holding nothing at all
+func onlyZerosLeft(reader io.Reader) (bool, error) {
+	buf := make([]byte, 4096)
+
+	for true {
+		n, err := reader.Read(buf)
+
+		for _, b := range buf[:n] {
+			if b != 0 {
+				return false, nil
+			}
+		}
+
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestFileStore returns the path of a log holding count blocks, along with the size of the file after each one was appended
+func newTestFileStore(t *testing.T, count int) (string, []int64) {
+	path := filepath.Join(t.TempDir(), "chain.log")
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	sizes := []int64{}
+
+	for i := 0; i < count; i++ {
+		if err := store.Append(&Block{ID: fmt.Sprintf("block-%d", i), PrevID: fmt.Sprintf("block-%d", i-1)}); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sizes = append(sizes, info.Size())
+	}
+
+	return path, sizes
+}
+
+// reopen opens the log at path after it was damaged, checking that it holds the expected number of blocks and can be appended to
+func reopen(t *testing.T, path string, expected int, expectedSize int64) {
+	t.Helper()
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("expected a torn log to be repaired, got %s", err)
+	}
+	defer store.Close()
+
+	blocks, err := store.Blocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(blocks) != expected {
+		t.Fatalf("expected %d blocks, got %d", expected, len(blocks))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() != expectedSize {
+		t.Errorf("expected the log to be truncated to %d bytes, got %d", expectedSize, info.Size())
+	}
+
+	if err := store.Append(&Block{ID: "after"}); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err = store.Blocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(blocks) != expected+1 || blocks[expected].ID != "after" {
+		t.Errorf("expected a block appended after the repair to be read back, got %d blocks", len(blocks))
+	}
+}
+
+func truncateTo(t *testing.T, path string, size int64) {
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeAt(t *testing.T, path string, data []byte, offset int64) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteAt(data, offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreTruncatedHeader(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	truncateTo(t, path, sizes[1]+recordHeaderSize/2)
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreTruncatedBody(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	truncateTo(t, path, sizes[2]-5)
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreZeroFilledTail(t *testing.T) {
+	path, sizes := newTestFileStore(t, 2)
+
+	writeAt(t, path, make([]byte, 4096), sizes[1])
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreZeroFilledRecord(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	// the final record's space was allocated but its data never reached the disk
+	writeAt(t, path, make([]byte, sizes[2]-sizes[1]), sizes[1])
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreOversizedHeader(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	header := make([]byte, recordHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], maxRecordSize+1)
+
+	// the rest of the record is still there, so the header was damaged rather than torn
+	writeAt(t, path, header, sizes[1])
+
+	if _, err := OpenFileStore(path); err == nil {
+		t.Error("expected a log with an oversized header followed by data to fail to open")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() != sizes[2] {
+		t.Errorf("expected a corrupt log to be left at %d bytes, got %d", sizes[2], info.Size())
+	}
+}
+
+func TestFileStoreZeroLengthHeaderMidLog(t *testing.T) {
+	path, sizes := newTestFileStore(t, 4)
+
+	// zeroing the third record's header leaves its data and the fourth record after it
+	writeAt(t, path, make([]byte, recordHeaderSize), sizes[1])
+
+	if _, err := OpenFileStore(path); err == nil {
+		t.Error("expected a log with a zeroed header before other records to fail to open")
+	}
+}
+
+func TestFileStoreBadChecksum(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	writeAt(t, path, []byte{'X'}, sizes[2]-2)
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreBadChecksumFollowedByZeros(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	writeAt(t, path, []byte{'X'}, sizes[2]-2)
+	writeAt(t, path, make([]byte, 100), sizes[2])
+
+	reopen(t, path, 2, sizes[1])
+}
+
+func TestFileStoreCorruptRecord(t *testing.T) {
+	path, sizes := newTestFileStore(t, 3)
+
+	// a bad record with a good one after it wasn't torn by a crash
+	writeAt(t, path, []byte{'X'}, sizes[1]-2)
+
+	if _, err := OpenFileStore(path); err == nil {
+		t.Error("expected a log with a corrupt record before the final one to fail to open")
+	}
+}
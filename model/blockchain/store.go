@@ -0,0 +1,33 @@
+package blockchain
+
+// Store defines a backend that committed blocks are persisted to
+// Append is called once for every block committed to the chain, in chain order, and must leave the store as it was if it fails
+// Blocks returns every block previously appended, used to reopen a chain on boot
+type Store interface {
+	Append(*Block) error
+	Blocks() ([]*Block, error)
+	Close() error
+}
+
+// memoryStore keeps nothing beyond what the chain itself holds in memory
+type memoryStore struct{}
+
+// NewMemoryStore returns a store that does not persist blocks
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+// Append does nothing, the chain keeps its own blocks in memory
+func (ms *memoryStore) Append(block *Block) error {
+	return nil
+}
+
+// Blocks always returns an empty slice
+func (ms *memoryStore) Blocks() ([]*Block, error) {
+	return []*Block{}, nil
+}
+
+// Close does nothing
+func (ms *memoryStore) Close() error {
+	return nil
+}
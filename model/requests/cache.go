@@ -1,11 +1,16 @@
 package requests
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 )
@@ -13,17 +18,68 @@ import (
 // KeyRequestKey and others are keys used for cache requests
 const (
 	KeyRequestKey = "key"
+
+	ETagHeader        = "ETag"
+	IfMatchHeader     = "If-Match"
+	ContentTypeHeader = "Content-Type"
 )
 
-// SetValueRequest contains information for adding a new node
+// MaxTTL is the longest TTL a key can be given, in seconds (10 years)
+// it keeps expiry times well inside what a time.Duration can hold, which would otherwise wrap and expire the key at an arbitrary time
+const MaxTTL = 10 * 365 * 24 * 60 * 60
+
+// verifyTTL checks that a request's TTL is between 0 (no TTL) and MaxTTL
+func verifyTTL(name string, ttl int64) error {
+	if ttl < 0 {
+		return fmt.Errorf("%s is negative", name)
+	}
+
+	if ttl > MaxTTL {
+		return fmt.Errorf("%s is %d, the max is %d", name, ttl, MaxTTL)
+	}
+
+	return nil
+}
+
+// WriteResponse contains the ID of the block a write was committed in
+// a read from a worker with it as minBlock waits until the worker has applied the write
+// Version is the version of the keys it wrote, which differs from BlockID when the write was batched with others
+type WriteResponse struct {
+	BlockID string `json:"blockId"`
+	Version string `json:"version,omitempty"`
+}
+
+// ETag returns the ETag of the keys the write set
+func (wr *WriteResponse) ETag() string {
+	if wr.Version == "" {
+		return FormatETag(wr.BlockID)
+	}
+
+	return FormatETag(wr.Version)
+}
+
+// SetValueRequest contains information for setting a value
+// the value is either text in Value, or bytes in Data (base64 encoded in JSON), ContentType is optional and is returned when the value is read
+// a PUT request's body is the value itself, its Content-Type header is the ContentType and the ttl query param is the TTL
+// Raw is set for a PUT, whose value may be empty since a zero-length body is still a value, and is kept when the request is forwarded
+// TTL is optional, and is the number of seconds until the value expires, up to MaxTTL
+// IfMatch is optional, and is the version the key must have for the value to be set (from the If-Match header)
+// Namespace is empty for the default namespace, and Token is the namespace's access token (from the Authorization header)
 type SetValueRequest struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	TTL         int64  `json:"ttl,omitempty"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+	Raw         bool   `json:"raw,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Token       string `json:"-"`
 }
 
 // Path returns the path for a new node request
 func (sv *SetValueRequest) Path() string {
-	return fmt.Sprintf("v1/value/%s", sv.Key)
+	return namespacePath(sv.Namespace, fmt.Sprintf("value/%s", sv.Key))
 }
 
 // FromRequest loads a new node request from an http request
@@ -34,7 +90,20 @@ func (sv *SetValueRequest) FromRequest(r *http.Request) error {
 	}
 	defer r.Body.Close()
 
-	if err := json.Unmarshal(reqBody, sv); err != nil {
+	if r.Method == http.MethodPut {
+		sv.Raw = true
+		sv.Data = reqBody
+		sv.ContentType = r.Header.Get(ContentTypeHeader)
+
+		if ttl := r.URL.Query().Get("ttl"); ttl != "" {
+			parsed, err := strconv.ParseInt(ttl, 10, 64)
+			if err != nil {
+				return fmt.Errorf("ttl must be an integer, got %q", ttl)
+			}
+
+			sv.TTL = parsed
+		}
+	} else if err := json.Unmarshal(reqBody, sv); err != nil {
 		return err
 	}
 
@@ -44,6 +113,12 @@ func (sv *SetValueRequest) FromRequest(r *http.Request) error {
 	}
 
 	sv.Key = key
+	sv.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	sv.Token = BearerToken(r)
+
+	if ifMatch := r.Header.Get(IfMatchHeader); ifMatch != "" {
+		sv.IfMatch = ParseETag(ifMatch)
+	}
 
 	return nil
 }
@@ -58,9 +133,470 @@ func (sv *SetValueRequest) Verify() error {
 		return errors.New("sv.Key is nil")
 	}
 
-	if sv.Value == "" {
+	if sv.Value == "" && len(sv.Data) == 0 && !sv.Raw {
 		return errors.New("sv.Value is nil")
 	}
 
+	if sv.Value != "" && len(sv.Data) > 0 {
+		return errors.New("sv can't have both Value and Data")
+	}
+
+	if sv.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(sv.ContentType); err != nil {
+			return fmt.Errorf("sv.ContentType %q is invalid", sv.ContentType)
+		}
+	}
+
+	if err := verifyTTL("sv.TTL", sv.TTL); err != nil {
+		return err
+	}
+
+	if sv.Namespace != "" {
+		if err := VerifyNamespaceName(sv.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Bytes returns the value being set
+func (sv *SetValueRequest) Bytes() []byte {
+	if len(sv.Data) > 0 {
+		return sv.Data
+	}
+
+	return []byte(sv.Value)
+}
+
+// ValueSet holds values returned in a JSON response
+// values that are valid UTF-8 are in Values, anything else is in Binary (base64 encoded in JSON) so that it survives encoding
+// ContentTypes only has the keys whose value was set with a content type
+type ValueSet struct {
+	Values       map[string]string `json:"values"`
+	Binary       map[string][]byte `json:"binary,omitempty"`
+	ContentTypes map[string]string `json:"contentTypes,omitempty"`
+}
+
+// NewValueSet creates an empty ValueSet
+func NewValueSet() *ValueSet {
+	return &ValueSet{
+		Values: make(map[string]string),
+	}
+}
+
+// Add adds a key's value and content type to the set
+func (vs *ValueSet) Add(key string, val []byte, contentType string) {
+	if utf8.Valid(val) {
+		vs.Values[key] = string(val)
+	} else {
+		if vs.Binary == nil {
+			vs.Binary = make(map[string][]byte)
+		}
+
+		vs.Binary[key] = val
+	}
+
+	if contentType != "" {
+		if vs.ContentTypes == nil {
+			vs.ContentTypes = make(map[string]string)
+		}
+
+		vs.ContentTypes[key] = contentType
+	}
+}
+
+// DeleteValueRequest contains information for deleting a key
+// IfMatch, if set, is the version the key must have for it to be deleted, and is read from the If-Match header
+type DeleteValueRequest struct {
+	Key       string `json:"key"`
+	IfMatch   string `json:"ifMatch,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Token     string `json:"-"`
+}
+
+// Path returns the path for a delete value request
+func (dv *DeleteValueRequest) Path() string {
+	return namespacePath(dv.Namespace, fmt.Sprintf("value/%s", dv.Key))
+}
+
+// FromRequest loads a delete value request from an http request
+func (dv *DeleteValueRequest) FromRequest(r *http.Request) error {
+	key := mux.Vars(r)[KeyRequestKey]
+	if key == "" {
+		return errors.New("No key found in request URL")
+	}
+
+	dv.Key = key
+	dv.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	dv.Token = BearerToken(r)
+
+	if ifMatch := r.Header.Get(IfMatchHeader); ifMatch != "" {
+		dv.IfMatch = ParseETag(ifMatch)
+	}
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (dv *DeleteValueRequest) Verify() error {
+	if dv == nil {
+		return errors.New("dv is nil")
+	}
+
+	if dv.Key == "" {
+		return errors.New("dv.Key is nil")
+	}
+
+	if dv.Namespace != "" {
+		if err := VerifyNamespaceName(dv.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IncrementValueRequest contains information for incrementing a key's integer value
+// Delta defaults to 1, and can be negative to decrement
+// TTL is optional, and is only used if the key does not exist yet
+type IncrementValueRequest struct {
+	Key       string `json:"key"`
+	Delta     int64  `json:"delta"`
+	TTL       int64  `json:"ttl,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Token     string `json:"-"`
+}
+
+// IncrementValueResponse contains the value of a key after it was incremented
+// Value is missing if the verifier that committed the increment had evicted the key, and can be read from a worker with minBlock set to BlockID
+type IncrementValueResponse struct {
+	Key     string `json:"key"`
+	Value   *int64 `json:"value,omitempty"`
+	BlockID string `json:"blockId"`
+}
+
+// Path returns the path for an increment value request
+func (iv *IncrementValueRequest) Path() string {
+	return namespacePath(iv.Namespace, fmt.Sprintf("value/%s/incr", iv.Key))
+}
+
+// FromRequest loads an increment value request from an http request, the body is optional
+func (iv *IncrementValueRequest) FromRequest(r *http.Request) error {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	iv.Delta = 1
+
+	if len(reqBody) > 0 {
+		if err := json.Unmarshal(reqBody, iv); err != nil {
+			return err
+		}
+	}
+
+	key := mux.Vars(r)[KeyRequestKey]
+	if key == "" {
+		return errors.New("No key found in request URL")
+	}
+
+	iv.Key = key
+	iv.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	iv.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (iv *IncrementValueRequest) Verify() error {
+	if iv == nil {
+		return errors.New("iv is nil")
+	}
+
+	if iv.Key == "" {
+		return errors.New("iv.Key is nil")
+	}
+
+	if err := verifyTTL("iv.TTL", iv.TTL); err != nil {
+		return err
+	}
+
+	if iv.Namespace != "" {
+		if err := VerifyNamespaceName(iv.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MaxBatchSize is the largest number of keys a batch request can change
+const MaxBatchSize = 1000
+
+// BatchSetRequest contains information for setting and deleting many keys at once
+// each key can only appear once in the batch, and every key is in Namespace
+type BatchSetRequest struct {
+	Sets      []*SetValueRequest `json:"sets,omitempty"`
+	Deletes   []string           `json:"deletes,omitempty"`
+	Namespace string             `json:"namespace,omitempty"`
+	Token     string             `json:"-"`
+}
+
+// Path returns the path for a batch set request
+func (bs *BatchSetRequest) Path() string {
+	return namespacePath(bs.Namespace, "values")
+}
+
+// FromRequest loads a batch set request from an http request
+func (bs *BatchSetRequest) FromRequest(r *http.Request) error {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(reqBody, bs); err != nil {
+		return err
+	}
+
+	bs.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	bs.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (bs *BatchSetRequest) Verify() error {
+	if bs == nil {
+		return errors.New("bs is nil")
+	}
+
+	size := len(bs.Sets) + len(bs.Deletes)
+
+	if size == 0 {
+		return errors.New("bs is empty")
+	}
+
+	if size > MaxBatchSize {
+		return fmt.Errorf("bs has %d keys, the max is %d", size, MaxBatchSize)
+	}
+
+	if bs.Namespace != "" {
+		if err := VerifyNamespaceName(bs.Namespace); err != nil {
+			return err
+		}
+	}
+
+	keys := make(map[string]bool, size)
+
+	for _, set := range bs.Sets {
+		if err := set.Verify(); err != nil {
+			return err
+		}
+
+		if keys[set.Key] {
+			return fmt.Errorf("bs has key %q more than once", set.Key)
+		}
+
+		keys[set.Key] = true
+	}
+
+	for _, key := range bs.Deletes {
+		if key == "" {
+			return errors.New("bs has an empty key to delete")
+		}
+
+		if keys[key] {
+			return fmt.Errorf("bs has key %q more than once", key)
+		}
+
+		keys[key] = true
+	}
+
+	return nil
+}
+
+// GetValuesRequest contains the keys for a batch read
+type GetValuesRequest struct {
+	Keys      []string `json:"keys"`
+	Namespace string   `json:"namespace,omitempty"`
+	Token     string   `json:"-"`
+}
+
+// GetValuesResponse contains the result of a batch read
+// every value was read at the same chain Height, BlockID is the last block applied at that height
+type GetValuesResponse struct {
+	*ValueSet
+	Misses  []string `json:"misses"`
+	Height  int      `json:"height"`
+	BlockID string   `json:"blockId"`
+}
+
+// Path returns the path for a batch read request
+func (gv *GetValuesRequest) Path() string {
+	return namespacePath(gv.Namespace, "values/get")
+}
+
+// FromRequest loads a batch read request from an http request
+// the keys come from the JSON body of a POST, or from repeated key query params of a GET
+func (gv *GetValuesRequest) FromRequest(r *http.Request) error {
+	gv.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	gv.Token = BearerToken(r)
+
+	if r.Method == http.MethodGet {
+		gv.Keys = r.URL.Query()[KeyRequestKey]
+		return nil
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(reqBody, gv); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (gv *GetValuesRequest) Verify() error {
+	if gv == nil {
+		return errors.New("gv is nil")
+	}
+
+	if gv.Namespace != "" {
+		if err := VerifyNamespaceName(gv.Namespace); err != nil {
+			return err
+		}
+	}
+
+	if len(gv.Keys) == 0 {
+		return errors.New("gv.Keys is empty")
+	}
+
+	if len(gv.Keys) > MaxBatchSize {
+		return fmt.Errorf("gv has %d keys, the max is %d", len(gv.Keys), MaxBatchSize)
+	}
+
+	for _, key := range gv.Keys {
+		if key == "" {
+			return errors.New("gv has an empty key")
+		}
+	}
+
+	return nil
+}
+
+// DefaultScanLimit and others bound the number of keys returned by a key listing
+const (
+	DefaultScanLimit = 100
+	MaxScanLimit     = 1000
+)
+
+// ListKeysRequest contains the query for a key listing
+// Cursor is empty for the first page, and is the Cursor from the previous response after that
+type ListKeysRequest struct {
+	Prefix     string
+	Cursor     string
+	Limit      int
+	WithValues bool
+	Namespace  string
+	Token      string
+}
+
+// ListKeysResponse contains a page of keys, in order
+// Cursor is empty when there are no more keys, the ValueSet is only set if values were requested
+type ListKeysResponse struct {
+	Keys []string `json:"keys"`
+	*ValueSet
+	Cursor  string `json:"cursor,omitempty"`
+	Height  int    `json:"height"`
+	BlockID string `json:"blockId"`
+}
+
+// FromRequest loads a key listing request from an http request's query params
+func (lk *ListKeysRequest) FromRequest(r *http.Request) error {
+	query := r.URL.Query()
+
+	lk.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	lk.Token = BearerToken(r)
+	lk.Prefix = query.Get("prefix")
+	lk.Cursor = query.Get("cursor")
+	lk.Limit = DefaultScanLimit
+
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return fmt.Errorf("limit must be an integer, got %q", limit)
+		}
+
+		lk.Limit = parsed
+	}
+
+	if values := query.Get("values"); values != "" {
+		withValues, err := strconv.ParseBool(values)
+		if err != nil {
+			return fmt.Errorf("values must be a boolean, got %q", values)
+		}
+
+		lk.WithValues = withValues
+	}
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (lk *ListKeysRequest) Verify() error {
+	if lk == nil {
+		return errors.New("lk is nil")
+	}
+
+	if lk.Namespace != "" {
+		if err := VerifyNamespaceName(lk.Namespace); err != nil {
+			return err
+		}
+	}
+
+	if lk.Limit < 1 || lk.Limit > MaxScanLimit {
+		return fmt.Errorf("lk.Limit must be between 1 and %d", MaxScanLimit)
+	}
+
+	if _, err := ParseCursor(lk.Cursor); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// FormatCursor creates an opaque cursor that continues a key listing after key
+func FormatCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// ParseCursor returns the key a cursor continues after
+func ParseCursor(cursor string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("cursor %q is invalid", cursor)
+	}
+
+	return string(key), nil
+}
+
+// FormatETag formats a key's version as an ETag header value
+func FormatETag(version string) string {
+	return fmt.Sprintf("%q", version)
+}
+
+// ParseETag parses a key's version from an ETag or If-Match header value
+func ParseETag(etag string) string {
+	etag = strings.TrimSpace(etag)
+	etag = strings.TrimPrefix(etag, "W/")
+
+	return strings.Trim(etag, "\"")
+}
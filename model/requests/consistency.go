@@ -0,0 +1,75 @@
+package requests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MinBlockRequestKey and others are query params used by reads that wait for writes to be applied
+const (
+	MinBlockRequestKey    = "minBlock"
+	ConsistencyRequestKey = "consistency"
+	TimeoutRequestKey     = "timeout"
+)
+
+// ConsistencyLocal and others are the read consistency modes
+// a local read answers from whatever the worker has applied, a strong read first catches up to the last block committed on the master, or by a quorum of verifiers while there is no master
+const (
+	ConsistencyLocal  = "local"
+	ConsistencyStrong = "strong"
+)
+
+// DefaultReadTimeout and others bound how long a read waits for the blocks it needs
+const (
+	DefaultReadTimeout = 5 * time.Second
+	MaxReadTimeout     = 30 * time.Second
+)
+
+// ReadConsistency describes what a read must wait for before it is answered
+// MinBlock is the blockId of a write's response that the read must see, and Timeout is how long to wait in total
+type ReadConsistency struct {
+	MinBlock string
+	Strong   bool
+	Timeout  time.Duration
+}
+
+// ReadConsistencyFromRequest loads a read's consistency from an http request's query params, the timeout is given in milliseconds
+func ReadConsistencyFromRequest(r *http.Request) (*ReadConsistency, error) {
+	query := r.URL.Query()
+
+	rc := &ReadConsistency{
+		MinBlock: query.Get(MinBlockRequestKey),
+		Timeout:  DefaultReadTimeout,
+	}
+
+	switch consistency := query.Get(ConsistencyRequestKey); consistency {
+	case "", ConsistencyLocal:
+	case ConsistencyStrong:
+		rc.Strong = true
+	default:
+		return nil, fmt.Errorf("%s must be %q or %q, got %q", ConsistencyRequestKey, ConsistencyLocal, ConsistencyStrong, consistency)
+	}
+
+	if timeoutMS := query.Get(TimeoutRequestKey); timeoutMS != "" {
+		parsed, err := strconv.Atoi(timeoutMS)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a positive integer, got %q", TimeoutRequestKey, timeoutMS)
+		}
+
+		rc.Timeout = time.Duration(parsed) * time.Millisecond
+	}
+
+	if rc.Timeout > MaxReadTimeout {
+		rc.Timeout = MaxReadTimeout
+	}
+
+	return rc, nil
+}
+
+// LastBlockResponse describes the last block committed to a node's chain
+type LastBlockResponse struct {
+	BlockID string `json:"blockId"`
+	Height  int    `json:"height"`
+}
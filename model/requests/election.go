@@ -0,0 +1,81 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	acrypto "github.com/astromechio/astrocache/crypto"
+)
+
+// VoteRequest asks a verifier to vote for a candidate to become master in Term
+// LastBlockID and Height describe the candidate's chain, which must be at least as long as the voter's
+// Signature is made by the candidate's keyPair over SigningBody, so that only a verifier can stand
+type VoteRequest struct {
+	Term         int                `json:"term"`
+	CandidateNID string             `json:"candidateNid"`
+	LastBlockID  string             `json:"lastBlockId"`
+	Height       int                `json:"height"`
+	Signature    *acrypto.Signature `json:"signature"`
+}
+
+// Path returns the path for a vote request
+func (vr *VoteRequest) Path() string {
+	return "v1/verifier/vote"
+}
+
+// FromRequest loads a vote request from an http request
+func (vr *VoteRequest) FromRequest(r *http.Request) error {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	return json.Unmarshal(reqBody, vr)
+}
+
+// Verify verifies that the request is valid
+func (vr *VoteRequest) Verify() error {
+	if vr == nil {
+		return errors.New("vr is nil")
+	}
+
+	if vr.Term < 1 {
+		return fmt.Errorf("vr.Term must be at least 1, got %d", vr.Term)
+	}
+
+	if vr.CandidateNID == "" {
+		return errors.New("vr.CandidateNID is empty")
+	}
+
+	if vr.Signature == nil {
+		return errors.New("vr.Signature is nil")
+	}
+
+	return nil
+}
+
+// SigningBody returns the bytes the candidate signs
+func (vr *VoteRequest) SigningBody() []byte {
+	return []byte(fmt.Sprintf("%d.%s.%s.%d", vr.Term, vr.CandidateNID, vr.LastBlockID, vr.Height))
+}
+
+// VoteResponse is a verifier's answer to a vote request, along with its current term so a candidate behind it can step down
+type VoteResponse struct {
+	Term    int  `json:"term"`
+	Granted bool `json:"granted"`
+}
+
+// ElectionStatusResponse is a verifier's view of the current election, used by the original master to find out whether it has been replaced
+// MasterNID is empty if the verifier doesn't know of a master, and Height is how many blocks it has committed
+type ElectionStatusResponse struct {
+	NID         string `json:"nid"`
+	Term        int    `json:"term"`
+	VotedFor    string `json:"votedFor,omitempty"`
+	MasterNID   string `json:"masterNid,omitempty"`
+	LastBlockID string `json:"lastBlockId"`
+	Height      int    `json:"height"`
+}
@@ -0,0 +1,210 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// NamespaceRequestKey and others are used for namespace requests
+const (
+	NamespaceRequestKey = "ns"
+
+	AuthorizationHeader = "Authorization"
+
+	maxNamespaceLength = 64
+)
+
+var namespaceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// CreateNamespaceRequest contains information for creating a namespace
+// MaxEntries and MaxBytes are the namespace's quota on each worker, 0 means unlimited
+// Token is optional, and if set must be presented as a bearer token to read or write the namespace
+// AdminToken is the bearer token the request was made with (from the Authorization header)
+type CreateNamespaceRequest struct {
+	Name       string `json:"name"`
+	MaxEntries int    `json:"maxEntries,omitempty"`
+	MaxBytes   int64  `json:"maxBytes,omitempty"`
+	Token      string `json:"token,omitempty"`
+	AdminToken string `json:"-"`
+}
+
+// Path returns the path for a create namespace request
+func (cn *CreateNamespaceRequest) Path() string {
+	return fmt.Sprintf("v1/ns/%s", cn.Name)
+}
+
+// FromRequest loads a create namespace request from an http request, the body is optional
+func (cn *CreateNamespaceRequest) FromRequest(r *http.Request) error {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if len(reqBody) > 0 {
+		if err := json.Unmarshal(reqBody, cn); err != nil {
+			return err
+		}
+	}
+
+	cn.Name = mux.Vars(r)[NamespaceRequestKey]
+	cn.AdminToken = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (cn *CreateNamespaceRequest) Verify() error {
+	if cn == nil {
+		return errors.New("cn is nil")
+	}
+
+	if err := VerifyNamespaceName(cn.Name); err != nil {
+		return err
+	}
+
+	if cn.MaxEntries < 0 {
+		return errors.New("cn.MaxEntries is negative")
+	}
+
+	if cn.MaxBytes < 0 {
+		return errors.New("cn.MaxBytes is negative")
+	}
+
+	return nil
+}
+
+// DeleteNamespaceRequest contains information for deleting a namespace and everything in it
+type DeleteNamespaceRequest struct {
+	Name       string `json:"name"`
+	AdminToken string `json:"-"`
+}
+
+// Path returns the path for a delete namespace request
+func (dn *DeleteNamespaceRequest) Path() string {
+	return fmt.Sprintf("v1/ns/%s", dn.Name)
+}
+
+// FromRequest loads a delete namespace request from an http request
+func (dn *DeleteNamespaceRequest) FromRequest(r *http.Request) error {
+	dn.Name = mux.Vars(r)[NamespaceRequestKey]
+	dn.AdminToken = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (dn *DeleteNamespaceRequest) Verify() error {
+	if dn == nil {
+		return errors.New("dn is nil")
+	}
+
+	return VerifyNamespaceName(dn.Name)
+}
+
+// FlushRequest contains information for flushing a namespace, or every namespace if All is set
+// an empty Namespace without All is the default namespace
+type FlushRequest struct {
+	Namespace  string `json:"namespace,omitempty"`
+	All        bool   `json:"all,omitempty"`
+	AdminToken string `json:"-"`
+}
+
+// Path returns the path for a flush request
+func (fr *FlushRequest) Path() string {
+	if fr.All {
+		return "v1/admin/flush"
+	}
+
+	return namespacePath(fr.Namespace, "flush")
+}
+
+// FromRequest loads a flush request from an http request, the namespace comes from the URL
+func (fr *FlushRequest) FromRequest(r *http.Request) error {
+	fr.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	fr.AdminToken = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (fr *FlushRequest) Verify() error {
+	if fr == nil {
+		return errors.New("fr is nil")
+	}
+
+	if fr.All && fr.Namespace != "" {
+		return errors.New("fr can't flush all namespaces and a single namespace")
+	}
+
+	if fr.Namespace != "" {
+		return VerifyNamespaceName(fr.Namespace)
+	}
+
+	return nil
+}
+
+// NamespaceResponse describes a namespace, without its token
+// UsedEntries and UsedBytes are what is counted against its quota, the length of each key and its value
+type NamespaceResponse struct {
+	Name        string      `json:"name"`
+	MaxEntries  int         `json:"maxEntries,omitempty"`
+	MaxBytes    int64       `json:"maxBytes,omitempty"`
+	UsedEntries int         `json:"usedEntries"`
+	UsedBytes   int64       `json:"usedBytes"`
+	Protected   bool        `json:"protected"`
+	Stats       interface{} `json:"stats,omitempty"`
+}
+
+// VerifyNamespaceName checks that a namespace name is usable in a URL
+func VerifyNamespaceName(name string) error {
+	if name == "" {
+		return errors.New("namespace name is empty")
+	}
+
+	if len(name) > maxNamespaceLength {
+		return fmt.Errorf("namespace name is longer than %d characters", maxNamespaceLength)
+	}
+
+	if !namespaceNameRegexp.MatchString(name) {
+		return fmt.Errorf("namespace name %q may only contain letters, numbers, '.', '_' and '-'", name)
+	}
+
+	return nil
+}
+
+// BearerToken returns the bearer token from an http request's Authorization header, or "" if there isn't one
+func BearerToken(r *http.Request) string {
+	auth := r.Header.Get(AuthorizationHeader)
+
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// SetBearerToken sets the Authorization header of an http request if token is not empty
+func SetBearerToken(r *http.Request, token string) {
+	if token == "" {
+		return
+	}
+
+	r.Header.Set(AuthorizationHeader, "Bearer "+token)
+}
+
+// namespacePath prefixes path with the namespace's route, or the default route if ns is empty
+func namespacePath(ns, path string) string {
+	if ns == "" {
+		return fmt.Sprintf("v1/%s", path)
+	}
+
+	return fmt.Sprintf("v1/ns/%s/%s", ns, path)
+}
@@ -9,8 +9,12 @@ import (
 
 	acrypto "github.com/astromechio/astrocache/crypto"
 	"github.com/astromechio/astrocache/model"
+	"github.com/gorilla/mux"
 )
 
+// NodeRequestKey is used for requests about a single node
+const NodeRequestKey = "nid"
+
 // NewNodeRequest contains information for adding a new node
 type NewNodeRequest struct {
 	Node     *model.Node `json:"node"`
@@ -68,9 +72,101 @@ func (nr *NewNodeRequest) Verify() error {
 }
 
 // NewNodeResponse contains everything a node needs to bootstrap istelf
+// GenesisKey is the pubKey of the network's first master, which signed the genesis block, in case the master is now an elected verifier
 type NewNodeResponse struct {
 	EncGlobalKey *acrypto.Message `json:"encGlobalKey"`
 	Master       *model.Node      `json:"master"`
+	GenesisKey   []byte           `json:"genesisKey,omitempty"`
 	Verifier     *model.Node      `json:"verifier,omitempty"`
 	IsPrimary    bool             `json:"isPrimary,omitempty"`
 }
+
+// RemoveNodeRequest contains information for evicting a node from the network
+type RemoveNodeRequest struct {
+	NID        string `json:"nid"`
+	AdminToken string `json:"-"`
+}
+
+// Path returns the path for a remove node request
+func (rn *RemoveNodeRequest) Path() string {
+	return fmt.Sprintf("v1/master/nodes/%s", rn.NID)
+}
+
+// FromRequest loads a remove node request from an http request
+func (rn *RemoveNodeRequest) FromRequest(r *http.Request) error {
+	rn.NID = mux.Vars(r)[NodeRequestKey]
+	rn.AdminToken = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (rn *RemoveNodeRequest) Verify() error {
+	if rn == nil {
+		return errors.New("rn is nil")
+	}
+
+	if rn.NID == "" {
+		return errors.New("rn.NID is empty")
+	}
+
+	return nil
+}
+
+// HeartbeatRequest is sent by the master to check that the node with NID is still running
+// Signature is made by the master's keyPair over SigningBody, and Sent only ever increases so that a recorded heartbeat can't be replayed
+type HeartbeatRequest struct {
+	MasterNID string             `json:"masterNid"`
+	NID       string             `json:"nid"`
+	Sent      int64              `json:"sent"`
+	Signature *acrypto.Signature `json:"signature"`
+}
+
+// Path returns the path for a heartbeat request
+func (hr *HeartbeatRequest) Path() string {
+	return "v1/node/heartbeat"
+}
+
+// FromRequest loads a heartbeat request from an http request
+func (hr *HeartbeatRequest) FromRequest(r *http.Request) error {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	return json.Unmarshal(reqBody, hr)
+}
+
+// Verify verifies that the request is valid
+func (hr *HeartbeatRequest) Verify() error {
+	if hr == nil {
+		return errors.New("hr is nil")
+	}
+
+	if hr.MasterNID == "" {
+		return errors.New("hr.MasterNID is empty")
+	}
+
+	if hr.NID == "" {
+		return errors.New("hr.NID is empty")
+	}
+
+	if hr.Signature == nil {
+		return errors.New("hr.Signature is nil")
+	}
+
+	return nil
+}
+
+// SigningBody returns the bytes the master signs
+func (hr *HeartbeatRequest) SigningBody() []byte {
+	return []byte(fmt.Sprintf("%s.%s.%d", hr.MasterNID, hr.NID, hr.Sent))
+}
+
+// HeartbeatResponse is a node's answer to a heartbeat from the master
+// NID lets the master tell that the node it expected is the one listening at the address
+type HeartbeatResponse struct {
+	NID         string `json:"nid"`
+	LastBlockID string `json:"lastBlockId"`
+}
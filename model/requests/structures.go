@@ -0,0 +1,335 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FieldRequestKey and others are keys used for structure requests
+const (
+	FieldRequestKey  = "field"
+	MemberRequestKey = "member"
+)
+
+// HashSetRequest contains fields to set in a hash
+// TTL is optional, and is only used if the hash does not exist yet
+type HashSetRequest struct {
+	Key       string            `json:"key"`
+	Fields    map[string]string `json:"fields"`
+	TTL       int64             `json:"ttl,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Token     string            `json:"-"`
+}
+
+// Path returns the path for a hash set request
+func (hs *HashSetRequest) Path() string {
+	return namespacePath(hs.Namespace, fmt.Sprintf("hash/%s", hs.Key))
+}
+
+// FromRequest loads a hash set request from an http request
+func (hs *HashSetRequest) FromRequest(r *http.Request) error {
+	key, err := structureFromRequest(r, hs)
+	if err != nil {
+		return err
+	}
+
+	hs.Key = key
+	hs.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	hs.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (hs *HashSetRequest) Verify() error {
+	if hs == nil {
+		return errors.New("hs is nil")
+	}
+
+	if len(hs.Fields) == 0 {
+		return errors.New("hs.Fields is empty")
+	}
+
+	if err := verifyTTL("hs.TTL", hs.TTL); err != nil {
+		return err
+	}
+
+	for field := range hs.Fields {
+		if field == "" {
+			return errors.New("hs has an empty field")
+		}
+	}
+
+	return verifyStructure(hs.Key, hs.Namespace, len(hs.Fields))
+}
+
+// HashDeleteRequest contains fields to remove from a hash
+type HashDeleteRequest struct {
+	Key       string   `json:"key"`
+	Fields    []string `json:"fields"`
+	Namespace string   `json:"namespace,omitempty"`
+	Token     string   `json:"-"`
+}
+
+// Path returns the path for a hash delete request
+func (hd *HashDeleteRequest) Path() string {
+	return namespacePath(hd.Namespace, fmt.Sprintf("hash/%s/delete", hd.Key))
+}
+
+// FromRequest loads a hash delete request from an http request
+func (hd *HashDeleteRequest) FromRequest(r *http.Request) error {
+	key, err := structureFromRequest(r, hd)
+	if err != nil {
+		return err
+	}
+
+	hd.Key = key
+	hd.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	hd.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (hd *HashDeleteRequest) Verify() error {
+	if hd == nil {
+		return errors.New("hd is nil")
+	}
+
+	if len(hd.Fields) == 0 {
+		return errors.New("hd.Fields is empty")
+	}
+
+	return verifyStructure(hd.Key, hd.Namespace, len(hd.Fields))
+}
+
+// ListPushRequest contains values to push onto the right (end) of a list, or the left (start) if Left is set
+// TTL is optional, and is only used if the list does not exist yet
+type ListPushRequest struct {
+	Key       string   `json:"key"`
+	Values    []string `json:"values"`
+	Left      bool     `json:"left,omitempty"`
+	TTL       int64    `json:"ttl,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Token     string   `json:"-"`
+}
+
+// Path returns the path for a list push request
+func (lp *ListPushRequest) Path() string {
+	return namespacePath(lp.Namespace, fmt.Sprintf("list/%s/push", lp.Key))
+}
+
+// FromRequest loads a list push request from an http request
+func (lp *ListPushRequest) FromRequest(r *http.Request) error {
+	key, err := structureFromRequest(r, lp)
+	if err != nil {
+		return err
+	}
+
+	lp.Key = key
+	lp.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	lp.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (lp *ListPushRequest) Verify() error {
+	if lp == nil {
+		return errors.New("lp is nil")
+	}
+
+	if len(lp.Values) == 0 {
+		return errors.New("lp.Values is empty")
+	}
+
+	if err := verifyTTL("lp.TTL", lp.TTL); err != nil {
+		return err
+	}
+
+	return verifyStructure(lp.Key, lp.Namespace, len(lp.Values))
+}
+
+// ListPopRequest contains the number of values to pop from the right (end) of a list, or the left (start) if Left is set
+// Count defaults to 1, and the body is optional
+type ListPopRequest struct {
+	Key       string `json:"key"`
+	Count     int    `json:"count"`
+	Left      bool   `json:"left,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Token     string `json:"-"`
+}
+
+// ListPopResponse contains the values popped from a list, in the order they were popped
+// Values is empty if the list was empty or didn't exist
+type ListPopResponse struct {
+	Key     string   `json:"key"`
+	Values  []string `json:"values"`
+	BlockID string   `json:"blockId"`
+}
+
+// Path returns the path for a list pop request
+func (lp *ListPopRequest) Path() string {
+	return namespacePath(lp.Namespace, fmt.Sprintf("list/%s/pop", lp.Key))
+}
+
+// FromRequest loads a list pop request from an http request
+func (lp *ListPopRequest) FromRequest(r *http.Request) error {
+	lp.Count = 1
+
+	key, err := structureFromRequest(r, lp)
+	if err != nil {
+		return err
+	}
+
+	lp.Key = key
+	lp.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	lp.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (lp *ListPopRequest) Verify() error {
+	if lp == nil {
+		return errors.New("lp is nil")
+	}
+
+	if lp.Count < 1 {
+		return errors.New("lp.Count must be at least 1")
+	}
+
+	return verifyStructure(lp.Key, lp.Namespace, lp.Count)
+}
+
+// SetAddRequest contains members to add to a set
+// TTL is optional, and is only used if the set does not exist yet
+type SetAddRequest struct {
+	Key       string   `json:"key"`
+	Members   []string `json:"members"`
+	TTL       int64    `json:"ttl,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Token     string   `json:"-"`
+}
+
+// Path returns the path for a set add request
+func (sa *SetAddRequest) Path() string {
+	return namespacePath(sa.Namespace, fmt.Sprintf("set/%s/add", sa.Key))
+}
+
+// FromRequest loads a set add request from an http request
+func (sa *SetAddRequest) FromRequest(r *http.Request) error {
+	key, err := structureFromRequest(r, sa)
+	if err != nil {
+		return err
+	}
+
+	sa.Key = key
+	sa.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	sa.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (sa *SetAddRequest) Verify() error {
+	if sa == nil {
+		return errors.New("sa is nil")
+	}
+
+	if len(sa.Members) == 0 {
+		return errors.New("sa.Members is empty")
+	}
+
+	if err := verifyTTL("sa.TTL", sa.TTL); err != nil {
+		return err
+	}
+
+	return verifyStructure(sa.Key, sa.Namespace, len(sa.Members))
+}
+
+// SetRemoveRequest contains members to remove from a set
+type SetRemoveRequest struct {
+	Key       string   `json:"key"`
+	Members   []string `json:"members"`
+	Namespace string   `json:"namespace,omitempty"`
+	Token     string   `json:"-"`
+}
+
+// Path returns the path for a set remove request
+func (sr *SetRemoveRequest) Path() string {
+	return namespacePath(sr.Namespace, fmt.Sprintf("set/%s/remove", sr.Key))
+}
+
+// FromRequest loads a set remove request from an http request
+func (sr *SetRemoveRequest) FromRequest(r *http.Request) error {
+	key, err := structureFromRequest(r, sr)
+	if err != nil {
+		return err
+	}
+
+	sr.Key = key
+	sr.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	sr.Token = BearerToken(r)
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (sr *SetRemoveRequest) Verify() error {
+	if sr == nil {
+		return errors.New("sr is nil")
+	}
+
+	if len(sr.Members) == 0 {
+		return errors.New("sr.Members is empty")
+	}
+
+	return verifyStructure(sr.Key, sr.Namespace, len(sr.Members))
+}
+
+// structureFromRequest loads the JSON body of a structure request into req, if there is one, and returns the key from the URL
+func structureFromRequest(r *http.Request, req interface{}) (string, error) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	defer r.Body.Close()
+
+	if len(reqBody) > 0 {
+		if err := json.Unmarshal(reqBody, req); err != nil {
+			return "", err
+		}
+	}
+
+	key := mux.Vars(r)[KeyRequestKey]
+	if key == "" {
+		return "", errors.New("No key found in request URL")
+	}
+
+	return key, nil
+}
+
+// verifyStructure checks the parts common to every structure request, size is the number of fields, values or members it changes
+func verifyStructure(key, ns string, size int) error {
+	if key == "" {
+		return errors.New("key is empty")
+	}
+
+	if size > MaxBatchSize {
+		return fmt.Errorf("request changes %d items, the max is %d", size, MaxBatchSize)
+	}
+
+	if ns != "" {
+		if err := VerifyNamespaceName(ns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package requests
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// LastEventIDHeader is sent by server-sent event clients when they reconnect
+const LastEventIDHeader = "Last-Event-ID"
+
+// WatchRequest contains the query for watching key changes
+// FromBlock is optional, and resumes the watch after a block ID that was already received
+type WatchRequest struct {
+	Prefix    string
+	FromBlock string
+	Namespace string
+	Token     string
+}
+
+// Path returns the path for a watch request, without its query params
+func (wr *WatchRequest) Path() string {
+	return namespacePath(wr.Namespace, "watch")
+}
+
+// FromRequest loads a watch request from an http request's query params
+// a reconnecting client's Last-Event-ID header is used if the fromBlock query param isn't set
+func (wr *WatchRequest) FromRequest(r *http.Request) error {
+	query := r.URL.Query()
+
+	wr.Namespace = mux.Vars(r)[NamespaceRequestKey]
+	wr.Token = BearerToken(r)
+	wr.Prefix = query.Get("prefix")
+	wr.FromBlock = query.Get("fromBlock")
+
+	if wr.FromBlock == "" {
+		wr.FromBlock = r.Header.Get(LastEventIDHeader)
+	}
+
+	return nil
+}
+
+// Verify verifies that the request is valid
+func (wr *WatchRequest) Verify() error {
+	if wr == nil {
+		return errors.New("wr is nil")
+	}
+
+	if wr.Namespace != "" {
+		if err := VerifyNamespaceName(wr.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package send
 import (
 	"fmt"
 
+	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/model/actions"
 
 	"github.com/astromechio/astrocache/logger"
@@ -14,7 +15,9 @@ import (
 )
 
 // ProposeBlockToVerifiers proposes a block and decides if the verifiers will accept it
-func ProposeBlockToVerifiers(block *blockchain.Block, verifiers []*model.Node, thisNode *model.Node) error {
+// the block is accepted as soon as enough verifiers to meet quorum have, counting this node if it is a verifier itself
+// verifiers that reject or miss a block that is accepted anyway repair themselves from the master afterwards
+func ProposeBlockToVerifiers(block *blockchain.Block, verifiers []*model.Node, thisNode *model.Node, quorum *config.Quorum) error {
 	req := &requests.ProposeBlockRequest{
 		Block:        block,
 		ProposingNID: thisNode.NID,
@@ -25,7 +28,17 @@ func ProposeBlockToVerifiers(block *blockchain.Block, verifiers []*model.Node, t
 		return nil
 	}
 
-	logger.LogInfo(fmt.Sprintf("ProposeBlockToVerifiers verifying block with %d verifiers", len(verifiers)))
+	accepted := 0
+	total := len(verifiers)
+
+	if thisNode.Type == model.NodeTypeVerifier {
+		accepted++
+		total++
+	}
+
+	required := quorum.Required(total)
+
+	logger.LogInfo(fmt.Sprintf("ProposeBlockToVerifiers verifying block with %d verifiers, %d of %d needed", len(verifiers), required, total))
 
 	resultChan := make(chan bool, len(verifiers))
 
@@ -35,27 +48,12 @@ func ProposeBlockToVerifiers(block *blockchain.Block, verifiers []*model.Node, t
 		go sendBlockProposal(reqURL, req, resultChan)
 	}
 
-	numMatch := 0
-	numMismatch := 0
-
-	for true {
-		match := <-resultChan
-
-		if match {
-			numMatch++
-		} else {
-			numMismatch++
-		}
-
-		if numMatch+numMismatch == len(verifiers) {
-			break
-		}
-	}
+	accepted, rejected, ok := awaitQuorum(resultChan, len(verifiers), accepted, required)
 
-	logger.LogInfo(fmt.Sprintf("ProposeBlockToVerifiers got %d matches and %d mismatches", numMatch, numMismatch))
+	logger.LogInfo(fmt.Sprintf("ProposeBlockToVerifiers got %d matches and %d mismatches", accepted, rejected))
 
-	if numMismatch > 0 {
-		return fmt.Errorf("ProposeBlockToVerifiers failed to add pending block: %d verifiers reported ID mismatch", numMismatch)
+	if !ok {
+		return fmt.Errorf("ProposeBlockToVerifiers failed to add pending block: %d verifiers reported ID mismatch or were unreachable, %d of %d needed", rejected, required, total)
 	}
 
 	return nil
@@ -65,13 +63,14 @@ func sendBlockProposal(url string, req *requests.ProposeBlockRequest, resultChan
 	if err := transport.Post(url, req, nil); err != nil {
 		logger.LogError(errors.Wrap(err, "sendBlockProposal failed to Post"))
 		resultChan <- false
+		return
 	}
 
 	resultChan <- true
 }
 
-// CheckBlockWithVerifiers proposes a block and decides if the verifiers will accept it
-func CheckBlockWithVerifiers(block *blockchain.Block, verifiers []*model.Node, propNID string) error {
+// CheckBlockWithVerifiers checks that enough of the other verifiers to meet quorum have a block proposed or committed, counting this node
+func CheckBlockWithVerifiers(block *blockchain.Block, verifiers []*model.Node, propNID string, quorum *config.Quorum) error {
 	req := &requests.CheckBlockRequest{
 		Block: block,
 	}
@@ -88,9 +87,12 @@ func CheckBlockWithVerifiers(block *blockchain.Block, verifiers []*model.Node, p
 		return nil
 	}
 
-	logger.LogInfo(fmt.Sprintf("CheckBlockWithVerifiers checking block with %d verifiers and propNID %q", len(actualVerifiers), propNID))
+	total := len(actualVerifiers) + 1
+	required := quorum.Required(total)
 
-	resultChan := make(chan bool)
+	logger.LogInfo(fmt.Sprintf("CheckBlockWithVerifiers checking block with %d verifiers and propNID %q, %d of %d needed", len(actualVerifiers), propNID, required, total))
+
+	resultChan := make(chan bool, len(actualVerifiers))
 
 	for _, v := range actualVerifiers {
 		reqURL := transport.URLFromAddressAndPath(v.Address, req.Path())
@@ -98,27 +100,12 @@ func CheckBlockWithVerifiers(block *blockchain.Block, verifiers []*model.Node, p
 		go sendBlockCheck(reqURL, req, resultChan)
 	}
 
-	numMatch := 0
-	numMismatch := 0
+	accepted, rejected, ok := awaitQuorum(resultChan, len(actualVerifiers), 1, required)
 
-	for true {
-		match := <-resultChan
+	logger.LogInfo(fmt.Sprintf("CheckBlockWithVerifiers got %d matches and %d mismatches", accepted, rejected))
 
-		if match {
-			numMatch++
-		} else {
-			numMismatch++
-		}
-
-		if numMatch+numMismatch == len(actualVerifiers) {
-			break
-		}
-	}
-
-	logger.LogInfo(fmt.Sprintf("CheckBlockWithVerifiers got %d matches and %d mismatches", numMatch, numMismatch))
-
-	if numMismatch > 0 {
-		return fmt.Errorf("CheckBlockWithVerifiers failed to check pending block: %d verifiers reported ID mismatch", numMismatch)
+	if !ok {
+		return fmt.Errorf("CheckBlockWithVerifiers failed to check pending block: %d verifiers reported ID mismatch or were unreachable, %d of %d needed", rejected, required, total)
 	}
 
 	return nil
@@ -128,11 +115,29 @@ func sendBlockCheck(url string, req *requests.CheckBlockRequest, resultChan chan
 	if err := transport.Post(url, req, nil); err != nil {
 		logger.LogError(errors.Wrap(err, "sendBlockCheck failed to Post"))
 		resultChan <- false
+		return
 	}
 
 	resultChan <- true
 }
 
+// awaitQuorum counts the results of pending requests until required have accepted, or so many have rejected that it can't happen
+// resultChan must be buffered for every pending request, since the ones still outstanding when it returns are never read
+func awaitQuorum(resultChan chan bool, pending, accepted, required int) (int, int, bool) {
+	rejected := 0
+	total := pending + accepted
+
+	for accepted < required && total-rejected >= required {
+		if <-resultChan {
+			accepted++
+		} else {
+			rejected++
+		}
+	}
+
+	return accepted, rejected, accepted >= required
+}
+
 // DistributeBlockToWorkers sends a block to all workers
 func DistributeBlockToWorkers(block *blockchain.Block, workers []*model.Node, thisNode *model.Node) error {
 	req := &requests.ProposeBlockRequest{
@@ -196,6 +201,7 @@ func distributeBlock(url string, req *requests.ProposeBlockRequest, resultChan c
 	if err := transport.Post(url, req, nil); err != nil {
 		logger.LogError(errors.Wrap(err, "distributeBlock failed to Post"))
 		resultChan <- false
+		return
 	}
 
 	resultChan <- true
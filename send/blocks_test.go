@@ -0,0 +1,145 @@
+package send
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/transport"
+)
+
+func TestAwaitQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []bool
+		accepted int
+		required int
+		ok       bool
+		read     int
+	}{
+		{"all accept", []bool{true, true}, 1, 3, true, 2},
+		{"stops once met", []bool{true, false, false}, 1, 2, true, 1},
+		{"stops once impossible", []bool{false, false, true}, 1, 3, false, 2},
+		{"majority with a rejection", []bool{false, true, true, false}, 1, 3, true, 3},
+		{"majority not met", []bool{false, true, false, false}, 1, 3, false, 4},
+		{"already met", []bool{false}, 1, 1, true, 0},
+	}
+
+	for _, test := range tests {
+		resultChan := make(chan bool, len(test.results))
+		for _, result := range test.results {
+			resultChan <- result
+		}
+
+		accepted, rejected, ok := awaitQuorum(resultChan, len(test.results), test.accepted, test.required)
+
+		if ok != test.ok {
+			t.Errorf("%s: expected ok %t, got %t", test.name, test.ok, ok)
+		}
+
+		if read := len(test.results) - len(resultChan); read != test.read {
+			t.Errorf("%s: expected %d results to be read, got %d", test.name, test.read, read)
+		}
+
+		if accepted+rejected != test.read+test.accepted {
+			t.Errorf("%s: expected %d accepted and rejected, got %d and %d", test.name, test.read+test.accepted, accepted, rejected)
+		}
+	}
+}
+
+// newTestVerifiers starts a verifier for each status that answers every request with it, or never answers if it is 0
+func newTestVerifiers(t *testing.T, statuses ...int) []*model.Node {
+	done := make(chan bool)
+	verifiers := []*model.Node{}
+
+	for i, status := range statuses {
+		status := status
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status == 0 {
+				<-done
+				transport.ServiceUnavailable(w)
+				return
+			}
+
+			w.WriteHeader(status)
+		}))
+
+		t.Cleanup(server.Close)
+
+		verifiers = append(verifiers, &model.Node{NID: fmt.Sprintf("verifier-%d", i), Type: model.NodeTypeVerifier, Address: server.URL})
+	}
+
+	// cleanups run last added first, so the servers that never answer are released before they are closed
+	t.Cleanup(func() { close(done) })
+
+	return verifiers
+}
+
+func TestProposeBlockToVerifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		selfType string
+		quorum   *config.Quorum
+		ok       bool
+	}{
+		{"master needs all", []int{http.StatusOK, http.StatusConflict}, model.NodeTypeMaster, &config.Quorum{Policy: config.QuorumAll}, false},
+		{"master majority", []int{http.StatusOK, http.StatusOK, http.StatusConflict}, model.NodeTypeMaster, &config.Quorum{Policy: config.QuorumMajority}, true},
+		{"master majority missed", []int{http.StatusOK, http.StatusConflict, http.StatusConflict}, model.NodeTypeMaster, &config.Quorum{Policy: config.QuorumMajority}, false},
+		{"verifier counts itself", []int{http.StatusOK, http.StatusConflict}, model.NodeTypeVerifier, &config.Quorum{Policy: config.QuorumMajority}, true},
+		{"verifier alone", []int{http.StatusConflict, http.StatusConflict}, model.NodeTypeVerifier, &config.Quorum{Policy: config.QuorumMajority}, false},
+		{"count raised to majority", []int{http.StatusOK, http.StatusConflict, http.StatusConflict}, model.NodeTypeMaster, &config.Quorum{Count: 1}, false},
+	}
+
+	for _, test := range tests {
+		verifiers := newTestVerifiers(t, test.statuses...)
+		self := &model.Node{NID: "self", Type: test.selfType}
+
+		err := ProposeBlockToVerifiers(&blockchain.Block{ID: "block"}, verifiers, self, test.quorum)
+
+		if test.ok && err != nil {
+			t.Errorf("%s: expected the block to be accepted, got %v", test.name, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("%s: expected the block to be rejected", test.name)
+		}
+	}
+}
+
+func TestProposeBlockToVerifiersDoesNotWaitForStragglers(t *testing.T) {
+	verifiers := newTestVerifiers(t, http.StatusOK, http.StatusOK, 0)
+	self := &model.Node{NID: "self", Type: model.NodeTypeMaster}
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- ProposeBlockToVerifiers(&blockchain.Block{ID: "block"}, verifiers, self, &config.Quorum{Policy: config.QuorumMajority})
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Errorf("expected the block to be accepted by a majority, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected the block to be accepted without waiting for the verifier that doesn't answer")
+	}
+}
+
+func TestCheckBlockWithVerifiersSkipsProposer(t *testing.T) {
+	verifiers := newTestVerifiers(t, http.StatusConflict, http.StatusOK)
+
+	// the proposer rejecting the check doesn't count, since it isn't asked
+	if err := CheckBlockWithVerifiers(&blockchain.Block{ID: "block"}, verifiers, verifiers[0].NID, &config.Quorum{Policy: config.QuorumAll}); err != nil {
+		t.Errorf("expected the block to be checked with the verifiers other than the proposer, got %v", err)
+	}
+
+	if err := CheckBlockWithVerifiers(&blockchain.Block{ID: "block"}, verifiers, "other", &config.Quorum{Policy: config.QuorumAll}); err == nil {
+		t.Error("expected the check to fail when a verifier rejects it and all must accept")
+	}
+}
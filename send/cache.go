@@ -6,9 +6,51 @@ import (
 	"github.com/astromechio/astrocache/transport"
 )
 
-// SetValue sends a value change request to a node
-func SetValue(req *requests.SetValueRequest, node *model.Node) error {
+// SetValue sends a value change request to a node, returning the ID of the block it was committed in
+// the namespace token of each request is passed along, so the node receiving it can check it too
+func SetValue(req *requests.SetValueRequest, node *model.Node) (*requests.WriteResponse, error) {
 	url := transport.URLFromAddressAndPath(node.Address, req.Path())
 
-	return transport.Post(url, req, nil)
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// IncrementValue sends an increment request to a node and returns the resulting value
+func IncrementValue(req *requests.IncrementValueRequest, node *model.Node) (*requests.IncrementValueResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.IncrementValueResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// BatchSet sends a batch set request to a node, returning the ID of the block it was committed in
+func BatchSet(req *requests.BatchSetRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteValue sends a key deletion request to a node, returning the ID of the block it was committed in
+func DeleteValue(req *requests.DeleteValueRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.DeleteIfMatch(url, req.Token, req.IfMatch, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
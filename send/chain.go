@@ -1,6 +1,9 @@
 package send
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/astromechio/astrocache/logger"
 	"github.com/astromechio/astrocache/model"
 	"github.com/astromechio/astrocache/model/blockchain"
@@ -33,6 +36,77 @@ func GetBlocksAfter(masterNode *model.Node, afterID string) ([]*blockchain.Block
 	return blocks, nil
 }
 
+// GetLastBlock requests the ID and height of the last block committed on the master node, giving up after timeout
+func GetLastBlock(masterNode *model.Node, timeout time.Duration) (*requests.LastBlockResponse, error) {
+	url := transport.URLFromAddressAndPath(masterNode.Address, "v1/master/chain/last")
+
+	resp := &requests.LastBlockResponse{}
+	if err := transport.GetWithTimeout(url, timeout, resp); err != nil {
+		return nil, errors.Wrap(err, "GetLastBlock failed to GetWithTimeout")
+	}
+
+	return resp, nil
+}
+
+// GetBlocksAfterFromAny requests the blocks after afterID from each node in turn until one answers, skipping nil nodes
+// it is used when the master may have changed, since any verifier redirects the request to whoever it thinks is master
+func GetBlocksAfterFromAny(nodes []*model.Node, afterID string) ([]*blockchain.Block, error) {
+	err := errors.New("no nodes to ask")
+
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+
+		var blocks []*blockchain.Block
+		blocks, err = GetBlocksAfter(node, afterID)
+		if err == nil {
+			return blocks, nil
+		}
+
+		logger.LogWarn(fmt.Sprintf("GetBlocksAfterFromAny failed to GetBlocksAfter from %s: %s", node.Address, err.Error()))
+	}
+
+	return nil, errors.Wrap(err, "GetBlocksAfterFromAny failed to GetBlocksAfter")
+}
+
+// GetCommittedBlocksAfter requests the blocks a verifier has itself committed after afterID, which it doesn't redirect to the master
+func GetCommittedBlocksAfter(verifier *model.Node, afterID string) ([]*blockchain.Block, error) {
+	url := transport.URLFromAddressAndPath(verifier.Address, "v1/verifier/chain/after/"+afterID)
+
+	blocks := []*blockchain.Block{}
+	if err := transport.Get(url, &blocks); err != nil {
+		return nil, errors.Wrap(err, "GetCommittedBlocksAfter failed to Get")
+	}
+
+	return blocks, nil
+}
+
+// GetCommittedLastBlock requests the ID and height of the last block a verifier has itself committed, giving up after timeout
+func GetCommittedLastBlock(verifier *model.Node, timeout time.Duration) (*requests.LastBlockResponse, error) {
+	url := transport.URLFromAddressAndPath(verifier.Address, "v1/verifier/chain/last")
+
+	resp := &requests.LastBlockResponse{}
+	if err := transport.GetWithTimeout(url, timeout, resp); err != nil {
+		return nil, errors.Wrap(err, "GetCommittedLastBlock failed to GetWithTimeout")
+	}
+
+	return resp, nil
+}
+
+// GetLatestBlock requests the ID and height of the latest committed block from a verifier, which catches up to it first
+// the verifier confirms the block with the master, or with a quorum of verifiers while there is no master
+func GetLatestBlock(verifier *model.Node) (*requests.LastBlockResponse, error) {
+	url := transport.URLFromAddressAndPath(verifier.Address, "v1/verifier/chain/latest")
+
+	resp := &requests.LastBlockResponse{}
+	if err := transport.Get(url, resp); err != nil {
+		return nil, errors.Wrap(err, "GetLatestBlock failed to Get")
+	}
+
+	return resp, nil
+}
+
 // RequestReservedID reserves a block ID with the master node
 func RequestReservedID(masterNode *model.Node, propNID string) (*requests.ReserveIDResponse, error) {
 	logger.LogInfo("RequestReservedID requesting block ID from master node")
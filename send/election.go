@@ -0,0 +1,44 @@
+package send
+
+import (
+	"time"
+
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// PingMaster checks that the master is responding by requesting its last block, giving up after timeout
+func PingMaster(masterNode *model.Node, timeout time.Duration) (*requests.LastBlockResponse, error) {
+	resp, err := GetLastBlock(masterNode, timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "PingMaster failed to GetLastBlock")
+	}
+
+	return resp, nil
+}
+
+// RequestVote asks a verifier to vote for us to become master, giving up after timeout
+func RequestVote(req *requests.VoteRequest, verifier *model.Node, timeout time.Duration) (*requests.VoteResponse, error) {
+	url := transport.URLFromAddressAndPath(verifier.Address, req.Path())
+
+	resp := &requests.VoteResponse{}
+	if err := transport.PostWithTimeout(url, timeout, req, resp); err != nil {
+		return nil, errors.Wrap(err, "RequestVote failed to PostWithTimeout")
+	}
+
+	return resp, nil
+}
+
+// GetElectionStatus requests a verifier's term and the master it follows, giving up after timeout
+func GetElectionStatus(verifier *model.Node, timeout time.Duration) (*requests.ElectionStatusResponse, error) {
+	url := transport.URLFromAddressAndPath(verifier.Address, "v1/verifier/election")
+
+	resp := &requests.ElectionStatusResponse{}
+	if err := transport.GetWithTimeout(url, timeout, resp); err != nil {
+		return nil, errors.Wrap(err, "GetElectionStatus failed to GetWithTimeout")
+	}
+
+	return resp, nil
+}
@@ -1,9 +1,13 @@
 package send
 
 import (
+	"time"
+
 	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model"
 	"github.com/astromechio/astrocache/model/requests"
 	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
 )
 
 // JoinNetwork requests that the current node be added to a network
@@ -22,3 +26,29 @@ func JoinNetwork(app *config.App, masterAddr, joinCode string) (*requests.NewNod
 
 	return resp, nil
 }
+
+// SendHeartbeat checks that a node is still running, giving up after timeout
+// the heartbeat is signed with our keyPair, which the node checks against the master it follows
+func SendHeartbeat(app *config.App, node *model.Node, timeout time.Duration) (*requests.HeartbeatResponse, error) {
+	req := &requests.HeartbeatRequest{
+		MasterNID: app.Self.NID,
+		NID:       node.NID,
+		Sent:      time.Now().UnixNano(),
+	}
+
+	sig, err := app.KeySet.KeyPair.Sign(req.SigningBody())
+	if err != nil {
+		return nil, errors.Wrap(err, "SendHeartbeat failed to Sign")
+	}
+
+	req.Signature = sig
+
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.HeartbeatResponse{}
+	if err := transport.PostWithTimeout(url, timeout, req, resp); err != nil {
+		return nil, errors.Wrap(err, "SendHeartbeat failed to PostWithTimeout")
+	}
+
+	return resp, nil
+}
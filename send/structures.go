@@ -0,0 +1,79 @@
+package send
+
+import (
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+)
+
+// HashSet sends a hash set request to a node, returning the ID of the block it was committed in
+func HashSet(req *requests.HashSetRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// HashDelete sends a hash delete request to a node, returning the ID of the block it was committed in
+func HashDelete(req *requests.HashDeleteRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListPush sends a list push request to a node, returning the ID of the block it was committed in
+func ListPush(req *requests.ListPushRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListPop sends a list pop request to a node and returns the values popped
+func ListPop(req *requests.ListPopRequest, node *model.Node) (*requests.ListPopResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.ListPopResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetAdd sends a set add request to a node, returning the ID of the block it was committed in
+func SetAdd(req *requests.SetAddRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetRemove sends a set remove request to a node, returning the ID of the block it was committed in
+func SetRemove(req *requests.SetRemoveRequest, node *model.Node) (*requests.WriteResponse, error) {
+	url := transport.URLFromAddressAndPath(node.Address, req.Path())
+
+	resp := &requests.WriteResponse{}
+	if err := transport.PostWithToken(url, req.Token, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -15,7 +15,33 @@ import (
 // GetEntireChainHandler returns the entire chain for a node to verify and store
 func GetEntireChainHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		transport.ReplyWithJSON(w, app.Chain.Blocks)
+		if !requireMaster(w, r, app) {
+			return
+		}
+
+		transport.ReplyWithJSON(w, app.Chain.Blocks())
+	}
+}
+
+// GetLastBlockHandler returns the ID and height of the last block committed on the master
+func GetLastBlockHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
+		last := app.Chain.LastBlock()
+		if last == nil {
+			transport.NotFound(w)
+			return
+		}
+
+		resp := &requests.LastBlockResponse{
+			BlockID: last.ID,
+			Height:  app.Chain.Height(),
+		}
+
+		transport.ReplyWithJSON(w, resp)
 	}
 }
 
@@ -24,6 +50,10 @@ const afterKey = "after"
 // GetBlocksAfterHandler handles blocks after ID requests
 func GetBlocksAfterHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
 		afterID := mux.Vars(r)[afterKey]
 
 		blocks := app.Chain.BlocksAfterID(afterID)
@@ -39,6 +69,10 @@ func GetBlocksAfterHandler(app *config.App) http.HandlerFunc {
 // ReserveIDHandler handles blocks after ID requests
 func ReserveIDHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
 		reserveReq := &requests.ReserveIDRequest{}
 		reserveReq.FromRequest(r)
 
@@ -48,6 +82,13 @@ func ReserveIDHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
+		// a removed verifier may still be running, so only verifiers still in the network can propose blocks
+		if reserveReq.ProposingNID != app.Self.NID && app.NodeList.VerifierWithNID(reserveReq.ProposingNID) == nil {
+			logger.LogError(errors.New("ReserveIDHandler refused to reserve a block ID for unknown verifier with NID " + reserveReq.ProposingNID))
+			transport.Forbidden(w)
+			return
+		}
+
 		errChan, reserveJob := app.Chain.ReserveBlockID(reserveReq.ProposingNID)
 		if err := <-errChan; err != nil {
 			logger.LogError(errors.New("ReserveIDHandler failed to ReserveBlockID, got empty blockID"))
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/transport"
+)
+
+// requireMaster makes sure this node is the master before it handles a master request
+// any other node redirects the request to the current master, so that nodes still talking to an old master find the new one
+// if false is returned, a response has already been written to w
+func requireMaster(w http.ResponseWriter, r *http.Request, app *config.App) bool {
+	if app.IsMaster() {
+		return true
+	}
+
+	master := app.NodeList.CurrentMaster()
+	if master == nil {
+		transport.ServiceUnavailable(w)
+		return false
+	}
+
+	url := transport.URLFromAddressAndPath(master.Address, strings.TrimPrefix(r.URL.RequestURI(), "/"))
+
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+
+	return false
+}
+
+// genesisKey returns the pubKey of the first master, which signed the genesis block
+func genesisKey(app *config.App) []byte {
+	keyPair := app.KeySet.KeyPairWithKID(acrypto.MasterKeyPairKID)
+	if keyPair == nil {
+		return nil
+	}
+
+	return keyPair.PubKeyJSON()
+}
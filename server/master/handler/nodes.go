@@ -1,10 +1,11 @@
 package handler
 
 import (
+	"bytes"
 	"net/http"
 
 	"github.com/astromechio/astrocache/config"
-	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model"
 
 	"github.com/astromechio/astrocache/logger"
 	"github.com/pkg/errors"
@@ -13,11 +14,16 @@ import (
 	"github.com/astromechio/astrocache/model/actions"
 	"github.com/astromechio/astrocache/model/requests"
 	"github.com/astromechio/astrocache/transport"
+	"github.com/astromechio/astrocache/workers"
 )
 
 // AddVerifierNodeHandler handles POST /v1/master/nodes/verifier
 func AddVerifierNodeHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
 		newNodeRequest := &requests.NewNodeRequest{}
 		if err := newNodeRequest.FromRequest(r); err != nil {
 			logger.LogError(errors.Wrap(err, "AddVerifierNodeHandler failed to FromRequest"))
@@ -45,6 +51,13 @@ func AddVerifierNodeHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
+		// checked by fingerprint, since a removed node could send the same key under a new KID
+		if app.NodeList.Forbidden(newNodeRequest.Node.NID, newNodePubKey.Fingerprint()) {
+			logger.LogError(errors.New("AddVerifierNodeHandler refused node with NID " + newNodeRequest.Node.NID + ", it or its key was removed from the network"))
+			transport.Forbidden(w)
+			return
+		}
+
 		encGlobalKey, err := newNodePubKey.Encrypt(app.KeySet.GlobalKey.JSON())
 		if err != nil {
 			logger.LogError(errors.Wrap(err, "AddVerifierNodeHandler failed to Encrypt"))
@@ -52,26 +65,29 @@ func AddVerifierNodeHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
-		nodeAddedAction := actions.NewNodeAdded(newNodeRequest.Node, encGlobalKey)
-		actionJSON := nodeAddedAction.JSON()
+		if existing := app.NodeList.VerifierWithNID(newNodeRequest.Node.NID); existing != nil {
+			if !bytes.Equal(existing.PubKey, newNodeRequest.Node.PubKey) {
+				logger.LogError(errors.New("AddVerifierNodeHandler found a different node with NID " + existing.NID))
+				transport.Conflict(w)
+				return
+			}
 
-		block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, actionJSON, nodeAddedAction.ActionType())
-		if err != nil {
-			logger.LogError(errors.Wrap(err, "AddVerifierNodeHandler failed to NewBlockWithAction"))
-			transport.InternalServerError(w)
-			return
-		}
+			// it died while joining after we added it, so it gets the same answer rather than being added twice
+			resp := requests.NewNodeResponse{
+				EncGlobalKey: encGlobalKey,
+				Master:       app.Self,
+				GenesisKey:   genesisKey(app),
+				IsPrimary:    app.Self.Type == model.NodeTypeMaster && app.NodeList.PrimaryNID() == existing.NID,
+			}
 
-		errChan, _ := app.Chain.ReserveBlockID(app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "SetValueHandler failed to ReserveBlockID"))
-			transport.InternalServerError(w)
+			transport.ReplyWithJSON(w, resp)
 			return
 		}
 
-		errChan = app.Chain.AddNewBlock(block, app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "AddVerifierNodeHandler failed to AddNewBlock"))
+		nodeAddedAction := actions.NewNodeAdded(newNodeRequest.Node, encGlobalKey)
+
+		if _, err := workers.CommitMembershipAction(app, nodeAddedAction); err != nil {
+			logger.LogError(errors.Wrap(err, "AddVerifierNodeHandler failed to CommitMembershipAction"))
 			transport.InternalServerError(w)
 			return
 		}
@@ -79,10 +95,12 @@ func AddVerifierNodeHandler(app *config.App) http.HandlerFunc {
 		resp := requests.NewNodeResponse{
 			EncGlobalKey: encGlobalKey,
 			Master:       app.Self,
+			GenesisKey:   genesisKey(app),
 		}
 
 		// if this is the first verifier, it will be responsible for distributing blocks to us
-		resp.IsPrimary = len(app.NodeList.Verifiers) == 0
+		// an elected master is a verifier itself and gets every block without one
+		resp.IsPrimary = app.Self.Type == model.NodeTypeMaster && app.NodeList.PrimaryNID() == newNodeRequest.Node.NID
 
 		transport.ReplyWithJSON(w, resp)
 	}
@@ -91,6 +109,10 @@ func AddVerifierNodeHandler(app *config.App) http.HandlerFunc {
 // AddWorkerNodeHandler handles POST /v1/master/nodes/verifier
 func AddWorkerNodeHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
 		newNodeRequest := &requests.NewNodeRequest{}
 		if err := newNodeRequest.FromRequest(r); err != nil {
 			logger.LogError(errors.Wrap(err, "AddWorkerNodeHandler failed to FromRequest"))
@@ -118,6 +140,13 @@ func AddWorkerNodeHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
+		// checked by fingerprint, since a removed node could send the same key under a new KID
+		if app.NodeList.Forbidden(newNodeRequest.Node.NID, newNodePubKey.Fingerprint()) {
+			logger.LogError(errors.New("AddWorkerNodeHandler refused node with NID " + newNodeRequest.Node.NID + ", it or its key was removed from the network"))
+			transport.Forbidden(w)
+			return
+		}
+
 		encGlobalKey, err := newNodePubKey.Encrypt(app.KeySet.GlobalKey.JSON())
 		if err != nil {
 			logger.LogError(errors.Wrap(err, "AddWorkerNodeHandler failed to Encrypt"))
@@ -125,37 +154,126 @@ func AddWorkerNodeHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
+		if existing := app.NodeList.NodeWithNID(newNodeRequest.Node.NID); existing != nil {
+			if existing.Type != model.NodeTypeWorker || !bytes.Equal(existing.PubKey, newNodeRequest.Node.PubKey) {
+				logger.LogError(errors.New("AddWorkerNodeHandler found a different node with NID " + existing.NID))
+				transport.Conflict(w)
+				return
+			}
+
+			verifier := app.NodeList.VerifierWithNID(existing.ParentNID)
+			if verifier == nil && existing.ParentNID == app.Self.NID {
+				verifier = app.Self
+			}
+
+			if verifier == nil {
+				logger.LogError(errors.New("AddWorkerNodeHandler found no verifier for rejoining worker with NID " + existing.NID))
+				transport.ServiceUnavailable(w)
+				return
+			}
+
+			// it died while joining after we added it, so it gets the same answer rather than being added twice
+			resp := requests.NewNodeResponse{
+				EncGlobalKey: encGlobalKey,
+				Master:       app.Self,
+				GenesisKey:   genesisKey(app),
+				Verifier:     verifier,
+			}
+
+			transport.ReplyWithJSON(w, resp)
+			return
+		}
+
+		// an elected master doesn't keep itself in its list of verifiers, but can still look after workers
 		verifier := app.NodeList.RandomVerifier()
+		if verifier == nil && app.Self.Type == model.NodeTypeVerifier {
+			verifier = app.Self
+		}
+
+		if verifier == nil {
+			logger.LogError(errors.New("AddWorkerNodeHandler found no verifier to assign the worker to"))
+			transport.ServiceUnavailable(w)
+			return
+		}
+
 		newNodeRequest.Node.ParentNID = verifier.NID
 
 		nodeAddedAction := actions.NewNodeAdded(newNodeRequest.Node, encGlobalKey)
-		actionJSON := nodeAddedAction.JSON()
 
-		block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, actionJSON, nodeAddedAction.ActionType())
-		if err != nil {
-			logger.LogError(errors.Wrap(err, "AddWorkerNodeHandler failed to NewBlockWithAction"))
+		if _, err := workers.CommitMembershipAction(app, nodeAddedAction); err != nil {
+			logger.LogError(errors.Wrap(err, "AddWorkerNodeHandler failed to CommitMembershipAction"))
 			transport.InternalServerError(w)
 			return
 		}
 
-		errChan, _ := app.Chain.ReserveBlockID(app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "SetValueHandler failed to ReserveBlockID"))
+		resp := requests.NewNodeResponse{
+			EncGlobalKey: encGlobalKey,
+			Master:       app.Self,
+			GenesisKey:   genesisKey(app),
+			Verifier:     verifier,
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// RemoveNodeHandler handles DELETE /v1/master/nodes/{nid}
+func RemoveNodeHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMaster(w, r, app) {
+			return
+		}
+
+		removeReq := &requests.RemoveNodeRequest{}
+		removeReq.FromRequest(r)
+
+		if err := requests.VerifyRequest(removeReq); err != nil {
+			logger.LogError(errors.Wrap(err, "RemoveNodeHandler failed to VerifyRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := config.AuthorizeAdmin(removeReq.AdminToken); err != nil {
+			logger.LogError(errors.Wrap(err, "RemoveNodeHandler failed to AuthorizeAdmin"))
+			transport.Forbidden(w)
+			return
+		}
+
+		if removeReq.NID == app.Self.NID {
+			logger.LogError(errors.New("RemoveNodeHandler tried to remove the master"))
+			transport.BadRequest(w)
+			return
+		}
+
+		node := app.NodeList.NodeWithNID(removeReq.NID)
+		if node == nil {
+			transport.NotFound(w)
+			return
+		}
+
+		pubKey, err := node.KeyPair()
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "RemoveNodeHandler failed to KeyPair"))
 			transport.InternalServerError(w)
 			return
 		}
 
-		errChan = app.Chain.AddNewBlock(block, app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "AddWorkerNodeHandler failed to AddNewBlock"))
+		nodeRemovedAction := actions.NewNodeRemoved(node.NID, pubKey.KID)
+
+		blockID, err := workers.CommitMembershipAction(app, nodeRemovedAction)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "RemoveNodeHandler failed to CommitMembershipAction"))
 			transport.InternalServerError(w)
 			return
 		}
 
-		resp := requests.NewNodeResponse{
-			EncGlobalKey: encGlobalKey,
-			Master:       app.Self,
-			Verifier:     verifier,
+		// the node is gone either way, and the heartbeat worker tries again if the master can't be given a new primary verifier now
+		if err := workers.ReassignMaster(app, node.NID); err != nil {
+			logger.LogError(errors.Wrap(err, "RemoveNodeHandler failed to ReassignMaster"))
+		}
+
+		resp := &requests.WriteResponse{
+			BlockID: blockID,
 		}
 
 		transport.ReplyWithJSON(w, resp)
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/workers"
+)
+
+// a removed node picks its own NID and KID, so it mustn't get back in by sending the same key under new ones
+func TestAddNodeRefusesRemovedKeyWithNewKID(t *testing.T) {
+	removed, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master := &model.Node{NID: "master", Type: model.NodeTypeMaster}
+
+	app := &config.App{
+		Self:     master,
+		NodeList: &config.NodeList{Master: master},
+	}
+
+	app.SetValueForKey("joincode", config.AppJoinCodeKey)
+	app.NodeList.Forbid("removed", removed.Fingerprint())
+
+	rejoining := &acrypto.KeyPair{Public: removed.Public, KID: "new-kid"}
+
+	handlers := map[string]http.HandlerFunc{
+		model.NodeTypeVerifier: AddVerifierNodeHandler(app),
+		model.NodeTypeWorker:   AddWorkerNodeHandler(app),
+	}
+
+	for nodeType, handler := range handlers {
+		req := &requests.NewNodeRequest{
+			Node:     &model.Node{NID: "new-nid", Type: nodeType, Address: "localhost:3000", PubKey: rejoining.PubKeyJSON()},
+			JoinCode: "joincode",
+		}
+
+		body, _ := json.Marshal(req)
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/"+req.Path(), bytes.NewReader(body)))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected a %s rejoining with a removed key under a new KID to get %d, got %d", nodeType, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+// newTestMaster returns a master that commits blocks on its own, as it does before any verifier joins
+func newTestMaster(t *testing.T) *config.App {
+	keyPair, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := blockchain.BrandNewChain(keyPair, globalKey, []byte("{}"), "test.genesis", blockchain.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master := &model.Node{NID: "master", Type: model.NodeTypeMaster, PubKey: keyPair.PubKeyJSON()}
+
+	app := &config.App{
+		Self:     master,
+		KeySet:   &acrypto.KeySet{KeyPair: keyPair, GlobalKey: globalKey},
+		Chain:    chain,
+		NodeList: &config.NodeList{Master: master},
+	}
+
+	app.SetValueForKey("joincode", config.AppJoinCodeKey)
+
+	go workers.ReserveWorker(app)
+	go workers.ProposeWorker(app)
+	go workers.CommitWorker(app)
+	go workers.ActionWorker(app)
+
+	return app
+}
+
+// the verifier is added to the node list before the handler replies, so it must not count itself as one that was there first
+func TestAddVerifierIsPrimary(t *testing.T) {
+	app := newTestMaster(t)
+
+	// the first verifier accepts every block proposed to it once it has joined
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer verifier.Close()
+
+	for _, nid := range []string{"first", "second"} {
+		keyPair, err := acrypto.GenerateNewKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := &requests.NewNodeRequest{
+			Node:     &model.Node{NID: nid, Type: model.NodeTypeVerifier, Address: verifier.URL, PubKey: keyPair.PubKeyJSON()},
+			JoinCode: "joincode",
+		}
+
+		body, _ := json.Marshal(req)
+
+		w := httptest.NewRecorder()
+		AddVerifierNodeHandler(app)(w, httptest.NewRequest(http.MethodPost, "/"+req.Path(), bytes.NewReader(body)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected verifier %s to join with %d, got %d", nid, http.StatusOK, w.Code)
+		}
+
+		resp := &requests.NewNodeResponse{}
+		if err := json.NewDecoder(w.Body).Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := nid == "first"; resp.IsPrimary != expected {
+			t.Errorf("expected verifier %s to be told IsPrimary %t, got %t", nid, expected, resp.IsPrimary)
+		}
+
+		if app.NodeList.VerifierWithNID(nid) == nil {
+			t.Errorf("expected verifier %s to be in the node list once the handler replied", nid)
+		}
+	}
+}
@@ -50,6 +50,8 @@ func startWorkers(app *config.App) {
 	go workers.ProposeWorker(app)
 	go workers.CommitWorker(app)
 	go workers.ActionWorker(app)
+	go workers.HeartbeatWorker(app)
+	go workers.TermWorker(app)
 }
 
 func generateConfig() (*config.App, error) {
@@ -62,6 +64,22 @@ func generateConfig() (*config.App, error) {
 		return nil, errors.New("address does not contain port value")
 	}
 
+	dataDir, err := config.DataDirFromEnv()
+	if err != nil {
+		return nil, errors.Wrap(err, "generateConfig failed to DataDirFromEnv")
+	}
+
+	if dataDir != nil {
+		identity, err := dataDir.LoadIdentity()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to LoadIdentity")
+		}
+
+		if identity != nil {
+			return restoreConfig(address, dataDir, identity)
+		}
+	}
+
 	keyPair, err := acrypto.GenerateMasterKeyPair()
 	if err != nil {
 		return nil, errors.Wrap(err, "generateConfig failed to GenerateMasterKeyPair")
@@ -85,35 +103,115 @@ func generateConfig() (*config.App, error) {
 		GlobalKey: globalKey,
 	}
 
-	globalKeyJSON := globalKey.JSON()
+	app := &config.App{
+		Self:     node,
+		KeySet:   keySet,
+		Cache:    cache.EmptyCache(),
+		NodeList: &config.NodeList{Master: node},
+		DataDir:  dataDir,
+	}
+
+	joinCode := generateJoinCode()
+	app.SetValueForKey(joinCode, config.AppJoinCodeKey)
+
+	if dataDir != nil {
+		if err := app.Election.Restore(dataDir); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to Election.Restore")
+		}
+	}
+
+	var store blockchain.Store = blockchain.NewMemoryStore()
+
+	if dataDir != nil {
+		// the identity is saved before the chain is created so that a crash in between can still be recovered from
+		identity, err := config.IdentityFromApp(app)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to IdentityFromApp")
+		}
+
+		if err := dataDir.SaveIdentity(identity); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to SaveIdentity")
+		}
+
+		store, err = dataDir.ChainStore()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to ChainStore")
+		}
 
-	encGlobalKey, err := keyPair.Encrypt(globalKeyJSON)
+		logger.LogInfo("persisting master node state to " + dataDir.Path)
+	}
+
+	chain, err := brandNewChain(app, store)
 	if err != nil {
-		return nil, errors.Wrap(err, "generateConfig failed to Encrypt")
+		return nil, errors.Wrap(err, "generateConfig failed to brandNewChain")
 	}
 
-	nodeAddedAction := actions.NewNodeAdded(node, encGlobalKey)
-	actionJSON := nodeAddedAction.JSON()
+	app.Chain = chain
+
+	return app, nil
+}
 
-	chain, err := blockchain.BrandNewChain(keyPair, globalKey, actionJSON, nodeAddedAction.ActionType())
+// restoreConfig restarts an existing network from the identity and chain persisted in dataDir
+func restoreConfig(address string, dataDir *config.DataDir, identity *config.Identity) (*config.App, error) {
+	app, err := dataDir.RestoreApp(address, model.NodeTypeMaster, identity)
 	if err != nil {
-		return nil, errors.Wrap(err, "generateConfig failed to BrandNewChain")
+		return nil, errors.Wrap(err, "restoreConfig failed to RestoreApp")
 	}
 
-	app := &config.App{
-		Self:     node,
-		KeySet:   keySet,
-		Chain:    chain,
-		Cache:    cache.EmptyCache(),
-		NodeList: &config.NodeList{},
+	// identities saved before masters knew themselves as master won't have one
+	if app.NodeList.Master == nil {
+		app.NodeList.Master = app.Self
 	}
 
-	joinCode := generateJoinCode()
-	app.SetValueForKey(joinCode, config.AppJoinCodeKey)
+	if app.Chain.Height() == 0 {
+		// we died after saving the identity but before committing the genesis block
+		app.Chain, err = brandNewChain(app, app.Chain.Store)
+		if err != nil {
+			return nil, errors.Wrap(err, "restoreConfig failed to brandNewChain")
+		}
+	}
+
+	// loaded before the chain's MasterElected blocks move the term forward
+	if err := app.Election.Restore(dataDir); err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to Election.Restore")
+	}
+
+	// rebuild the node list and keySet from the chain
+	workers.ReplayChain(app)
+
+	// a verifier may have been elected while we were down, so we come back without a master and take no writes or joins
+	// until the TermWorker either finds a majority of verifiers still follow us, or catches up on the blocks that say who replaced us
+	if app.IsMaster() && len(app.NodeList.AllVerifiers()) > 0 {
+		logger.LogWarn(fmt.Sprintf("restoreConfig found this node was master in term %d when it went down, waiting for the verifiers to confirm it still is", app.Election.Term()))
+
+		app.NodeList.SetMaster(nil)
+	}
+
+	logger.LogInfo(fmt.Sprintf("restored master node from %s with %d verifiers and %d workers", dataDir.Path, len(app.NodeList.AllVerifiers()), len(app.NodeList.AllWorkers())))
 
 	return app, nil
 }
 
+// brandNewChain creates a new chain whose genesis block adds the master node
+func brandNewChain(app *config.App, store blockchain.Store) (*blockchain.Chain, error) {
+	globalKeyJSON := app.KeySet.GlobalKey.JSON()
+
+	encGlobalKey, err := app.KeySet.KeyPair.Encrypt(globalKeyJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, "brandNewChain failed to Encrypt")
+	}
+
+	nodeAddedAction := actions.NewNodeAdded(app.Self, encGlobalKey)
+	actionJSON := nodeAddedAction.JSON()
+
+	chain, err := blockchain.BrandNewChain(app.KeySet.KeyPair, app.KeySet.GlobalKey, actionJSON, nodeAddedAction.ActionType(), store)
+	if err != nil {
+		return nil, errors.Wrap(err, "brandNewChain failed to BrandNewChain")
+	}
+
+	return chain, nil
+}
+
 func generateJoinCode() string {
 	bytes := make([]byte, 12)
 	rand.Read(bytes)
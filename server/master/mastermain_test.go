@@ -0,0 +1,107 @@
+package master
+
+import (
+	"os"
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+)
+
+// startTestMaster creates a master persisting to a new data dir, as if it was started for the first time
+func startTestMaster(t *testing.T) (*config.App, *config.DataDir) {
+	t.Setenv(config.EnvDataDir, t.TempDir())
+
+	args := os.Args
+	os.Args = []string{"astrocache", "master", "localhost:3000"}
+	defer func() { os.Args = args }()
+
+	app, err := generateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app, app.DataDir
+}
+
+// commitTestVerifier commits a block adding a verifier straight to the master's chain
+func commitTestVerifier(t *testing.T, app *config.App) *model.Node {
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := model.NewNode("localhost:3001", model.NodeTypeVerifier, keyPair)
+	action := actions.NewNodeAdded(verifier, nil)
+
+	block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, action.JSON(), action.ActionType())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := block.PrepareForCommit(app.KeySet.KeyPair, app.Chain.LastBlock()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Chain.Commit(block); err != nil {
+		t.Fatal(err)
+	}
+
+	return verifier
+}
+
+func restoreTestMaster(t *testing.T, dataDir *config.DataDir) *config.App {
+	identity, err := dataDir.LoadIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := restoreConfig("localhost:3000", dataDir, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func TestRestoreConfigWithVerifiers(t *testing.T) {
+	app, dataDir := startTestMaster(t)
+	verifier := commitTestVerifier(t, app)
+
+	restored := restoreTestMaster(t, dataDir)
+
+	if restored.Self.NID != app.Self.NID || restored.Chain.Height() != 2 {
+		t.Fatalf("expected the master to be restored with its 2 blocks, got %d", restored.Chain.Height())
+	}
+
+	if restored.NodeList.VerifierWithNID(verifier.NID) == nil {
+		t.Error("expected the verifier to be restored by replaying the chain")
+	}
+
+	if restored.ValueForKey(config.AppJoinCodeKey) != app.ValueForKey(config.AppJoinCodeKey) {
+		t.Error("expected the join code to be restored")
+	}
+
+	// a verifier may have been elected while we were down, so the TermWorker has to confirm the term first
+	if master := restored.NodeList.CurrentMaster(); master != nil {
+		t.Errorf("expected no master until the verifiers confirm the term, got %q", master.NID)
+	}
+
+	if restored.IsMaster() {
+		t.Error("expected the restored master not to act as master until the term is confirmed")
+	}
+}
+
+// with no verifiers there is nobody who could have replaced the master
+func TestRestoreConfigWithoutVerifiers(t *testing.T) {
+	app, dataDir := startTestMaster(t)
+
+	restored := restoreTestMaster(t, dataDir)
+
+	if !restored.IsMaster() || restored.NodeList.CurrentMaster().NID != app.Self.NID {
+		t.Error("expected a master with no verifiers to carry on as master")
+	}
+}
@@ -14,8 +14,10 @@ func router(app *config.App) *mux.Router {
 
 	mux.Methods(http.MethodPost).Path("/v1/master/nodes/verifier").HandlerFunc(handler.AddVerifierNodeHandler(app))
 	mux.Methods(http.MethodPost).Path("/v1/master/nodes/worker").HandlerFunc(handler.AddWorkerNodeHandler(app))
+	mux.Methods(http.MethodDelete).Path("/v1/master/nodes/{nid}").HandlerFunc(handler.RemoveNodeHandler(app))
 
 	mux.Methods(http.MethodGet).Path("/v1/master/chain").HandlerFunc(handler.GetEntireChainHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/master/chain/last").HandlerFunc(handler.GetLastBlockHandler(app))
 	mux.Methods(http.MethodGet).Path("/v1/master/chain/after/{after}").HandlerFunc(handler.GetBlocksAfterHandler(app))
 
 	mux.Methods(http.MethodPost).Path("/v1/master/block/reserve").HandlerFunc(handler.ReserveIDHandler(app))
@@ -3,11 +3,16 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/send"
 	"github.com/astromechio/astrocache/transport"
+	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
@@ -89,3 +94,174 @@ func CheckBlockHandler(app *config.App) http.HandlerFunc {
 // 	transport.Conflict(w)
 // 	return
 // }
+
+// CommittedBlocksAfterHandler returns the blocks this verifier has committed after an ID, without redirecting to the master
+// it lets a restarted master catch up on blocks the verifiers committed that it never received
+func CommittedBlocksAfterHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		afterID := mux.Vars(r)["after"]
+
+		blocks := app.Chain.BlocksAfterID(afterID)
+		if blocks == nil {
+			transport.NotFound(w)
+			return
+		}
+
+		transport.ReplyWithJSON(w, blocks)
+	}
+}
+
+// CommittedLastBlockHandler returns the ID and height of the last block this verifier has committed, without redirecting to the master
+func CommittedLastBlockHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		last := app.Chain.LastBlock()
+		if last == nil {
+			transport.NotFound(w)
+			return
+		}
+
+		resp := &requests.LastBlockResponse{
+			BlockID: last.ID,
+			Height:  app.Chain.Height(),
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// LatestBlockHandler returns the ID and height of the latest committed block, for workers serving strong reads
+// every block ID is reserved with the master, so the block is confirmed with the master and this verifier catches up from it so that the worker can catch up from us
+// if the master can't be reached the read fails, only while there is no master at all is the latest block found by asking a quorum of verifiers instead
+func LatestBlockHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.IsMaster() {
+			transport.ReplyWithJSON(w, &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: app.Chain.Height()})
+			return
+		}
+
+		if master := app.NodeList.CurrentMaster(); master != nil {
+			latestFromMaster(w, app, master)
+			return
+		}
+
+		latest, verifier, err := latestCommittedBlock(app, requests.DefaultReadTimeout)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "LatestBlockHandler failed to latestCommittedBlock"))
+			transport.ServiceUnavailable(w)
+			return
+		}
+
+		if verifier != nil && app.Chain.BlocksAfterID(latest.BlockID) == nil {
+			last := app.Chain.LastBlock()
+
+			missing, err := send.GetCommittedBlocksAfter(verifier, last.ID)
+			if err != nil {
+				logger.LogError(errors.Wrap(err, "LatestBlockHandler failed to GetCommittedBlocksAfter"))
+				transport.ServiceUnavailable(w)
+				return
+			}
+
+			logger.LogInfo(fmt.Sprintf("LatestBlockHandler catching up on %d blocks after %q from %s", len(missing), last.ID, verifier.Address))
+
+			appendMissing(app, missing)
+		}
+
+		transport.ReplyWithJSON(w, latest)
+	}
+}
+
+// latestFromMaster replies with the last block committed on master, once this verifier has caught up to it
+func latestFromMaster(w http.ResponseWriter, app *config.App, master *model.Node) {
+	latest, err := send.GetLastBlock(master, requests.DefaultReadTimeout)
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "latestFromMaster failed to GetLastBlock"))
+		transport.ServiceUnavailable(w)
+		return
+	}
+
+	if app.Chain.BlocksAfterID(latest.BlockID) == nil {
+		last := app.Chain.LastBlock()
+
+		missing, err := send.GetBlocksAfter(master, last.ID)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "latestFromMaster failed to GetBlocksAfter"))
+			transport.ServiceUnavailable(w)
+			return
+		}
+
+		logger.LogInfo(fmt.Sprintf("latestFromMaster catching up on %d blocks after %q from %s", len(missing), last.ID, master.Address))
+
+		appendMissing(app, missing)
+	}
+
+	transport.ReplyWithJSON(w, latest)
+}
+
+// latestCommittedBlock asks the other verifiers for their last committed block until a majority including this one have answered
+// it returns the one with the greatest height, along with the verifier that has it, which is nil if it is this one
+func latestCommittedBlock(app *config.App, timeout time.Duration) (*requests.LastBlockResponse, *model.Node, error) {
+	verifiers := app.NodeList.AllVerifiers()
+
+	type answer struct {
+		last     *requests.LastBlockResponse
+		verifier *model.Node
+	}
+
+	answerChan := make(chan *answer, len(verifiers))
+
+	for i := range verifiers {
+		go func(verifier *model.Node) {
+			last, err := send.GetCommittedLastBlock(verifier, timeout)
+			if err != nil {
+				logger.LogWarn(fmt.Sprintf("latestCommittedBlock failed to GetCommittedLastBlock from %s: %s", verifier.Address, err.Error()))
+				answerChan <- nil
+				return
+			}
+
+			answerChan <- &answer{last: last, verifier: verifier}
+		}(verifiers[i])
+	}
+
+	latest := &answer{
+		last: &requests.LastBlockResponse{
+			BlockID: app.Chain.LastBlock().ID,
+			Height:  app.Chain.Height(),
+		},
+	}
+
+	total := len(verifiers) + 1
+	answered := 1
+
+	for i := 0; i < len(verifiers) && answered*2 <= total; i++ {
+		a := <-answerChan
+		if a == nil {
+			continue
+		}
+
+		answered++
+
+		if a.last.Height > latest.last.Height {
+			latest = a
+		}
+	}
+
+	if answered*2 <= total {
+		return nil, nil, fmt.Errorf("only %d of %d verifiers answered, a majority is needed", answered, total)
+	}
+
+	return latest.last, latest.verifier, nil
+}
+
+// appendMissing appends the blocks that haven't been committed here yet, skipping any that arrived while they were being loaded
+func appendMissing(app *config.App, missing []*blockchain.Block) {
+	for i := range missing {
+		if app.Chain.BlocksAfterID(missing[i].ID) != nil {
+			continue
+		}
+
+		if err := app.Chain.AppendBlocks(missing[i : i+1]); err != nil {
+			logger.LogWarn(fmt.Sprintf("appendMissing failed to AppendBlocks for block with ID %q: %s", missing[i].ID, err.Error()))
+			break
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+)
+
+func newBlocksTestApp(t *testing.T) *config.App {
+	keyPair, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := blockchain.BrandNewChain(keyPair, globalKey, []byte("{}"), "test.genesis", blockchain.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &config.App{
+		Self:     &model.Node{NID: "self", Type: model.NodeTypeVerifier},
+		Chain:    chain,
+		NodeList: &config.NodeList{},
+	}
+}
+
+// addTestVerifier adds a verifier to app that answers with last, or that can't be reached if last is nil
+func addTestVerifier(t *testing.T, app *config.App, nid string, last *requests.LastBlockResponse) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if last == nil {
+			transport.ServiceUnavailable(w)
+			return
+		}
+
+		transport.ReplyWithJSON(w, last)
+	}))
+
+	t.Cleanup(server.Close)
+
+	app.NodeList.AddVerifier(&model.Node{NID: nid, Type: model.NodeTypeVerifier, Address: server.URL})
+}
+
+func TestLatestCommittedBlock(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	addTestVerifier(t, app, "ahead", &requests.LastBlockResponse{BlockID: "ahead", Height: 5})
+	addTestVerifier(t, app, "behind", &requests.LastBlockResponse{BlockID: "behind", Height: 2})
+	addTestVerifier(t, app, "down", nil)
+
+	latest, verifier, err := latestCommittedBlock(app, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with one verifier down, the majority of 3 of 4 needs every other verifier to answer
+	if latest.BlockID != "ahead" || latest.Height != 5 {
+		t.Errorf("expected block %q at height 5, got %q at height %d", "ahead", latest.BlockID, latest.Height)
+	}
+
+	if verifier == nil || verifier.NID != "ahead" {
+		t.Error("expected the block to be returned along with the verifier that has it")
+	}
+}
+
+func TestLatestCommittedBlockFromSelf(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	addTestVerifier(t, app, "behind", &requests.LastBlockResponse{BlockID: "behind", Height: 0})
+
+	latest, verifier, err := latestCommittedBlock(app, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verifier != nil || latest.BlockID != app.Chain.LastBlock().ID {
+		t.Errorf("expected this verifier's last block, got %q", latest.BlockID)
+	}
+}
+
+func TestLatestCommittedBlockWithoutMajority(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	addTestVerifier(t, app, "ahead", &requests.LastBlockResponse{BlockID: "ahead", Height: 5})
+	addTestVerifier(t, app, "down", nil)
+	addTestVerifier(t, app, "also-down", nil)
+
+	if _, _, err := latestCommittedBlock(app, time.Second); err == nil {
+		t.Error("expected an error when only 2 of 4 verifiers answer")
+	}
+}
+
+// setTestMaster makes app's master a node that answers with last, or that can't be reached if last is nil
+func setTestMaster(t *testing.T, app *config.App, last *requests.LastBlockResponse) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if last == nil {
+			transport.ServiceUnavailable(w)
+			return
+		}
+
+		transport.ReplyWithJSON(w, last)
+	}))
+
+	t.Cleanup(server.Close)
+
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster, Address: server.URL})
+}
+
+func getTestLatestBlock(t *testing.T, app *config.App) (int, *requests.LastBlockResponse) {
+	w := httptest.NewRecorder()
+	LatestBlockHandler(app)(w, httptest.NewRequest(http.MethodGet, "/v1/verifier/chain/latest", nil))
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+
+	latest := &requests.LastBlockResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), latest); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Code, latest
+}
+
+func TestLatestBlockFromMaster(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	setTestMaster(t, app, &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+
+	// without a master, this verifier couldn't reach a majority
+	addTestVerifier(t, app, "down", nil)
+	addTestVerifier(t, app, "also-down", nil)
+
+	code, latest := getTestLatestBlock(t, app)
+	if code != http.StatusOK {
+		t.Fatalf("expected the master's last block, got %d", code)
+	}
+
+	if latest.BlockID != app.Chain.LastBlock().ID {
+		t.Errorf("expected block %q, got %q", app.Chain.LastBlock().ID, latest.BlockID)
+	}
+}
+
+func TestLatestBlockMasterUnreachable(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	setTestMaster(t, app, nil)
+
+	addTestVerifier(t, app, "up", &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+	addTestVerifier(t, app, "also-up", &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+
+	// the verifiers can't vouch for the latest block while a master may be handing out more of them
+	if code, _ := getTestLatestBlock(t, app); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d while the master can't be reached, got %d", http.StatusServiceUnavailable, code)
+	}
+}
+
+func TestLatestBlockWithoutMaster(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	addTestVerifier(t, app, "up", &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+	addTestVerifier(t, app, "down", nil)
+
+	code, latest := getTestLatestBlock(t, app)
+	if code != http.StatusOK {
+		t.Fatalf("expected the quorum's latest block while there is no master, got %d", code)
+	}
+
+	if latest.BlockID != app.Chain.LastBlock().ID {
+		t.Errorf("expected block %q, got %q", app.Chain.LastBlock().ID, latest.BlockID)
+	}
+}
+
+func TestLatestBlockOnElectedMaster(t *testing.T) {
+	app := newBlocksTestApp(t)
+
+	app.NodeList.SetMaster(app.Self)
+	addTestVerifier(t, app, "down", nil)
+	addTestVerifier(t, app, "also-down", nil)
+
+	// an elected verifier hands out block IDs itself, so its own chain is the one to confirm with
+	code, latest := getTestLatestBlock(t, app)
+	if code != http.StatusOK {
+		t.Fatalf("expected this verifier's last block, got %d", code)
+	}
+
+	if latest.BlockID != app.Chain.LastBlock().ID || latest.Height != app.Chain.Height() {
+		t.Errorf("expected block %q at height %d, got %q at height %d", app.Chain.LastBlock().ID, app.Chain.Height(), latest.BlockID, latest.Height)
+	}
+}
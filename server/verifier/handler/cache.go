@@ -2,12 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/astromechio/astrocache/model/actions"
-	"github.com/astromechio/astrocache/model/blockchain"
 
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/astromechio/astrocache/model/requests"
 	"github.com/astromechio/astrocache/transport"
 	"github.com/pkg/errors"
@@ -17,7 +18,11 @@ import (
 func SetValueHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setValReq := &requests.SetValueRequest{}
-		setValReq.FromRequest(r)
+		if err := setValReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "SetValueHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
 
 		if err := setValReq.Verify(); err != nil {
 			logger.LogError(errors.Wrap(err, "SetValueHandler failed to Verify"))
@@ -25,29 +30,184 @@ func SetValueHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
-		action := actions.NewSetValue(setValReq.Key, setValReq.Value)
-		actionJSON := action.JSON()
+		if !authorizeNamespace(w, app, setValReq.Namespace, setValReq.Token) {
+			return
+		}
+
+		action := actions.NewSetValue(setValReq.Namespace, setValReq.Key, setValReq.Bytes(), setValReq.ContentType, setValReq.TTL, setValReq.IfMatch)
 
-		block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, actionJSON, action.ActionType())
-		if err != nil {
-			transport.InternalServerError(w)
+		result, ok := commitAction(w, app, action)
+		if !ok {
 			return
 		}
 
-		errChan, _ := app.Chain.ReserveBlockID(app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "SetValueHandler failed to ReserveBlockID"))
-			transport.InternalServerError(w)
+		w.Header().Set(requests.ETagHeader, requests.FormatETag(result.Version))
+		replyWithWrite(w, result)
+	}
+}
+
+// DeleteValueHandler handles value delete requests
+func DeleteValueHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delValReq := &requests.DeleteValueRequest{}
+		delValReq.FromRequest(r)
+
+		if err := delValReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "DeleteValueHandler failed to Verify"))
+			transport.BadRequest(w)
 			return
 		}
 
-		errChan = app.Chain.AddNewBlock(block, app.Self.NID)
-		if err := <-errChan; err != nil {
-			logger.LogError(errors.Wrap(err, "SetValueHandler failed to AddNewBlock"))
-			transport.Conflict(w)
+		if !authorizeNamespace(w, app, delValReq.Namespace, delValReq.Token) {
+			return
+		}
+
+		action := actions.NewDeleteValue(delValReq.Namespace, delValReq.Key, delValReq.IfMatch)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// BatchSetHandler handles batch set requests
+func BatchSetHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchReq := &requests.BatchSetRequest{}
+		if err := batchReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "BatchSetHandler failed to FromRequest"))
+			transport.BadRequest(w)
 			return
 		}
 
-		transport.Ok(w)
+		if err := batchReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "BatchSetHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if !authorizeNamespace(w, app, batchReq.Namespace, batchReq.Token) {
+			return
+		}
+
+		sets := make([]*actions.BatchSetValue, len(batchReq.Sets))
+		for i, set := range batchReq.Sets {
+			sets[i] = &actions.BatchSetValue{
+				Key:         set.Key,
+				Data:        set.Bytes(),
+				ContentType: set.ContentType,
+				TTL:         set.TTL,
+				IfMatch:     set.IfMatch,
+			}
+		}
+
+		action := actions.NewBatchSet(batchReq.Namespace, sets, batchReq.Deletes)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		w.Header().Set(requests.ETagHeader, requests.FormatETag(result.Version))
+		replyWithWrite(w, result)
 	}
 }
+
+// IncrementValueHandler handles value increment requests
+func IncrementValueHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		incValReq := &requests.IncrementValueRequest{}
+		if err := incValReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := incValReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if !authorizeNamespace(w, app, incValReq.Namespace, incValReq.Token) {
+			return
+		}
+
+		action := actions.NewIncrementValue(incValReq.Namespace, incValReq.Key, incValReq.Delta, incValReq.TTL)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		resp := &requests.IncrementValueResponse{
+			Key:     incValReq.Key,
+			BlockID: result.BlockID,
+		}
+
+		// the increment is committed either way, but a verifier that evicted the key doesn't know what it was incremented to
+		if result.Value != "" {
+			value, err := strconv.ParseInt(result.Value, 10, 64)
+			if err != nil {
+				logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to ParseInt"))
+				transport.InternalServerError(w)
+				return
+			}
+
+			resp.Value = &value
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// commitAction submits action to be added to the chain and waits for it to be executed
+// it returns the action's result, with the ID of the block it was committed in, if anything fails an error is written to w and false is returned
+// an action rejected because of the key's state (a version mismatch, for example) is still committed, but is reported as a conflict
+func commitAction(w http.ResponseWriter, app *config.App, action actions.Action) (*blockchain.ActionResult, bool) {
+	resultChan := app.Chain.SubmitAction(action.ActionType(), action.JSON())
+
+	result := <-resultChan
+	if err := result.Err; err != nil {
+		if !result.Committed {
+			logger.LogError(errors.Wrap(err, "commitAction failed to commit action"))
+			transport.Conflict(w)
+			return nil, false
+		}
+
+		if isRejection(err) {
+			logger.LogWarn("commitAction rejected action: " + err.Error())
+			transport.Conflict(w)
+			return nil, false
+		}
+
+		logger.LogError(errors.Wrap(err, "commitAction failed to apply action"))
+		transport.InternalServerError(w)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// replyWithWrite replies with the ID of the block a write was committed in, and the version of the keys it wrote
+func replyWithWrite(w http.ResponseWriter, result *blockchain.ActionResult) {
+	resp := &requests.WriteResponse{
+		BlockID: result.BlockID,
+		Version: result.Version,
+	}
+
+	transport.ReplyWithJSON(w, resp)
+}
+
+// isRejection returns true if err means an action was refused because of the state of its key
+func isRejection(err error) bool {
+	switch errors.Cause(err) {
+	case actions.ErrVersionMismatch, actions.ErrNotNumeric, actions.ErrOverflow, actions.ErrWrongType, config.ErrNoNamespace, config.ErrNamespaceExists, config.ErrQuotaExceeded:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+	workerhandler "github.com/astromechio/astrocache/server/worker/handler"
+	"github.com/gorilla/mux"
+)
+
+// newCacheTestApp creates a verifier whose submitted actions are each executed in a block of their own, in the order they were submitted
+func newCacheTestApp(t *testing.T) *config.App {
+	app := newBlocksTestApp(t)
+	app.Cache = cache.EmptyCache()
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		for n := 1; ; n++ {
+			select {
+			case pending := <-app.Chain.PendingChan:
+				pending.ResultChan <- executeTestAction(app, pending, fmt.Sprintf("block-%d", n))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return app
+}
+
+// executeTestAction executes a submitted action as the only action in a committed block with ID id
+func executeTestAction(app *config.App, pending *blockchain.PendingAction, id string) *blockchain.ActionResult {
+	action, err := actions.UnmarshalAction(pending.ActionJSON, pending.ActionType)
+	if err != nil {
+		return &blockchain.ActionResult{Err: err}
+	}
+
+	actions.AdvanceClock(app, action)
+
+	return &blockchain.ActionResult{
+		Err:       action.Execute(app, &blockchain.Block{ID: id}),
+		BlockID:   id,
+		Version:   id,
+		Committed: true,
+	}
+}
+
+// newValueTestRouter routes value writes to the verifier's handlers, and value reads to a worker's, both backed by app
+func newValueTestRouter(app *config.App) *mux.Router {
+	router := mux.NewRouter()
+
+	for _, prefix := range []string{"/v1", "/v1/ns/{ns}"} {
+		router.Methods(http.MethodPost).Path(prefix + "/value/{key}").HandlerFunc(SetValueHandler(app))
+		router.Methods(http.MethodPut).Path(prefix + "/value/{key}").HandlerFunc(SetValueHandler(app))
+		router.Methods(http.MethodDelete).Path(prefix + "/value/{key}").HandlerFunc(DeleteValueHandler(app))
+		router.Methods(http.MethodGet).Path(prefix + "/value/{key}").HandlerFunc(workerhandler.GetValueHandler(app))
+	}
+
+	return router
+}
+
+func sendValueTestRequest(router *mux.Router, method, path string, header http.Header, body []byte) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, bytes.NewReader(body))
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+// expectWrite checks that a write succeeded and returns the ID of the block it was committed in
+func expectWrite(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected write to succeed, got %d", w.Code)
+	}
+
+	resp := &requests.WriteResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.BlockID == "" {
+		t.Fatal("expected write to return the ID of its block")
+	}
+
+	return resp.BlockID
+}
+
+func TestSetValueRawPut(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	// not valid UTF-8, and with a zero byte, so it would be mangled by any trip through a JSON string
+	value := []byte{0xff, 0xfe, 0x00, 0x80, 'a', 0xc3}
+
+	before := time.Now()
+
+	blockID := expectWrite(t, sendValueTestRequest(router, http.MethodPut, "/v1/value/blob?ttl=60", http.Header{requests.ContentTypeHeader: {"application/x-protobuf"}}, value))
+
+	w := sendValueTestRequest(router, http.MethodGet, "/v1/value/blob", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected value to be found, got %d", w.Code)
+	}
+
+	if !bytes.Equal(w.Body.Bytes(), value) {
+		t.Errorf("expected value %v, got %v", value, w.Body.Bytes())
+	}
+
+	if contentType := w.Header().Get(requests.ContentTypeHeader); contentType != "application/x-protobuf" {
+		t.Errorf("expected content type %q to be echoed, got %q", "application/x-protobuf", contentType)
+	}
+
+	if etag := w.Header().Get(requests.ETagHeader); etag != requests.FormatETag(blockID) {
+		t.Errorf("expected ETag %q, got %q", requests.FormatETag(blockID), etag)
+	}
+
+	_, expiresAt, ok := app.Cache.ItemForKeyAt("blob", time.Now())
+	if !ok {
+		t.Fatal("expected value to be set")
+	}
+
+	if expiresAt.Before(before.Add(60*time.Second)) || expiresAt.After(time.Now().Add(60*time.Second)) {
+		t.Errorf("expected value to expire 60s after it was set, expires at %s", expiresAt)
+	}
+}
+
+func TestSetValueRawPutEmpty(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	// a zero-length body is a value too
+	expectWrite(t, sendValueTestRequest(router, http.MethodPut, "/v1/value/empty", http.Header{requests.ContentTypeHeader: {"application/octet-stream"}}, nil))
+
+	w := sendValueTestRequest(router, http.MethodGet, "/v1/value/empty", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected empty value to be found, got %d", w.Code)
+	}
+
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty value, got %v", w.Body.Bytes())
+	}
+
+	// a JSON body still has to carry a value
+	if w := sendValueTestRequest(router, http.MethodPost, "/v1/value/empty", nil, []byte(`{}`)); w.Code != http.StatusBadRequest {
+		t.Errorf("expected set without a value to be a bad request, got %d", w.Code)
+	}
+}
+
+func TestSetValueRawPutBadTTL(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	if w := sendValueTestRequest(router, http.MethodPut, "/v1/value/blob?ttl=soon", nil, []byte("value")); w.Code != http.StatusBadRequest {
+		t.Errorf("expected non-integer ttl to be a bad request, got %d", w.Code)
+	}
+
+	if _, ok := app.Cache.ItemForKey("blob"); ok {
+		t.Error("expected value not to be set")
+	}
+}
+
+func TestDeleteValue(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	expectWrite(t, sendValueTestRequest(router, http.MethodPost, "/v1/value/key", nil, []byte(`{"value":"value"}`)))
+
+	deleteBlockID := expectWrite(t, sendValueTestRequest(router, http.MethodDelete, "/v1/value/key", nil, nil))
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/value/key", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected deleted key not to be found, got %d", w.Code)
+	}
+
+	// deleting a missing key is still committed, so it is ordered against other writes to the key
+	if blockID := expectWrite(t, sendValueTestRequest(router, http.MethodDelete, "/v1/value/missing", nil, nil)); blockID == deleteBlockID {
+		t.Error("expected each delete to be committed in its own block")
+	}
+
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/value/missing", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected missing key not to be found, got %d", w.Code)
+	}
+}
+
+func TestDeleteValueIfMatch(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	version := expectWrite(t, sendValueTestRequest(router, http.MethodPost, "/v1/value/key", nil, []byte(`{"value":"value"}`)))
+
+	stale := http.Header{requests.IfMatchHeader: {requests.FormatETag("stale")}}
+	if w := sendValueTestRequest(router, http.MethodDelete, "/v1/value/key", stale, nil); w.Code != http.StatusConflict {
+		t.Errorf("expected delete of a key with another version to conflict, got %d", w.Code)
+	}
+
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/value/key", nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected key to survive a conflicting delete, got %d", w.Code)
+	}
+
+	current := http.Header{requests.IfMatchHeader: {requests.FormatETag(version)}}
+	expectWrite(t, sendValueTestRequest(router, http.MethodDelete, "/v1/value/key", current, nil))
+
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/value/key", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected key deleted at its current version not to be found, got %d", w.Code)
+	}
+}
+
+func TestDeleteValueNamespace(t *testing.T) {
+	app := newCacheTestApp(t)
+	router := newValueTestRouter(app)
+
+	if err := app.Namespaces.Create("ns", 0, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	expectWrite(t, sendValueTestRequest(router, http.MethodPost, "/v1/value/key", nil, []byte(`{"value":"default"}`)))
+	expectWrite(t, sendValueTestRequest(router, http.MethodPost, "/v1/ns/ns/value/key", nil, []byte(`{"value":"ns"}`)))
+
+	expectWrite(t, sendValueTestRequest(router, http.MethodDelete, "/v1/ns/ns/value/key", nil, nil))
+
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/ns/ns/value/key", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected key deleted from the namespace not to be found, got %d", w.Code)
+	}
+
+	// the same key in the default namespace is left alone
+	if w := sendValueTestRequest(router, http.MethodGet, "/v1/value/key", nil, nil); w.Code != http.StatusOK || w.Body.String() != "default" {
+		t.Errorf("expected key in the default namespace to keep its value, got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := sendValueTestRequest(router, http.MethodDelete, "/v1/ns/other/value/key", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected delete in a missing namespace to not be found, got %d", w.Code)
+	}
+}
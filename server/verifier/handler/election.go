@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// VoteHandler handles requests from candidates to vote for them to become master
+func VoteHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		voteReq := &requests.VoteRequest{}
+		if err := voteReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "VoteHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := voteReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "VoteHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		candidate := app.NodeList.VerifierWithNID(voteReq.CandidateNID)
+		if candidate == nil {
+			logger.LogError(fmt.Errorf("VoteHandler got vote request from unknown verifier with NID %s", voteReq.CandidateNID))
+			transport.Forbidden(w)
+			return
+		}
+
+		pubKey, err := candidate.KeyPair()
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "VoteHandler failed to KeyPair"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		if !pubKey.Verify(voteReq.SigningBody(), voteReq.Signature) {
+			logger.LogError(fmt.Errorf("VoteHandler failed to Verify signature from verifier with NID %s", voteReq.CandidateNID))
+			transport.Forbidden(w)
+			return
+		}
+
+		resp := &requests.VoteResponse{}
+
+		// a live master never votes itself out
+		if app.IsMaster() {
+			if _, err := app.Election.Observe(voteReq.Term); err != nil {
+				logger.LogError(errors.Wrap(err, "VoteHandler failed to Observe"))
+			}
+
+			resp.Term = app.Election.Term()
+		} else {
+			upToDate := voteReq.Height >= app.Chain.Height()
+
+			resp.Granted, resp.Term, err = app.Election.Vote(voteReq.Term, voteReq.CandidateNID, upToDate)
+			if err != nil {
+				logger.LogError(errors.Wrap(err, "VoteHandler failed to Vote"))
+			}
+		}
+
+		logger.LogInfo(fmt.Sprintf("VoteHandler voted %t for verifier with NID %s in term %d", resp.Granted, voteReq.CandidateNID, voteReq.Term))
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// ElectionStatusHandler handles GET /v1/verifier/election, which describes this verifier's term and the master it follows
+func ElectionStatusHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &requests.ElectionStatusResponse{
+			NID:    app.Self.NID,
+			Height: app.Chain.Height(),
+		}
+
+		resp.Term, resp.VotedFor = app.Election.Status()
+
+		if master := app.NodeList.CurrentMaster(); master != nil {
+			resp.MasterNID = master.NID
+		}
+
+		if last := app.Chain.LastBlock(); last != nil {
+			resp.LastBlockID = last.ID
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
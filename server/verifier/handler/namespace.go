@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// CreateNamespaceHandler handles namespace creation requests, which need the admin token
+func CreateNamespaceHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		createReq := &requests.CreateNamespaceRequest{}
+		if err := createReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "CreateNamespaceHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := createReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "CreateNamespaceHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := config.AuthorizeAdmin(createReq.AdminToken); err != nil {
+			logger.LogError(errors.Wrap(err, "CreateNamespaceHandler failed to AuthorizeAdmin"))
+			transport.Forbidden(w)
+			return
+		}
+
+		if app.Namespaces.Get(createReq.Name) != nil {
+			transport.Conflict(w)
+			return
+		}
+
+		action := actions.NewNamespaceCreated(createReq.Name, createReq.MaxEntries, createReq.MaxBytes, config.HashToken(createReq.Token))
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// DeleteNamespaceHandler handles namespace deletion requests, which need the admin token
+func DeleteNamespaceHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleteReq := &requests.DeleteNamespaceRequest{}
+		deleteReq.FromRequest(r)
+
+		if err := deleteReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "DeleteNamespaceHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := config.AuthorizeAdmin(deleteReq.AdminToken); err != nil {
+			logger.LogError(errors.Wrap(err, "DeleteNamespaceHandler failed to AuthorizeAdmin"))
+			transport.Forbidden(w)
+			return
+		}
+
+		if app.Namespaces.Get(deleteReq.Name) == nil {
+			transport.NotFound(w)
+			return
+		}
+
+		action := actions.NewNamespaceDeleted(deleteReq.Name)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// FlushHandler handles flush requests, which need the admin token
+// all is set for the route that flushes every namespace
+func FlushHandler(app *config.App, all bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flushReq := &requests.FlushRequest{All: all}
+		flushReq.FromRequest(r)
+
+		if err := flushReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "FlushHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := config.AuthorizeAdmin(flushReq.AdminToken); err != nil {
+			logger.LogError(errors.Wrap(err, "FlushHandler failed to AuthorizeAdmin"))
+			transport.Forbidden(w)
+			return
+		}
+
+		var action actions.Action
+		if flushReq.All {
+			action = actions.NewFlushAll()
+		} else {
+			if _, err := app.CacheForNamespace(flushReq.Namespace); err != nil {
+				transport.NotFound(w)
+				return
+			}
+
+			action = actions.NewFlushNamespace(flushReq.Namespace)
+		}
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// authorizeNamespace checks that a namespace exists and that the token grants access to it
+// if not, an error is written to w and false is returned
+func authorizeNamespace(w http.ResponseWriter, app *config.App, ns, token string) bool {
+	if _, err := app.AuthorizeNamespace(ns, token); err != nil {
+		if err == config.ErrNoNamespace {
+			transport.NotFound(w)
+			return false
+		}
+
+		logger.LogError(errors.Wrap(err, "authorizeNamespace failed to AuthorizeNamespace for namespace "+ns))
+		transport.Forbidden(w)
+		return false
+	}
+
+	return true
+}
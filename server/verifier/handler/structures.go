@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// HashSetHandler handles hash set requests
+func HashSetHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hashReq := &requests.HashSetRequest{}
+		if !loadRequest(w, r, hashReq) || !authorizeNamespace(w, app, hashReq.Namespace, hashReq.Token) {
+			return
+		}
+
+		action := actions.NewHashSet(hashReq.Namespace, hashReq.Key, hashReq.Fields, hashReq.TTL)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// HashDeleteHandler handles hash delete requests
+func HashDeleteHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hashReq := &requests.HashDeleteRequest{}
+		if !loadRequest(w, r, hashReq) || !authorizeNamespace(w, app, hashReq.Namespace, hashReq.Token) {
+			return
+		}
+
+		action := actions.NewHashDelete(hashReq.Namespace, hashReq.Key, hashReq.Fields)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// ListPushHandler handles list push requests
+func ListPushHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listReq := &requests.ListPushRequest{}
+		if !loadRequest(w, r, listReq) || !authorizeNamespace(w, app, listReq.Namespace, listReq.Token) {
+			return
+		}
+
+		action := actions.NewListPush(listReq.Namespace, listReq.Key, listReq.Values, listReq.Left, listReq.TTL)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// ListPopHandler handles list pop requests
+func ListPopHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listReq := &requests.ListPopRequest{}
+		if !loadRequest(w, r, listReq) || !authorizeNamespace(w, app, listReq.Namespace, listReq.Token) {
+			return
+		}
+
+		action := actions.NewListPop(listReq.Namespace, listReq.Key, listReq.Count, listReq.Left)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		resp := &requests.ListPopResponse{
+			Key:     listReq.Key,
+			Values:  []string{},
+			BlockID: result.BlockID,
+		}
+
+		if result.Value != "" {
+			if err := json.Unmarshal([]byte(result.Value), &resp.Values); err != nil {
+				logger.LogError(errors.Wrap(err, "ListPopHandler failed to Unmarshal"))
+				transport.InternalServerError(w)
+				return
+			}
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// SetAddHandler handles set add requests
+func SetAddHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setReq := &requests.SetAddRequest{}
+		if !loadRequest(w, r, setReq) || !authorizeNamespace(w, app, setReq.Namespace, setReq.Token) {
+			return
+		}
+
+		action := actions.NewSetAdd(setReq.Namespace, setReq.Key, setReq.Members, setReq.TTL)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// SetRemoveHandler handles set remove requests
+func SetRemoveHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setReq := &requests.SetRemoveRequest{}
+		if !loadRequest(w, r, setReq) || !authorizeNamespace(w, app, setReq.Namespace, setReq.Token) {
+			return
+		}
+
+		action := actions.NewSetRemove(setReq.Namespace, setReq.Key, setReq.Members)
+
+		result, ok := commitAction(w, app, action)
+		if !ok {
+			return
+		}
+
+		replyWithWrite(w, result)
+	}
+}
+
+// loadRequest loads and verifies a request, if either fails an error is written to w and false is returned
+func loadRequest(w http.ResponseWriter, r *http.Request, req requests.Request) bool {
+	if err := req.FromRequest(r); err != nil {
+		logger.LogError(errors.Wrap(err, "loadRequest failed to FromRequest"))
+		transport.BadRequest(w)
+		return false
+	}
+
+	if err := req.Verify(); err != nil {
+		logger.LogError(errors.Wrap(err, "loadRequest failed to Verify"))
+		transport.BadRequest(w)
+		return false
+	}
+
+	return true
+}
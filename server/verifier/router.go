@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/astromechio/astrocache/config"
+	mhandler "github.com/astromechio/astrocache/server/master/handler"
 	"github.com/astromechio/astrocache/server/verifier/handler"
+	whandler "github.com/astromechio/astrocache/server/worker/handler"
 	"github.com/gorilla/mux"
 )
 
@@ -16,7 +18,50 @@ func router(app *config.App) *mux.Router {
 	// TODO: different method for check?
 	mux.Methods(http.MethodPost).Path("/v1/verifier/block/check").HandlerFunc(handler.CheckBlockHandler(app))
 
-	mux.Methods(http.MethodPost).Path("/v1/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+	mux.Methods(http.MethodPost).Path("/v1/verifier/vote").HandlerFunc(handler.VoteHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/verifier/chain/last").HandlerFunc(handler.CommittedLastBlockHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/verifier/chain/latest").HandlerFunc(handler.LatestBlockHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/verifier/chain/after/{after}").HandlerFunc(handler.CommittedBlocksAfterHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/verifier/election").HandlerFunc(handler.ElectionStatusHandler(app))
+	mux.Methods(http.MethodPost).Path("/v1/node/heartbeat").HandlerFunc(whandler.HeartbeatHandler(app))
+
+	// a verifier takes over the master's routes if it is elected, until then they redirect to the current master
+	mux.Methods(http.MethodPost).Path("/v1/master/nodes/verifier").HandlerFunc(mhandler.AddVerifierNodeHandler(app))
+	mux.Methods(http.MethodPost).Path("/v1/master/nodes/worker").HandlerFunc(mhandler.AddWorkerNodeHandler(app))
+	mux.Methods(http.MethodDelete).Path("/v1/master/nodes/{nid}").HandlerFunc(mhandler.RemoveNodeHandler(app))
+
+	mux.Methods(http.MethodGet).Path("/v1/master/chain").HandlerFunc(mhandler.GetEntireChainHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/master/chain/last").HandlerFunc(mhandler.GetLastBlockHandler(app))
+	mux.Methods(http.MethodGet).Path("/v1/master/chain/after/{after}").HandlerFunc(mhandler.GetBlocksAfterHandler(app))
+
+	mux.Methods(http.MethodPost).Path("/v1/master/block/reserve").HandlerFunc(mhandler.ReserveIDHandler(app))
+
+	// writes stop being accepted once this node is no longer part of the network
+	clients := mux.NewRoute().Subrouter()
+	clients.Use(whandler.RequireMembership(app))
+
+	// every value route exists for the default namespace and for named namespaces
+	for _, prefix := range []string{"/v1", "/v1/ns/{ns}"} {
+		clients.Methods(http.MethodPost).Path(prefix + "/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+		clients.Methods(http.MethodPut).Path(prefix + "/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/values").HandlerFunc(handler.BatchSetHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/value/{key}/incr").HandlerFunc(handler.IncrementValueHandler(app))
+		clients.Methods(http.MethodDelete).Path(prefix + "/value/{key}").HandlerFunc(handler.DeleteValueHandler(app))
+
+		clients.Methods(http.MethodPost).Path(prefix + "/hash/{key}").HandlerFunc(handler.HashSetHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/hash/{key}/delete").HandlerFunc(handler.HashDeleteHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/list/{key}/push").HandlerFunc(handler.ListPushHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/list/{key}/pop").HandlerFunc(handler.ListPopHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/set/{key}/add").HandlerFunc(handler.SetAddHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/set/{key}/remove").HandlerFunc(handler.SetRemoveHandler(app))
+	}
+
+	mux.Methods(http.MethodPost).Path("/v1/ns/{ns}").HandlerFunc(handler.CreateNamespaceHandler(app))
+	mux.Methods(http.MethodDelete).Path("/v1/ns/{ns}").HandlerFunc(handler.DeleteNamespaceHandler(app))
+
+	mux.Methods(http.MethodPost).Path("/v1/flush").HandlerFunc(handler.FlushHandler(app, false))
+	mux.Methods(http.MethodPost).Path("/v1/ns/{ns}/flush").HandlerFunc(handler.FlushHandler(app, false))
+	mux.Methods(http.MethodPost).Path("/v1/admin/flush").HandlerFunc(handler.FlushHandler(app, true))
 
 	return mux
 }
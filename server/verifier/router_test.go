@@ -0,0 +1,26 @@
+package verifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/gorilla/mux"
+)
+
+func TestRouterDeleteValue(t *testing.T) {
+	router := router(&config.App{})
+
+	for _, path := range []string{"/v1/value/key", "/v1/ns/ns/value/key"} {
+		match := &mux.RouteMatch{}
+		if !router.Match(httptest.NewRequest(http.MethodDelete, path, nil), match) || match.MatchErr != nil {
+			t.Errorf("expected DELETE %s to be routed", path)
+			continue
+		}
+
+		if match.Vars["key"] != "key" {
+			t.Errorf("expected DELETE %s to route key %q, got %q", path, "key", match.Vars["key"])
+		}
+	}
+}
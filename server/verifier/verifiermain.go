@@ -12,6 +12,7 @@ import (
 	acrypto "github.com/astromechio/astrocache/crypto"
 	"github.com/astromechio/astrocache/logger"
 	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
 	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/astromechio/astrocache/send"
 	"github.com/astromechio/astrocache/workers"
@@ -48,6 +49,10 @@ func startWorkers(app *config.App) {
 	go workers.CommitWorker(app)
 	go workers.ActionWorker(app)
 	go workers.DistributeWorker(app)
+	go workers.SweepWorker(app)
+	go workers.BatchWorker(app)
+	go workers.ElectionWorker(app)
+	go workers.HeartbeatWorker(app)
 }
 
 func generateConfig() (*config.App, error) {
@@ -72,25 +77,72 @@ func generateConfig() (*config.App, error) {
 
 	joinCode := os.Args[4]
 
-	keyPair, err := acrypto.GenerateNewKeyPair()
+	dataDir, err := config.DataDirFromEnv()
 	if err != nil {
-		return nil, errors.Wrap(err, "generateConfig failed to GenerateMasterKeyPair")
+		return nil, errors.Wrap(err, "generateConfig failed to DataDirFromEnv")
 	}
 
-	node := model.NewNode(address, model.NodeTypeVerifier, keyPair)
+	var store blockchain.Store = blockchain.NewMemoryStore()
+
+	// set if we died while joining before, in which case we join again as the same node
+	var pending *config.Identity
+
+	if dataDir != nil {
+		identity, err := dataDir.LoadIdentity()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to LoadIdentity")
+		}
+
+		if identity != nil && identity.Joined() {
+			return restoreConfig(address, dataDir, identity)
+		}
+
+		pending = identity
+
+		store, err = dataDir.ChainStore()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to ChainStore")
+		}
+	}
+
+	keyPair, node, err := config.NewIdentity(address, model.NodeTypeVerifier, pending)
+	if err != nil {
+		return nil, errors.Wrap(err, "generateConfig failed to NewIdentity")
+	}
 
 	keySet := &acrypto.KeySet{
 		KeyPair: keyPair,
 	}
 
-	chain := blockchain.EmptyChain()
+	chain := blockchain.EmptyChainWithStore(store)
 
 	app := &config.App{
 		Self:     node,
 		KeySet:   keySet,
 		Chain:    chain,
-		Cache:    cache.EmptyCache(),
 		NodeList: &config.NodeList{},
+		DataDir:  dataDir,
+	}
+
+	if err := setupCache(app); err != nil {
+		return nil, errors.Wrap(err, "generateConfig failed to setupCache")
+	}
+
+	if dataDir != nil {
+		if err := app.Election.Restore(dataDir); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to Election.Restore")
+		}
+	}
+
+	if dataDir != nil && pending == nil {
+		identity, err := config.IdentityFromApp(app)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to IdentityFromApp")
+		}
+
+		if err := dataDir.SaveIdentity(identity); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to SaveIdentity")
+		}
 	}
 
 	newNode, err := send.JoinNetwork(app, masterAddr, joinCode)
@@ -110,11 +162,24 @@ func generateConfig() (*config.App, error) {
 
 	app.KeySet.GlobalKey = globalKey
 
+	// kept so that we can admit nodes if we are ever elected master
+	app.SetValueForKey(joinCode, config.AppJoinCodeKey)
+
 	masterKeyPair, err := acrypto.KeyPairFromPubKeyJSON(newNode.Master.PubKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "generateConfig failed to KeyPairFromPubKeyJSON")
 	}
 
+	// if we joined through an elected master, we still need the first master's key to verify the genesis block
+	if len(newNode.GenesisKey) > 0 {
+		genesisKeyPair, err := acrypto.KeyPairFromPubKeyJSON(newNode.GenesisKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to KeyPairFromPubKeyJSON")
+		}
+
+		app.KeySet.AddKeyPair(genesisKeyPair)
+	}
+
 	app.KeySet.AddKeyPair(masterKeyPair)
 	app.NodeList.Master = newNode.Master
 
@@ -125,11 +190,95 @@ func generateConfig() (*config.App, error) {
 
 	logger.LogInfo("joined network successfully")
 
+	if dataDir != nil {
+		identity, err := config.IdentityFromApp(app)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to IdentityFromApp")
+		}
+
+		if err := dataDir.SaveIdentity(identity); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to SaveIdentity")
+		}
+
+		logger.LogInfo("persisting verifier node state to " + dataDir.Path)
+	}
+
+	return app, nil
+}
+
+// restoreConfig re-attaches to the network as the node persisted in dataDir, without joining again
+func restoreConfig(address string, dataDir *config.DataDir, identity *config.Identity) (*config.App, error) {
+	app, err := dataDir.RestoreApp(address, model.NodeTypeVerifier, identity)
+	if err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to RestoreApp")
+	}
+
+	if err := setupCache(app); err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to setupCache")
+	}
+
+	// loaded before anything can ask for our vote, and before the chain's MasterElected blocks move the term forward
+	if err := app.Election.Restore(dataDir); err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to Election.Restore")
+	}
+
+	// rebuild the node list, keySet and cache from the blocks we already have
+	workers.ReplayChain(app)
+
+	// another verifier may have been elected while we were down, and if not, the rest of the network has to agree that we are master again
+	// so we come back without a master and take no writes until we see a newer MasterElected block or win an election ourselves
+	if app.IsMaster() {
+		logger.LogWarn(fmt.Sprintf("restoreConfig found this node was master in term %d when it went down, rejoining as a verifier", app.Election.Term()))
+
+		app.NodeList.SetMaster(nil)
+	}
+
+	logger.LogInfo(fmt.Sprintf("restored verifier node from %s with %d blocks", dataDir.Path, app.Chain.Height()))
+
 	return app, nil
 }
 
+// setupCache creates the verifier's cache, which only keeps the values that actions need to execute (see actions.KeepsValue)
+// every key's version is still kept so that write decisions match the rest of the network, but most values are only stored on workers
+func setupCache(app *config.App) error {
+	var err error
+	app.Cache, err = cache.NewCache(&cache.Options{KeepValue: actions.KeepsValue})
+	if err != nil {
+		return errors.Wrap(err, "setupCache failed to NewCache")
+	}
+
+	app.Namespaces.KeepValue = actions.KeepsValue
+
+	return nil
+}
+
 func loadChain(app *config.App) {
-	blocks, err := send.GetEntireChain(app.NodeList.Master)
+	if last := app.Chain.LastBlock(); last != nil {
+		// we were restored from a data dir, so only catch up on what we missed
+		// any verifier redirects chain requests to whoever it thinks is master, so they are asked if the master we knew doesn't answer
+		sources := append([]*model.Node{app.NodeList.CurrentMaster()}, app.NodeList.AllVerifiers()...)
+
+		blocks, err := send.GetBlocksAfterFromAny(sources, last.ID)
+		if err != nil {
+			// a master that went down has no master to ask if nobody else was elected in the meantime, so it waits for an election instead
+			if app.NodeList.CurrentMaster() == nil {
+				logger.LogWarn(fmt.Sprintf("loadChain failed to GetBlocksAfterFromAny, waiting for an election: %s", err.Error()))
+				return
+			}
+
+			log.Fatal(errors.Wrap(err, "loadChain failed to GetBlocksAfterFromAny, dying now..."))
+		}
+
+		logger.LogInfo(fmt.Sprintf("loadChain catching up on %d blocks after %q", len(blocks), last.ID))
+
+		if err := app.Chain.AppendBlocks(blocks); err != nil {
+			log.Fatal(errors.Wrap(err, "loadChain failed to AppendBlocks, dying now..."))
+		}
+
+		return
+	}
+
+	blocks, err := send.GetEntireChain(app.NodeList.CurrentMaster())
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "loadChain failed to GetEntireChain, dying now..."))
 	}
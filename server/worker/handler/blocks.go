@@ -24,6 +24,12 @@ func AddBlockHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
+		// a block can arrive more than once, such as from both its proposer and the primary verifier
+		if chain.HasCommittedBlock(proposeReq.Block) {
+			transport.Ok(w)
+			return
+		}
+
 		errChan := chain.VerifyProposedBlock(proposeReq.Block, "")
 		if err := <-errChan; err != nil {
 			logger.LogError(errors.Wrap(err, "ProposeAddBlockHandler failed to AddNewBlock"))
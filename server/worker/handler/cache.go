@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/astromechio/astrocache/cache"
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
 	"github.com/astromechio/astrocache/model/requests"
@@ -16,7 +17,11 @@ import (
 func SetValueHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setValReq := &requests.SetValueRequest{}
-		setValReq.FromRequest(r)
+		if err := setValReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "SetValueHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
 
 		if err := setValReq.Verify(); err != nil {
 			logger.LogError(errors.Wrap(err, "SetValueHandler failed to Verify"))
@@ -24,28 +29,252 @@ func SetValueHandler(app *config.App) http.HandlerFunc {
 			return
 		}
 
-		if err := send.SetValue(setValReq, app.NodeList.RandomVerifier()); err != nil {
+		resp, err := send.SetValue(setValReq, app.NodeList.RandomVerifier())
+		if err != nil {
 			logger.LogError(errors.Wrap(err, "SetValueHandler failed to SetValue"))
-			transport.InternalServerError(w)
+			replyWithForwardError(w, err)
+			return
+		}
+
+		w.Header().Set(requests.ETagHeader, resp.ETag())
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// DeleteValueHandler handles value delete requests
+func DeleteValueHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delValReq := &requests.DeleteValueRequest{}
+		delValReq.FromRequest(r)
+
+		if err := delValReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "DeleteValueHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		resp, err := send.DeleteValue(delValReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "DeleteValueHandler failed to DeleteValue"))
+			replyWithForwardError(w, err)
 			return
 		}
 
-		transport.Ok(w)
+		transport.ReplyWithJSON(w, resp)
 	}
 }
 
-// GetValueHandler handles value set requests
+// BatchSetHandler handles batch set requests
+func BatchSetHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchReq := &requests.BatchSetRequest{}
+		if err := batchReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "BatchSetHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := batchReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "BatchSetHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		resp, err := send.BatchSet(batchReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "BatchSetHandler failed to BatchSet"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		w.Header().Set(requests.ETagHeader, resp.ETag())
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// IncrementValueHandler handles value increment requests
+func IncrementValueHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		incValReq := &requests.IncrementValueRequest{}
+		if err := incValReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := incValReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		resp, err := send.IncrementValue(incValReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "IncrementValueHandler failed to IncrementValue"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// GetValueHandler handles value get requests
 func GetValueHandler(app *config.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		key := mux.Vars(r)[requests.KeyRequestKey]
+		ns := mux.Vars(r)[requests.NamespaceRequestKey]
+
+		c, ok := authorizeNamespace(w, app, ns, requests.BearerToken(r))
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
 
-		val := app.Cache.ValueForKey(key)
-		if val == "" {
+		item, ok := c.ItemForKey(key)
+		if !ok {
 			transport.NotFound(w)
 			return
 		}
 
+		if item.ContentType != "" {
+			w.Header().Set(requests.ContentTypeHeader, item.ContentType)
+		}
+
+		w.Header().Set(requests.ETagHeader, requests.FormatETag(item.Version))
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(val))
+		w.Write(item.Value)
+	}
+}
+
+// GetValuesHandler handles batch read requests
+func GetValuesHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		getValsReq := &requests.GetValuesRequest{}
+		if err := getValsReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "GetValuesHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := getValsReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "GetValuesHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		c, ok := authorizeNamespace(w, app, getValsReq.Namespace, getValsReq.Token)
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
+
+		resp := &requests.GetValuesResponse{
+			ValueSet: requests.NewValueSet(),
+			Misses:   []string{},
+		}
+
+		app.Applied.View(func(height int, blockID string) {
+			resp.Height = height
+			resp.BlockID = blockID
+
+			seen := make(map[string]bool, len(getValsReq.Keys))
+
+			for _, key := range getValsReq.Keys {
+				if seen[key] {
+					continue
+				}
+
+				seen[key] = true
+
+				if item, ok := c.ItemForKey(key); ok {
+					resp.Add(key, item.Value, item.ContentType)
+				} else {
+					resp.Misses = append(resp.Misses, key)
+				}
+			}
+		})
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// maxScanAttempts is how many times a key listing is scanned while blocks are being applied before using the last attempt
+const maxScanAttempts = 3
+
+// ListKeysHandler handles key listing requests
+func ListKeysHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listReq := &requests.ListKeysRequest{}
+		if err := listReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "ListKeysHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := listReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "ListKeysHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		c, ok := authorizeNamespace(w, app, listReq.Namespace, listReq.Token)
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
+
+		after, _ := requests.ParseCursor(listReq.Cursor)
+
+		resp := &requests.ListKeysResponse{}
+
+		// a scan walks every key, so it doesn't hold up blocks being applied and is retried if one was
+		// if blocks keep being applied the last attempt is used, which may include some changes from after its height
+		var entries []cache.KeyValue
+		var more bool
+
+		for attempt := 0; attempt < maxScanAttempts; attempt++ {
+			resp.Height, resp.BlockID = app.Applied.Current()
+
+			entries, more = c.Scan(listReq.Prefix, after, listReq.Limit)
+
+			if height, _ := app.Applied.Current(); height == resp.Height {
+				break
+			}
+		}
+
+		resp.Keys = make([]string, len(entries))
+		for i, entry := range entries {
+			resp.Keys[i] = entry.Key
+		}
+
+		if listReq.WithValues {
+			resp.ValueSet = requests.NewValueSet()
+			for _, entry := range entries {
+				resp.Add(entry.Key, entry.Value, entry.ContentType)
+			}
+		}
+
+		if more {
+			resp.Cursor = requests.FormatCursor(entries[len(entries)-1].Key)
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// GetStatsHandler handles cache stats requests
+func GetStatsHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		transport.ReplyWithJSON(w, app.Cache.Stats())
 	}
 }
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/gorilla/mux"
+)
+
+// listTestKeys requests a page of keys with prefix after cursor, returning the status code and the page
+func listTestKeys(t *testing.T, app *config.App, prefix, cursor string, limit int) (int, *requests.ListKeysResponse) {
+	query := url.Values{}
+	query.Set("prefix", prefix)
+	query.Set("cursor", cursor)
+	query.Set("limit", fmt.Sprint(limit))
+
+	w := httptest.NewRecorder()
+	ListKeysHandler(app)(w, httptest.NewRequest(http.MethodGet, "/v1/keys?"+query.Encode(), nil))
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+
+	resp := &requests.ListKeysResponse{}
+	if err := json.NewDecoder(w.Body).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Code, resp
+}
+
+func newListKeysTestApp(numKeys int) *config.App {
+	app := &config.App{Cache: cache.EmptyCache()}
+
+	for i := 0; i < numKeys; i++ {
+		app.Cache.SetValueForKey([]byte("value"), fmt.Sprintf("a/%03d", i))
+		app.Cache.SetValueForKey([]byte("value"), fmt.Sprintf("b/%03d", i))
+	}
+
+	return app
+}
+
+// expectNextKeys checks that keys carry on from the key numbered next without duplicates or gaps, and returns the number after the last
+func expectNextKeys(t *testing.T, keys []string, next int) int {
+	t.Helper()
+
+	for _, key := range keys {
+		if expected := fmt.Sprintf("a/%03d", next); key != expected {
+			t.Fatalf("expected key %q, got %q", expected, key)
+		}
+
+		next++
+	}
+
+	return next
+}
+
+func TestListKeysPages(t *testing.T) {
+	const numKeys = 250
+
+	app := newListKeysTestApp(numKeys)
+
+	cursor := ""
+	next := 0
+	pages := 0
+
+	for {
+		code, resp := listTestKeys(t, app, "a/", cursor, 40)
+		if code != http.StatusOK {
+			t.Fatalf("expected page %d to get %d, got %d", pages, http.StatusOK, code)
+		}
+
+		next = expectNextKeys(t, resp.Keys, next)
+		pages++
+
+		if resp.Cursor == "" {
+			break
+		}
+
+		cursor = resp.Cursor
+	}
+
+	if next != numKeys || pages != 7 {
+		t.Errorf("expected %d keys in 7 pages, got %d in %d", numKeys, next, pages)
+	}
+}
+
+// the cursor is the last key returned, which may be gone by the time the next page is requested
+func TestListKeysCursorKeyDeleted(t *testing.T) {
+	const numKeys = 100
+
+	app := newListKeysTestApp(numKeys)
+
+	_, first := listTestKeys(t, app, "a/", "", 30)
+	next := expectNextKeys(t, first.Keys, 0)
+
+	app.Cache.DeleteValueForKey(first.Keys[len(first.Keys)-1])
+
+	_, second := listTestKeys(t, app, "a/", first.Cursor, 30)
+	next = expectNextKeys(t, second.Keys, next)
+
+	// deleting the key after the cursor means the page carries on from the one after it
+	app.Cache.DeleteValueForKey(fmt.Sprintf("a/%03d", next))
+	next++
+
+	cursor := second.Cursor
+	for cursor != "" {
+		_, page := listTestKeys(t, app, "a/", cursor, 30)
+		next = expectNextKeys(t, page.Keys, next)
+
+		cursor = page.Cursor
+	}
+
+	if next != numKeys {
+		t.Errorf("expected to list up to key %d, got to %d", numKeys, next)
+	}
+}
+
+func TestListKeysMalformedCursor(t *testing.T) {
+	app := newListKeysTestApp(10)
+
+	for _, cursor := range []string{"not a cursor", "YS8wMDU=", "a/005"} {
+		listReq := &requests.ListKeysRequest{Cursor: cursor, Limit: requests.DefaultScanLimit}
+		if err := listReq.Verify(); err == nil {
+			t.Errorf("expected cursor %q to be rejected", cursor)
+		}
+
+		if code, _ := listTestKeys(t, app, "a/", cursor, 10); code != http.StatusBadRequest {
+			t.Errorf("expected cursor %q to get %d, got %d", cursor, http.StatusBadRequest, code)
+		}
+	}
+
+	// a cursor made by the listing itself continues after its key
+	_, resp := listTestKeys(t, app, "a/", requests.FormatCursor("a/004"), 10)
+	expectNextKeys(t, resp.Keys, 5)
+}
+
+// a read that isn't allowed into a namespace is refused before it waits for any block, or asks the master or a verifier for the latest one
+func TestGetValueAuthorizesBeforeWaiting(t *testing.T) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+
+	if err := app.Namespaces.Create("ns", 0, 0, config.HashToken("secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"minBlock=unknown", "consistency=strong"} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/ns/ns/value/key?"+query, nil)
+		r.Header.Set(requests.AuthorizationHeader, "Bearer wrong")
+		r = mux.SetURLVars(r, map[string]string{requests.NamespaceRequestKey: "ns", requests.KeyRequestKey: "key"})
+
+		started := time.Now()
+
+		w := httptest.NewRecorder()
+		GetValueHandler(app)(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected a read with %s and the wrong token to get %d, got %d", query, http.StatusForbidden, w.Code)
+		}
+
+		if elapsed := time.Since(started); elapsed >= requests.DefaultReadTimeout {
+			t.Errorf("expected a read with %s and the wrong token to be refused without waiting, took %s", query, elapsed)
+		}
+	}
+}
+
+// getTestValues sends a batch read, with the keys as query params for a GET or as JSON for a POST
+func getTestValues(t *testing.T, app *config.App, method string, keys []string) (int, *requests.GetValuesResponse) {
+	var r *http.Request
+
+	if method == http.MethodGet {
+		query := url.Values{requests.KeyRequestKey: keys}
+		r = httptest.NewRequest(http.MethodGet, "/v1/values?"+query.Encode(), nil)
+	} else {
+		body, _ := json.Marshal(&requests.GetValuesRequest{Keys: keys})
+		r = httptest.NewRequest(http.MethodPost, "/v1/values/get", bytes.NewReader(body))
+	}
+
+	w := httptest.NewRecorder()
+	GetValuesHandler(app)(w, r)
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+
+	resp := &requests.GetValuesResponse{}
+	if err := json.NewDecoder(w.Body).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Code, resp
+}
+
+func TestGetValuesHitsAndMisses(t *testing.T) {
+	app := &config.App{Cache: cache.EmptyCache()}
+
+	app.Cache.SetVersionedValueForKey([]byte("one"), "text/plain", "a", "v1", time.Time{})
+	app.Cache.SetVersionedValueForKey([]byte{0xff, 0xfe}, "", "b", "v1", time.Time{})
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		code, resp := getTestValues(t, app, method, []string{"a", "missing", "b", "a"})
+		if code != http.StatusOK {
+			t.Fatalf("expected a %s to get %d, got %d", method, http.StatusOK, code)
+		}
+
+		if resp.Values["a"] != "one" || resp.ContentTypes["a"] != "text/plain" {
+			t.Errorf("expected a %s to return a's value and content type, got %+v", method, resp.ValueSet)
+		}
+
+		if !bytes.Equal(resp.Binary["b"], []byte{0xff, 0xfe}) {
+			t.Errorf("expected a %s to return b's bytes, got %v", method, resp.Binary["b"])
+		}
+
+		// a key asked for twice is only answered once
+		if len(resp.Misses) != 1 || resp.Misses[0] != "missing" {
+			t.Errorf("expected a %s to miss only the missing key, got %v", method, resp.Misses)
+		}
+	}
+}
+
+func TestGetValuesNoKeys(t *testing.T) {
+	app := &config.App{Cache: cache.EmptyCache()}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if code, _ := getTestValues(t, app, method, []string{}); code != http.StatusBadRequest {
+			t.Errorf("expected a %s with no keys to get %d, got %d", method, http.StatusBadRequest, code)
+		}
+	}
+}
+
+// every block sets both keys to its height, so a read split across two heights would see them differ
+func TestGetValuesSingleHeight(t *testing.T) {
+	app := &config.App{Cache: cache.EmptyCache()}
+
+	done := make(chan bool)
+
+	go func() {
+		defer close(done)
+
+		for height := 1; height <= 500; height++ {
+			value := []byte(fmt.Sprint(height))
+
+			app.Applied.Apply(fmt.Sprintf("block-%d", height), func() {
+				app.Cache.SetValueForKey(value, "a")
+				app.Cache.SetValueForKey(value, "b")
+			})
+		}
+	}()
+
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+
+		_, resp := getTestValues(t, app, http.MethodPost, []string{"a", "b"})
+
+		if resp.Height == 0 {
+			continue
+		}
+
+		expected := fmt.Sprint(resp.Height)
+
+		if resp.Values["a"] != expected || resp.Values["b"] != expected || resp.BlockID != "block-"+expected {
+			t.Fatalf("expected both values from block %q at height %d, got a=%q b=%q", resp.BlockID, resp.Height, resp.Values["a"], resp.Values["b"])
+		}
+	}
+}
+
+func TestSetValueForwardsEmptyRawPut(t *testing.T) {
+	forwarded := make(chan *requests.SetValueRequest, 1)
+
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setValReq := &requests.SetValueRequest{}
+		if err := json.NewDecoder(r.Body).Decode(setValReq); err != nil {
+			transport.BadRequest(w)
+			return
+		}
+
+		forwarded <- setValReq
+
+		transport.ReplyWithJSON(w, &requests.WriteResponse{BlockID: "block"})
+	}))
+	defer verifier.Close()
+
+	app := &config.App{NodeList: &config.NodeList{}}
+	app.NodeList.AddVerifier(&model.Node{NID: "verifier", Type: model.NodeTypeVerifier, Address: verifier.URL})
+
+	r := httptest.NewRequest(http.MethodPut, "/v1/value/empty", nil)
+	r.Header.Set(requests.ContentTypeHeader, "application/octet-stream")
+	r = mux.SetURLVars(r, map[string]string{requests.KeyRequestKey: "empty"})
+
+	w := httptest.NewRecorder()
+	SetValueHandler(app)(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected empty raw PUT to succeed, got %d", w.Code)
+	}
+
+	setValReq := <-forwarded
+
+	// the verifier has to be able to tell the empty value was sent on purpose
+	if err := setValReq.Verify(); err != nil {
+		t.Errorf("expected forwarded request to verify, got %s", err)
+	}
+
+	if len(setValReq.Bytes()) != 0 || setValReq.ContentType != "application/octet-stream" {
+		t.Errorf("expected empty value with its content type to be forwarded, got %q with %q", setValReq.Bytes(), setValReq.ContentType)
+	}
+}
+
+func TestDeleteValueForwardsIfMatch(t *testing.T) {
+	forwarded := make(chan string, 1)
+
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded <- r.Header.Get(requests.IfMatchHeader)
+
+		transport.Conflict(w)
+	}))
+	defer verifier.Close()
+
+	app := &config.App{NodeList: &config.NodeList{}}
+	app.NodeList.AddVerifier(&model.Node{NID: "verifier", Type: model.NodeTypeVerifier, Address: verifier.URL})
+
+	r := httptest.NewRequest(http.MethodDelete, "/v1/value/key", nil)
+	r.Header.Set(requests.IfMatchHeader, requests.FormatETag("version"))
+	r = mux.SetURLVars(r, map[string]string{requests.KeyRequestKey: "key"})
+
+	w := httptest.NewRecorder()
+	DeleteValueHandler(app)(w, r)
+
+	if ifMatch := <-forwarded; requests.ParseETag(ifMatch) != "version" {
+		t.Errorf("expected If-Match %q to be forwarded, got %q", requests.FormatETag("version"), ifMatch)
+	}
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected the verifier's conflict to be passed on, got %d", w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/send"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// waitForConsistency waits until the worker has applied every block the read needs before it is answered
+// that is the block in the request's minBlock param so a read sees the write committed in it, and for a strong read the latest committed block
+// if the params are invalid, the master (or while there is none, the verifiers) can't be reached, or the blocks aren't applied in time, an error is written to w and false is returned
+// callers authorize the request first, so that a client without access to a namespace can't make the worker wait or ask the master or its verifier for blocks
+func waitForConsistency(w http.ResponseWriter, r *http.Request, app *config.App) bool {
+	consistency, err := requests.ReadConsistencyFromRequest(r)
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "waitForConsistency failed to ReadConsistencyFromRequest"))
+		transport.BadRequest(w)
+		return false
+	}
+
+	deadline := time.Now().Add(consistency.Timeout)
+
+	if consistency.MinBlock != "" {
+		if !app.Applied.WaitFor(app.Chain, consistency.MinBlock, time.Until(deadline)) {
+			logger.LogWarn(fmt.Sprintf("waitForConsistency timed out after %s waiting for block with ID %s", consistency.Timeout, consistency.MinBlock))
+			transport.GatewayTimeout(w)
+			return false
+		}
+	}
+
+	if !consistency.Strong {
+		return true
+	}
+
+	lastID, err := catchUpToLatest(app)
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "waitForConsistency failed to catchUpToLatest"))
+		transport.ServiceUnavailable(w)
+		return false
+	}
+
+	if !app.Applied.WaitFor(app.Chain, lastID, time.Until(deadline)) {
+		logger.LogWarn(fmt.Sprintf("waitForConsistency timed out after %s waiting for the latest block with ID %s", consistency.Timeout, lastID))
+		transport.GatewayTimeout(w)
+		return false
+	}
+
+	return true
+}
+
+// catchUpToLatest catches the worker up to the latest committed block and returns its ID
+// the block is confirmed with the master, which reserves every block ID, and only while there is no master with a quorum of verifiers
+func catchUpToLatest(app *config.App) (string, error) {
+	master := app.NodeList.CurrentMaster()
+	if master == nil {
+		return catchUpWithQuorum(app)
+	}
+
+	latest, err := send.GetLastBlock(master, requests.DefaultReadTimeout)
+	if err != nil {
+		return "", errors.Wrap(err, "catchUpToLatest failed to GetLastBlock")
+	}
+
+	// the block is either committed here already or on its way to being applied
+	if app.Chain.BlocksAfterID(latest.BlockID) != nil {
+		return latest.BlockID, nil
+	}
+
+	ownLast := app.Chain.LastBlock()
+
+	missing, err := send.GetBlocksAfter(master, ownLast.ID)
+	if err != nil {
+		return "", errors.Wrap(err, "catchUpToLatest failed to GetBlocksAfter")
+	}
+
+	logger.LogInfo(fmt.Sprintf("catchUpToLatest catching up on %d blocks after %q from the master", len(missing), ownLast.ID))
+
+	appendMissing(app, missing)
+
+	return latest.BlockID, nil
+}
+
+// catchUpWithQuorum asks our verifier for the latest block committed by a quorum of verifiers and returns its ID
+// if that block isn't in the worker's chain yet, the blocks after the worker's last block are loaded from the verifier, which has caught up to it
+func catchUpWithQuorum(app *config.App) (string, error) {
+	verifier := app.NodeList.VerifierWithNID(app.NodeList.ParentNID())
+	if verifier == nil {
+		return "", errors.New("catchUpWithQuorum found no verifier to ask")
+	}
+
+	latest, err := send.GetLatestBlock(verifier)
+	if err != nil {
+		return "", errors.Wrap(err, "catchUpWithQuorum failed to GetLatestBlock")
+	}
+
+	if app.Chain.BlocksAfterID(latest.BlockID) != nil {
+		return latest.BlockID, nil
+	}
+
+	ownLast := app.Chain.LastBlock()
+
+	missing, err := send.GetCommittedBlocksAfter(verifier, ownLast.ID)
+	if err != nil {
+		return "", errors.Wrap(err, "catchUpWithQuorum failed to GetCommittedBlocksAfter")
+	}
+
+	logger.LogInfo(fmt.Sprintf("catchUpWithQuorum catching up on %d blocks after %q", len(missing), ownLast.ID))
+
+	appendMissing(app, missing)
+
+	return latest.BlockID, nil
+}
+
+// appendMissing appends the blocks that haven't been committed here yet
+func appendMissing(app *config.App, missing []*blockchain.Block) {
+	for i := range missing {
+		// blocks are still distributed to us while we catch up, so skip any that arrived in the meantime
+		if app.Chain.BlocksAfterID(missing[i].ID) != nil {
+			continue
+		}
+
+		if err := app.Chain.AppendBlocks(missing[i : i+1]); err != nil {
+			// if distribution beat us to it the block will still be applied, so it's up to the caller's wait to decide
+			logger.LogWarn(fmt.Sprintf("appendMissing failed to AppendBlocks for block with ID %q: %s", missing[i].ID, err.Error()))
+			break
+		}
+	}
+}
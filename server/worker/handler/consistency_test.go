@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+)
+
+// newConsistencyTestApp creates a worker that has applied its genesis block, with no master until one is set
+func newConsistencyTestApp(t *testing.T) *config.App {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+	app.NodeList.SetMaster(nil)
+	app.Applied.Apply(app.Chain.LastBlock().ID, func() {})
+
+	return app
+}
+
+// startTestChainNode starts a node that answers requests for its last or latest block with last, and has no blocks after any other
+// it can't be reached if last is nil, and the returned counter holds how many requests it was sent
+func startTestChainNode(t *testing.T, last *requests.LastBlockResponse) (string, *int32) {
+	var sent int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sent, 1)
+
+		if last == nil {
+			transport.ServiceUnavailable(w)
+			return
+		}
+
+		if strings.Contains(r.URL.Path, "/chain/after/") {
+			transport.ReplyWithJSON(w, []*blockchain.Block{})
+			return
+		}
+
+		transport.ReplyWithJSON(w, last)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server.URL, &sent
+}
+
+func setTestMaster(t *testing.T, app *config.App, last *requests.LastBlockResponse) *int32 {
+	address, sent := startTestChainNode(t, last)
+
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster, Address: address})
+
+	return sent
+}
+
+func setTestParent(t *testing.T, app *config.App, latest *requests.LastBlockResponse) *int32 {
+	address, sent := startTestChainNode(t, latest)
+
+	app.NodeList.SetParent(&model.Node{NID: "verifier", Type: model.NodeTypeVerifier, Address: address})
+
+	return sent
+}
+
+func waitForTestConsistency(app *config.App, query string) (bool, int) {
+	w := httptest.NewRecorder()
+	ok := waitForConsistency(w, httptest.NewRequest(http.MethodGet, "/v1/value/key?"+query, nil), app)
+
+	return ok, w.Code
+}
+
+func TestStrongReadConfirmsWithMaster(t *testing.T) {
+	app := newConsistencyTestApp(t)
+
+	genesis := &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1}
+
+	masterSent := setTestMaster(t, app, genesis)
+	parentSent := setTestParent(t, app, genesis)
+
+	if ok, code := waitForTestConsistency(app, "consistency=strong"); !ok {
+		t.Fatalf("expected strong read to go ahead once the master's last block is applied, got %d", code)
+	}
+
+	if atomic.LoadInt32(masterSent) == 0 {
+		t.Error("expected the master to be asked for its last block")
+	}
+
+	if atomic.LoadInt32(parentSent) != 0 {
+		t.Error("expected the verifiers not to be asked while there is a master")
+	}
+}
+
+func TestStrongReadWaitsForMasterBlock(t *testing.T) {
+	app := newConsistencyTestApp(t)
+
+	setTestMaster(t, app, &requests.LastBlockResponse{BlockID: "ahead", Height: 2})
+
+	// the master has committed a block the worker hasn't, so the read can't be answered until it is applied
+	if ok, code := waitForTestConsistency(app, "consistency=strong&timeout=50"); ok || code != http.StatusGatewayTimeout {
+		t.Errorf("expected strong read to time out waiting for the master's last block, got %d", code)
+	}
+}
+
+func TestStrongReadMasterUnreachable(t *testing.T) {
+	app := newConsistencyTestApp(t)
+
+	setTestMaster(t, app, nil)
+	parentSent := setTestParent(t, app, &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+
+	// the verifiers can't vouch for the latest block while a master may be handing out more of them
+	if ok, code := waitForTestConsistency(app, "consistency=strong"); ok || code != http.StatusServiceUnavailable {
+		t.Errorf("expected strong read to fail while the master can't be reached, got %d", code)
+	}
+
+	if atomic.LoadInt32(parentSent) != 0 {
+		t.Error("expected the verifiers not to be asked while there is a master")
+	}
+
+	// a local read doesn't need the master
+	if ok, code := waitForTestConsistency(app, "consistency=local"); !ok {
+		t.Errorf("expected local read to go ahead without the master, got %d", code)
+	}
+}
+
+func TestStrongReadWithoutMaster(t *testing.T) {
+	app := newConsistencyTestApp(t)
+
+	parentSent := setTestParent(t, app, &requests.LastBlockResponse{BlockID: app.Chain.LastBlock().ID, Height: 1})
+
+	if ok, code := waitForTestConsistency(app, "consistency=strong"); !ok {
+		t.Fatalf("expected strong read to go ahead with the quorum's latest block while there is no master, got %d", code)
+	}
+
+	if atomic.LoadInt32(parentSent) == 0 {
+		t.Error("expected the verifier to be asked for the quorum's latest block")
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// HeartbeatHandler handles POST /v1/node/heartbeat, which the master sends to check that a node is still running
+// only heartbeats signed by the master this node follows are answered, or by any verifier while it doesn't know who the master is,
+// so that nobody else can learn where its chain is or make a dead node look alive
+func HeartbeatHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		heartbeatReq := &requests.HeartbeatRequest{}
+		if err := heartbeatReq.FromRequest(r); err != nil {
+			logger.LogError(errors.Wrap(err, "HeartbeatHandler failed to FromRequest"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if err := heartbeatReq.Verify(); err != nil {
+			logger.LogError(errors.Wrap(err, "HeartbeatHandler failed to Verify"))
+			transport.BadRequest(w)
+			return
+		}
+
+		if heartbeatReq.NID != app.Self.NID {
+			logger.LogError(fmt.Errorf("HeartbeatHandler got heartbeat for node with NID %s", heartbeatReq.NID))
+			transport.Forbidden(w)
+			return
+		}
+
+		sender := heartbeatSender(app, heartbeatReq.MasterNID)
+		if sender == nil {
+			logger.LogError(fmt.Errorf("HeartbeatHandler got heartbeat from node with NID %s, which isn't the master", heartbeatReq.MasterNID))
+			transport.Forbidden(w)
+			return
+		}
+
+		pubKey, err := sender.KeyPair()
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "HeartbeatHandler failed to KeyPair"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		if !pubKey.Verify(heartbeatReq.SigningBody(), heartbeatReq.Signature) {
+			logger.LogError(fmt.Errorf("HeartbeatHandler failed to Verify signature from node with NID %s", heartbeatReq.MasterNID))
+			transport.Forbidden(w)
+			return
+		}
+
+		if !app.Membership.Heartbeat(heartbeatReq.MasterNID, heartbeatReq.Sent) {
+			logger.LogError(fmt.Errorf("HeartbeatHandler got heartbeat from node with NID %s that is older than the last one", heartbeatReq.MasterNID))
+			transport.Forbidden(w)
+			return
+		}
+
+		resp := &requests.HeartbeatResponse{
+			NID: app.Self.NID,
+		}
+
+		if last := app.Chain.LastBlock(); last != nil {
+			resp.LastBlockID = last.ID
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// heartbeatSender returns the node with masterNID if it may send us heartbeats, or nil
+// a node without a master, such as a restarted ex-master, accepts them from any verifier, since one of them may have been elected
+func heartbeatSender(app *config.App, masterNID string) *model.Node {
+	master := app.NodeList.CurrentMaster()
+	if master == nil {
+		return app.NodeList.VerifierWithNID(masterNID)
+	}
+
+	if master.NID != masterNID {
+		return nil
+	}
+
+	return master
+}
+
+// RequireMembership replies 503 to client requests once this node has executed a NodeRemoved block for itself
+// an evicted node isn't sent blocks anymore, so it would otherwise keep serving stale values
+func RequireMembership(app *config.App) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !app.IsMaster() && !app.Membership.Active() {
+				transport.ServiceUnavailable(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
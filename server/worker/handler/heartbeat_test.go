@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+)
+
+func newHeartbeatTestApp(t *testing.T, master *acrypto.KeyPair) *config.App {
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := blockchain.BrandNewChain(master, globalKey, []byte("{}"), "test.genesis", blockchain.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &config.App{
+		Self:     &model.Node{NID: "self", Type: model.NodeTypeWorker},
+		Chain:    chain,
+		NodeList: &config.NodeList{},
+	}
+
+	app.NodeList.SetMaster(&model.Node{NID: "master", Type: model.NodeTypeMaster, PubKey: master.PubKeyJSON()})
+
+	return app
+}
+
+func sendTestHeartbeat(t *testing.T, app *config.App, signer *acrypto.KeyPair, masterNID string, sent int64) int {
+	req := &requests.HeartbeatRequest{
+		MasterNID: masterNID,
+		NID:       "self",
+		Sent:      sent,
+	}
+
+	sig, err := signer.Sign(req.SigningBody())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Signature = sig
+
+	body, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	HeartbeatHandler(app)(w, httptest.NewRequest(http.MethodPost, "/"+req.Path(), bytes.NewReader(body)))
+
+	return w.Code
+}
+
+func TestHeartbeatHandler(t *testing.T) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+
+	if code := sendTestHeartbeat(t, app, master, "master", 1); code != http.StatusOK {
+		t.Errorf("expected a heartbeat signed by the master to get %d, got %d", http.StatusOK, code)
+	}
+
+	if code := sendTestHeartbeat(t, app, master, "master", 1); code != http.StatusForbidden {
+		t.Errorf("expected a replayed heartbeat to get %d, got %d", http.StatusForbidden, code)
+	}
+
+	if code := sendTestHeartbeat(t, app, other, "master", 2); code != http.StatusForbidden {
+		t.Errorf("expected a heartbeat claiming to be from the master but signed by another key to get %d, got %d", http.StatusForbidden, code)
+	}
+
+	if code := sendTestHeartbeat(t, app, other, "other", 3); code != http.StatusForbidden {
+		t.Errorf("expected a heartbeat from a node that isn't the master to get %d, got %d", http.StatusForbidden, code)
+	}
+
+	w := httptest.NewRecorder()
+	HeartbeatHandler(app)(w, httptest.NewRequest(http.MethodPost, "/v1/node/heartbeat", bytes.NewReader([]byte("{}"))))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unsigned heartbeat to get %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHeartbeatHandlerWithoutMaster(t *testing.T) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elected, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+	app.NodeList.SetMaster(nil)
+	app.NodeList.AddVerifier(&model.Node{NID: "elected", Type: model.NodeTypeVerifier, PubKey: elected.PubKeyJSON()})
+
+	if code := sendTestHeartbeat(t, app, elected, "elected", 1); code != http.StatusOK {
+		t.Errorf("expected a node without a master to accept a heartbeat from a verifier, got %d", code)
+	}
+
+	if code := sendTestHeartbeat(t, app, master, "master", 2); code != http.StatusForbidden {
+		t.Errorf("expected a node without a master to refuse a heartbeat from a node it doesn't know, got %d", code)
+	}
+}
+
+func TestRequireMembership(t *testing.T) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+
+	handler := RequireMembership(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// no heartbeat has been heard, but that doesn't matter while the master may just be down
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/value/key", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a member to serve requests, got %d", w.Code)
+	}
+
+	app.Membership.Remove()
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/value/key", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected a removed node to get %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// ListNamespacesHandler handles namespace listing requests, tokens are never included
+func ListNamespacesHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespaces := app.Namespaces.All()
+
+		resp := make([]*requests.NamespaceResponse, len(namespaces))
+		for i, ns := range namespaces {
+			usedEntries, usedBytes := ns.Usage()
+
+			resp[i] = &requests.NamespaceResponse{
+				Name:        ns.Name,
+				MaxEntries:  ns.MaxEntries,
+				MaxBytes:    ns.MaxBytes,
+				UsedEntries: usedEntries,
+				UsedBytes:   usedBytes,
+				Protected:   ns.TokenHash != "",
+				Stats:       ns.Cache.Stats(),
+			}
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// authorizeNamespace returns the cache for a namespace if the token grants access to it
+// if the namespace doesn't exist or the token is wrong, an error is written to w and false is returned
+func authorizeNamespace(w http.ResponseWriter, app *config.App, ns, token string) (*cache.Cache, bool) {
+	c, err := app.AuthorizeNamespace(ns, token)
+	if err != nil {
+		if err == config.ErrNoNamespace {
+			transport.NotFound(w)
+			return nil, false
+		}
+
+		logger.LogError(errors.Wrap(err, "authorizeNamespace failed to AuthorizeNamespace for namespace "+ns))
+		transport.Forbidden(w)
+		return nil, false
+	}
+
+	return c, true
+}
+
+// replyWithForwardError replies with the status a verifier rejected a forwarded request with
+// namespaces are checked by the verifier, since this node may not have seen a new namespace yet
+func replyWithForwardError(w http.ResponseWriter, err error) {
+	switch transport.StatusCodeFromError(err) {
+	case http.StatusConflict:
+		transport.Conflict(w)
+	case http.StatusNotFound:
+		transport.NotFound(w)
+	case http.StatusForbidden:
+		transport.Forbidden(w)
+	case http.StatusBadRequest:
+		transport.BadRequest(w)
+	default:
+		transport.InternalServerError(w)
+	}
+}
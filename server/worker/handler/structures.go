@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/send"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// HashSetHandler handles hash set requests
+func HashSetHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hashReq := &requests.HashSetRequest{}
+		if !loadRequest(w, r, hashReq) {
+			return
+		}
+
+		resp, err := send.HashSet(hashReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "HashSetHandler failed to HashSet"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// HashDeleteHandler handles hash delete requests
+func HashDeleteHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hashReq := &requests.HashDeleteRequest{}
+		if !loadRequest(w, r, hashReq) {
+			return
+		}
+
+		resp, err := send.HashDelete(hashReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "HashDeleteHandler failed to HashDelete"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// ListPushHandler handles list push requests
+func ListPushHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listReq := &requests.ListPushRequest{}
+		if !loadRequest(w, r, listReq) {
+			return
+		}
+
+		resp, err := send.ListPush(listReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "ListPushHandler failed to ListPush"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// ListPopHandler handles list pop requests
+func ListPopHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listReq := &requests.ListPopRequest{}
+		if !loadRequest(w, r, listReq) {
+			return
+		}
+
+		resp, err := send.ListPop(listReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "ListPopHandler failed to ListPop"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// SetAddHandler handles set add requests
+func SetAddHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setReq := &requests.SetAddRequest{}
+		if !loadRequest(w, r, setReq) {
+			return
+		}
+
+		resp, err := send.SetAdd(setReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "SetAddHandler failed to SetAdd"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// SetRemoveHandler handles set remove requests
+func SetRemoveHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setReq := &requests.SetRemoveRequest{}
+		if !loadRequest(w, r, setReq) {
+			return
+		}
+
+		resp, err := send.SetRemove(setReq, app.NodeList.RandomVerifier())
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "SetRemoveHandler failed to SetRemove"))
+			replyWithForwardError(w, err)
+			return
+		}
+
+		transport.ReplyWithJSON(w, resp)
+	}
+}
+
+// GetHashHandler handles hash reads, replying with every field as JSON, or the raw value of the field in the URL
+func GetHashHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		c, ok := authorizeNamespace(w, app, vars[requests.NamespaceRequestKey], requests.BearerToken(r))
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
+
+		raw, ok := structureForKey(w, c, vars[requests.KeyRequestKey], actions.ContentTypeHash)
+		if !ok {
+			return
+		}
+
+		hash, err := actions.DecodeHash(raw)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "GetHashHandler failed to DecodeHash"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		field := vars[requests.FieldRequestKey]
+		if field == "" {
+			transport.ReplyWithJSON(w, hash)
+			return
+		}
+
+		val, ok := hash[field]
+		if !ok {
+			transport.NotFound(w)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(val))
+	}
+}
+
+// GetListHandler handles list reads, replying with the values from the start to stop query params as JSON
+// start and stop are inclusive and default to the whole list, negative indexes count back from the end
+func GetListHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		start, err := queryInt(r, "start", 0)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "GetListHandler failed to queryInt"))
+			transport.BadRequest(w)
+			return
+		}
+
+		stop, err := queryInt(r, "stop", -1)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "GetListHandler failed to queryInt"))
+			transport.BadRequest(w)
+			return
+		}
+
+		c, ok := authorizeNamespace(w, app, vars[requests.NamespaceRequestKey], requests.BearerToken(r))
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
+
+		raw, ok := structureForKey(w, c, vars[requests.KeyRequestKey], actions.ContentTypeList)
+		if !ok {
+			return
+		}
+
+		list, err := actions.DecodeList(raw)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "GetListHandler failed to DecodeList"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		transport.ReplyWithJSON(w, listRange(list, start, stop))
+	}
+}
+
+// GetSetHandler handles set reads, replying with every member as sorted JSON
+// with a member in the URL, it replies 200 if the member is in the set and 404 if not
+func GetSetHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		c, ok := authorizeNamespace(w, app, vars[requests.NamespaceRequestKey], requests.BearerToken(r))
+		if !ok {
+			return
+		}
+
+		if !waitForConsistency(w, r, app) {
+			return
+		}
+
+		raw, ok := structureForKey(w, c, vars[requests.KeyRequestKey], actions.ContentTypeSet)
+		if !ok {
+			return
+		}
+
+		member := vars[requests.MemberRequestKey]
+		if member == "" {
+			members, err := actions.DecodeList(raw)
+			if err != nil {
+				logger.LogError(errors.Wrap(err, "GetSetHandler failed to DecodeList"))
+				transport.InternalServerError(w)
+				return
+			}
+
+			transport.ReplyWithJSON(w, members)
+			return
+		}
+
+		set, err := actions.DecodeSet(raw)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "GetSetHandler failed to DecodeSet"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		if !set[member] {
+			transport.NotFound(w)
+			return
+		}
+
+		transport.Ok(w)
+	}
+}
+
+// loadRequest loads and verifies a request, if either fails an error is written to w and false is returned
+func loadRequest(w http.ResponseWriter, r *http.Request, req requests.Request) bool {
+	if err := req.FromRequest(r); err != nil {
+		logger.LogError(errors.Wrap(err, "loadRequest failed to FromRequest"))
+		transport.BadRequest(w)
+		return false
+	}
+
+	if err := req.Verify(); err != nil {
+		logger.LogError(errors.Wrap(err, "loadRequest failed to Verify"))
+		transport.BadRequest(w)
+		return false
+	}
+
+	return true
+}
+
+// structureForKey returns the structure stored at key if it has contentType
+// if the key is missing it replies 404, and if it holds a different kind of value it replies 409
+func structureForKey(w http.ResponseWriter, c *cache.Cache, key, contentType string) ([]byte, bool) {
+	item, ok := c.ItemForKey(key)
+	if !ok {
+		transport.NotFound(w)
+		return nil, false
+	}
+
+	if item.ContentType != contentType {
+		transport.Conflict(w)
+		return nil, false
+	}
+
+	return item.Value, true
+}
+
+// listRange returns the values of list from start to stop inclusive, negative indexes count back from the end
+func listRange(list []string, start, stop int) []string {
+	if start < 0 {
+		start += len(list)
+	}
+
+	if stop < 0 {
+		stop += len(list)
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= len(list) {
+		stop = len(list) - 1
+	}
+
+	if start > stop {
+		return []string{}
+	}
+
+	return list[start : stop+1]
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return def, nil
+	}
+
+	val, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, errors.Errorf("%s must be an integer, got %q", name, str)
+	}
+
+	return val, nil
+}
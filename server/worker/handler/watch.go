@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/transport"
+	"github.com/pkg/errors"
+)
+
+// watchKeepAlive is how often a comment is sent to idle watches so that proxies don't close them
+const watchKeepAlive = 15 * time.Second
+
+// watchResumeTimeout is how long a watch waits for the block it resumes from to be applied, such as when this worker is behind the one it was watching before
+const watchResumeTimeout = 5 * time.Second
+
+// WatchHandler streams changes to keys as server-sent events as blocks are applied
+// each event holds the matching changes from one block and has the block's ID as its event ID, so a client can resume after it
+// if the block to resume from is no longer in the watch history it replies 410, and the client should re-read what it needs
+// a block that hasn't been applied yet is waited for first, so that it isn't mistaken for one that has left the history
+func WatchHandler(app *config.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		watchReq := &requests.WatchRequest{}
+		if !loadRequest(w, r, watchReq) {
+			return
+		}
+
+		if _, ok := authorizeNamespace(w, app, watchReq.Namespace, watchReq.Token); !ok {
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			logger.LogError(errors.New("WatchHandler found ResponseWriter that can't flush"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		watcher, err := app.Watchers.Watch(watchReq.Namespace, watchReq.Prefix, watchReq.FromBlock)
+		if err == config.ErrWatchTooOld && app.Applied.WaitFor(app.Chain, watchReq.FromBlock, watchResumeTimeout) {
+			watcher, err = app.Watchers.Watch(watchReq.Namespace, watchReq.Prefix, watchReq.FromBlock)
+		}
+
+		if err != nil {
+			if err == config.ErrWatchTooOld {
+				transport.Gone(w)
+				return
+			}
+
+			logger.LogError(errors.Wrap(err, "WatchHandler failed to Watch"))
+			transport.InternalServerError(w)
+			return
+		}
+
+		defer app.Watchers.Unwatch(watcher)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(watchKeepAlive)
+		defer keepAlive.Stop()
+
+		for true {
+			select {
+			case <-r.Context().Done():
+				return
+			case changes, ok := <-watcher.Events:
+				if !ok {
+					// we fell behind and were dropped, the client will resume from the last event it got
+					return
+				}
+
+				changesJSON, err := json.Marshal(changes)
+				if err != nil {
+					logger.LogError(errors.Wrap(err, "WatchHandler failed to Marshal"))
+					return
+				}
+
+				fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", changes.BlockID, changesJSON)
+				flusher.Flush()
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keepalive\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/gorilla/mux"
+)
+
+func newWatchTestServer(t *testing.T) (*config.App, *httptest.Server) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newHeartbeatTestApp(t, master)
+	app.Watchers.HistorySize = 3
+
+	if err := app.Namespaces.Create("ns", 0, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.Path("/v1/watch").HandlerFunc(WatchHandler(app))
+	router.Path("/v1/ns/{ns}/watch").HandlerFunc(WatchHandler(app))
+
+	return app, httptest.NewServer(router)
+}
+
+// startTestWatch starts a watch, returning the response and a chan of the IDs of the blocks sent to it
+func startTestWatch(t *testing.T, server *httptest.Server, path, lastEventID string) (*http.Response, chan string) {
+	req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if lastEventID != "" {
+		req.Header.Set(requests.LastEventIDHeader, lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockIDs := make(chan string, 16)
+
+	go func() {
+		defer close(blockIDs)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "id: ") {
+				blockIDs <- strings.TrimPrefix(line, "id: ")
+			}
+		}
+	}()
+
+	return resp, blockIDs
+}
+
+func expectWatchBlocks(t *testing.T, blockIDs chan string, expected ...string) {
+	t.Helper()
+
+	for _, blockID := range expected {
+		select {
+		case received := <-blockIDs:
+			if received != blockID {
+				t.Fatalf("expected an event for block %q, got %q", blockID, received)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected an event for block %q", blockID)
+		}
+	}
+}
+
+func publishWatchTestBlocks(app *config.App) {
+	app.Watchers.Publish("b1", []*config.Change{{Key: "a/1", Op: config.ChangeOpSet}})
+	app.Watchers.Publish("b2", []*config.Change{{Key: "b/2", Op: config.ChangeOpSet}})
+	app.Watchers.Publish("b3", []*config.Change{{Namespace: "ns", Key: "a/3", Op: config.ChangeOpSet}})
+	app.Watchers.Publish("b4", []*config.Change{{Key: "a/4", Op: config.ChangeOpDelete}})
+}
+
+func TestWatchHandlerResume(t *testing.T) {
+	app, server := newWatchTestServer(t)
+	defer server.Close()
+
+	publishWatchTestBlocks(app)
+
+	// a reconnecting client resumes from the Last-Event-ID it was sent
+	resp, blockIDs := startTestWatch(t, server, "/v1/watch?prefix=a/", "b2")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected resuming from a block in the history to get %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	expectWatchBlocks(t, blockIDs, "b4")
+
+	app.Watchers.Publish("b5", []*config.Change{{Key: "b/5", Op: config.ChangeOpSet}})
+	app.Watchers.Publish("b6", []*config.Change{{Key: "a/6", Op: config.ChangeOpSet}})
+
+	expectWatchBlocks(t, blockIDs, "b6")
+}
+
+func TestWatchHandlerNamespace(t *testing.T) {
+	app, server := newWatchTestServer(t)
+	defer server.Close()
+
+	publishWatchTestBlocks(app)
+
+	resp, blockIDs := startTestWatch(t, server, "/v1/ns/ns/watch?fromBlock=b2", "")
+	defer resp.Body.Close()
+
+	app.Watchers.Publish("b5", []*config.Change{{Key: "a/5", Op: config.ChangeOpSet}, {Namespace: "ns", Key: "a/5", Op: config.ChangeOpSet}})
+
+	// b4 only changed the default namespace, so the next event after b3 is b5
+	expectWatchBlocks(t, blockIDs, "b3", "b5")
+
+	missing, _ := startTestWatch(t, server, "/v1/ns/missing/watch", "")
+	defer missing.Body.Close()
+
+	if missing.StatusCode != http.StatusNotFound {
+		t.Errorf("expected watching a missing namespace to get %d, got %d", http.StatusNotFound, missing.StatusCode)
+	}
+}
+
+// a block that has been applied but has left the history must not be resumed from as if nothing was missed
+func TestWatchHandlerResumeTooOld(t *testing.T) {
+	app, server := newWatchTestServer(t)
+	defer server.Close()
+
+	genesis := app.Chain.LastBlock()
+	app.Applied.Apply(genesis.ID, func() {})
+
+	app.Watchers.Publish(genesis.ID, []*config.Change{})
+	publishWatchTestBlocks(app)
+
+	resp, _ := startTestWatch(t, server, "/v1/watch?fromBlock="+genesis.ID, "")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("expected resuming from a block older than the history to get %d, got %d", http.StatusGone, resp.StatusCode)
+	}
+}
@@ -12,9 +12,43 @@ func router(app *config.App) *mux.Router {
 	mux := mux.NewRouter()
 
 	mux.Methods(http.MethodPost).Path("/v1/worker/block").HandlerFunc(handler.AddBlockHandler(app))
+	mux.Methods(http.MethodPost).Path("/v1/node/heartbeat").HandlerFunc(handler.HeartbeatHandler(app))
 
-	mux.Methods(http.MethodGet).Path("/v1/value/{key}").HandlerFunc(handler.GetValueHandler(app))
-	mux.Methods(http.MethodPost).Path("/v1/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+	// client routes stop being served once this node is no longer part of the network
+	clients := mux.NewRoute().Subrouter()
+	clients.Use(handler.RequireMembership(app))
+
+	// every value route exists for the default namespace and for named namespaces
+	for _, prefix := range []string{"/v1", "/v1/ns/{ns}"} {
+		clients.Methods(http.MethodGet).Path(prefix + "/value/{key}").HandlerFunc(handler.GetValueHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+		clients.Methods(http.MethodPut).Path(prefix + "/value/{key}").HandlerFunc(handler.SetValueHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/values").HandlerFunc(handler.GetValuesHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/values/get").HandlerFunc(handler.GetValuesHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/values").HandlerFunc(handler.BatchSetHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/value/{key}/incr").HandlerFunc(handler.IncrementValueHandler(app))
+		clients.Methods(http.MethodDelete).Path(prefix + "/value/{key}").HandlerFunc(handler.DeleteValueHandler(app))
+
+		clients.Methods(http.MethodGet).Path(prefix + "/keys").HandlerFunc(handler.ListKeysHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/watch").HandlerFunc(handler.WatchHandler(app))
+
+		clients.Methods(http.MethodPost).Path(prefix + "/hash/{key}").HandlerFunc(handler.HashSetHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/hash/{key}/delete").HandlerFunc(handler.HashDeleteHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/list/{key}/push").HandlerFunc(handler.ListPushHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/list/{key}/pop").HandlerFunc(handler.ListPopHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/set/{key}/add").HandlerFunc(handler.SetAddHandler(app))
+		clients.Methods(http.MethodPost).Path(prefix + "/set/{key}/remove").HandlerFunc(handler.SetRemoveHandler(app))
+
+		clients.Methods(http.MethodGet).Path(prefix + "/hash/{key}").HandlerFunc(handler.GetHashHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/hash/{key}/{field}").HandlerFunc(handler.GetHashHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/list/{key}").HandlerFunc(handler.GetListHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/set/{key}").HandlerFunc(handler.GetSetHandler(app))
+		clients.Methods(http.MethodGet).Path(prefix + "/set/{key}/{member}").HandlerFunc(handler.GetSetHandler(app))
+	}
+
+	clients.Methods(http.MethodGet).Path("/v1/ns").HandlerFunc(handler.ListNamespacesHandler(app))
+
+	mux.Methods(http.MethodGet).Path("/v1/stats").HandlerFunc(handler.GetStatsHandler(app))
 
 	return mux
 }
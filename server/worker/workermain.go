@@ -47,6 +47,7 @@ func startWorkers(app *config.App) {
 	go workers.ProposeWorker(app)
 	go workers.CommitWorker(app)
 	go workers.ActionWorker(app)
+	go workers.SweepWorker(app)
 }
 
 func generateConfig() (*config.App, error) {
@@ -71,25 +72,66 @@ func generateConfig() (*config.App, error) {
 
 	joinCode := os.Args[4]
 
-	keyPair, err := acrypto.GenerateNewKeyPair()
+	dataDir, err := config.DataDirFromEnv()
 	if err != nil {
-		return nil, errors.Wrap(err, "generateConfig failed to GenerateMasterKeyPair")
+		return nil, errors.Wrap(err, "generateConfig failed to DataDirFromEnv")
 	}
 
-	node := model.NewNode(address, model.NodeTypeWorker, keyPair)
+	var store blockchain.Store = blockchain.NewMemoryStore()
+
+	// set if we died while joining before, in which case we join again as the same node
+	var pending *config.Identity
+
+	if dataDir != nil {
+		identity, err := dataDir.LoadIdentity()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to LoadIdentity")
+		}
+
+		if identity != nil && identity.Joined() {
+			return restoreConfig(address, dataDir, identity)
+		}
+
+		pending = identity
+
+		store, err = dataDir.ChainStore()
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to ChainStore")
+		}
+	}
+
+	keyPair, node, err := config.NewIdentity(address, model.NodeTypeWorker, pending)
+	if err != nil {
+		return nil, errors.Wrap(err, "generateConfig failed to NewIdentity")
+	}
 
 	keySet := &acrypto.KeySet{
 		KeyPair: keyPair,
 	}
 
-	chain := blockchain.EmptyChain()
+	chain := blockchain.EmptyChainWithStore(store)
 
 	app := &config.App{
 		Self:     node,
 		KeySet:   keySet,
 		Chain:    chain,
-		Cache:    cache.EmptyCache(),
 		NodeList: &config.NodeList{},
+		DataDir:  dataDir,
+	}
+
+	if err := setupCache(app); err != nil {
+		return nil, errors.Wrap(err, "generateConfig failed to setupCache")
+	}
+
+	if dataDir != nil && pending == nil {
+		identity, err := config.IdentityFromApp(app)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to IdentityFromApp")
+		}
+
+		if err := dataDir.SaveIdentity(identity); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to SaveIdentity")
+		}
 	}
 
 	newNode, err := send.JoinNetwork(app, masterAddr, joinCode)
@@ -114,6 +156,16 @@ func generateConfig() (*config.App, error) {
 		return nil, errors.Wrap(err, "generateConfig failed to KeyPairFromPubKeyJSON")
 	}
 
+	// if we joined through an elected master, we still need the first master's key to verify the genesis block
+	if len(newNode.GenesisKey) > 0 {
+		genesisKeyPair, err := acrypto.KeyPairFromPubKeyJSON(newNode.GenesisKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to KeyPairFromPubKeyJSON")
+		}
+
+		app.KeySet.AddKeyPair(genesisKeyPair)
+	}
+
 	verifierKeyPair, err := acrypto.KeyPairFromPubKeyJSON(newNode.Verifier.PubKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "generateConfig failed to KeyPairFromPubKeyJSON")
@@ -123,17 +175,94 @@ func generateConfig() (*config.App, error) {
 	app.NodeList.Master = newNode.Master
 
 	app.KeySet.AddKeyPair(verifierKeyPair)
-	app.NodeList.AddVerifier(newNode.Verifier)
+	app.NodeList.SetParent(newNode.Verifier)
 
 	app.Self.ParentNID = newNode.Verifier.NID
 
 	logger.LogInfo("joined network successfully")
 
+	if dataDir != nil {
+		identity, err := config.IdentityFromApp(app)
+		if err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to IdentityFromApp")
+		}
+
+		if err := dataDir.SaveIdentity(identity); err != nil {
+			return nil, errors.Wrap(err, "generateConfig failed to SaveIdentity")
+		}
+
+		logger.LogInfo("persisting worker node state to " + dataDir.Path)
+	}
+
+	return app, nil
+}
+
+// restoreConfig re-attaches to the network as the node persisted in dataDir, without joining again
+func restoreConfig(address string, dataDir *config.DataDir, identity *config.Identity) (*config.App, error) {
+	app, err := dataDir.RestoreApp(address, model.NodeTypeWorker, identity)
+	if err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to RestoreApp")
+	}
+
+	if err := setupCache(app); err != nil {
+		return nil, errors.Wrap(err, "restoreConfig failed to setupCache")
+	}
+
+	// rebuild the node list, keySet and cache from the blocks we already have
+	workers.ReplayChain(app)
+
+	logger.LogInfo(fmt.Sprintf("restored worker node from %s with %d blocks", dataDir.Path, app.Chain.Height()))
+
 	return app, nil
 }
 
+// setupCache creates the worker's cache with the limits configured in the environment
+// namespaces aren't limited by these, their quotas limit what can be written to them instead
+// the watch history is set up here too, since it is filled as blocks are applied to the cache
+func setupCache(app *config.App) error {
+	options, err := config.CacheOptionsFromEnv()
+	if err != nil {
+		return errors.Wrap(err, "setupCache failed to CacheOptionsFromEnv")
+	}
+
+	app.Cache, err = cache.NewCache(options)
+	if err != nil {
+		return errors.Wrap(err, "setupCache failed to NewCache")
+	}
+
+	if options.MaxEntries > 0 || options.MaxBytes > 0 {
+		logger.LogInfo(fmt.Sprintf("limiting cache to %d entries and %d bytes with %s eviction", options.MaxEntries, options.MaxBytes, options.Policy))
+	}
+
+	app.Watchers.HistorySize, err = config.WatchHistoryFromEnv()
+	if err != nil {
+		return errors.Wrap(err, "setupCache failed to WatchHistoryFromEnv")
+	}
+
+	return nil
+}
+
 func loadChain(app *config.App) {
-	blocks, err := send.GetEntireChain(app.NodeList.Master)
+	if last := app.Chain.LastBlock(); last != nil {
+		// we were restored from a data dir, so only catch up on what we missed
+		// our verifier redirects chain requests to whoever it thinks is master, so it is asked if the master we knew doesn't answer
+		sources := append([]*model.Node{app.NodeList.CurrentMaster()}, app.NodeList.AllVerifiers()...)
+
+		blocks, err := send.GetBlocksAfterFromAny(sources, last.ID)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "loadChain failed to GetBlocksAfterFromAny, dying now..."))
+		}
+
+		logger.LogInfo(fmt.Sprintf("loadChain catching up on %d blocks after %q", len(blocks), last.ID))
+
+		if err := app.Chain.AppendBlocks(blocks); err != nil {
+			log.Fatal(errors.Wrap(err, "loadChain failed to AppendBlocks, dying now..."))
+		}
+
+		return
+	}
+
+	blocks, err := send.GetEntireChain(app.NodeList.CurrentMaster())
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "loadChain failed to GetEntireChain, dying now..."))
 	}
@@ -97,7 +97,7 @@ func setVal(key, val string, node *model.Node, result chan error) {
 		Value: val,
 	}
 
-	if err := send.SetValue(setValRequest, node); err != nil {
+	if _, err := send.SetValue(setValRequest, node); err != nil {
 		result <- err
 	}
 
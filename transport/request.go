@@ -7,13 +7,48 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/astromechio/astrocache/model/requests"
 	"github.com/pkg/errors"
 )
 
+// StatusError is returned when a node responds with a non-200 status code
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("%s (%q) returned non-200 status code %d", se.Method, se.URL, se.StatusCode)
+}
+
+// StatusCodeFromError returns the status code a node responded with, or 0 if err isn't a StatusError
+func StatusCodeFromError(err error) int {
+	if statusErr, ok := errors.Cause(err).(*StatusError); ok {
+		return statusErr.StatusCode
+	}
+
+	return 0
+}
+
 // Post sends a POST request to a node with a request
 func Post(url string, req requests.Request, res interface{}) error {
+	return PostWithToken(url, "", req, res)
+}
+
+// PostWithToken sends a POST request to a node with a request, passing token along as a bearer token if it is set
+func PostWithToken(url, token string, req requests.Request, res interface{}) error {
+	return post(url, token, 0, req, res)
+}
+
+// PostWithTimeout sends a POST request to a node with a request, giving up if it hasn't responded within timeout
+func PostWithTimeout(url string, timeout time.Duration, req requests.Request, res interface{}) error {
+	return post(url, "", timeout, req, res)
+}
+
+func post(url, token string, timeout time.Duration, req requests.Request, res interface{}) error {
 	reqJSON, err := json.Marshal(req)
 	if err != nil {
 		return errors.Wrap(err, "Post failed to Marshal")
@@ -24,13 +59,15 @@ func Post(url string, req requests.Request, res interface{}) error {
 		return errors.Wrap(err, "Post failed to NewRequest")
 	}
 
-	response, err := HttpClient().Do(postRequest)
+	requests.SetBearerToken(postRequest, token)
+
+	response, err := HttpClientWithTimeout(timeout).Do(postRequest)
 	if err != nil {
 		return errors.Wrap(err, "Post failed to Do")
 	}
 
 	if response.StatusCode != 200 {
-		return fmt.Errorf("Post (%q) returned non-200 status code %d", url, response.StatusCode)
+		return &StatusError{Method: http.MethodPost, URL: url, StatusCode: response.StatusCode}
 	}
 
 	resBody, err := ioutil.ReadAll(response.Body)
@@ -50,18 +87,23 @@ func Post(url string, req requests.Request, res interface{}) error {
 
 // Get sends a POST request to a node with a request
 func Get(url string, res interface{}) error {
+	return GetWithTimeout(url, 0, res)
+}
+
+// GetWithTimeout sends a GET request to a node, giving up if it hasn't responded within timeout
+func GetWithTimeout(url string, timeout time.Duration, res interface{}) error {
 	getRequest, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return errors.Wrap(err, "Get failed to NewRequest")
 	}
 
-	response, err := HttpClient().Do(getRequest)
+	response, err := HttpClientWithTimeout(timeout).Do(getRequest)
 	if err != nil {
 		return errors.Wrap(err, "Get failed to Do")
 	}
 
 	if response.StatusCode != 200 {
-		return fmt.Errorf("Get (%q) returned non-200 status code %d", url, response.StatusCode)
+		return &StatusError{Method: http.MethodGet, URL: url, StatusCode: response.StatusCode}
 	}
 
 	resBody, err := ioutil.ReadAll(response.Body)
@@ -79,7 +121,59 @@ func Get(url string, res interface{}) error {
 	return nil
 }
 
+// Delete sends a DELETE request to a node
+func Delete(url string, res interface{}) error {
+	return DeleteWithToken(url, "", res)
+}
+
+// DeleteWithToken sends a DELETE request to a node, passing token along as a bearer token if it is set
+func DeleteWithToken(url, token string, res interface{}) error {
+	return DeleteIfMatch(url, token, "", res)
+}
+
+// DeleteIfMatch is the same as DeleteWithToken, but passes ifMatch along as an If-Match header if it is set
+func DeleteIfMatch(url, token, ifMatch string, res interface{}) error {
+	deleteRequest, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "Delete failed to NewRequest")
+	}
+
+	requests.SetBearerToken(deleteRequest, token)
+
+	if ifMatch != "" {
+		deleteRequest.Header.Set(requests.IfMatchHeader, requests.FormatETag(ifMatch))
+	}
+
+	response, err := HttpClient().Do(deleteRequest)
+	if err != nil {
+		return errors.Wrap(err, "Delete failed to Do")
+	}
+
+	if response.StatusCode != 200 {
+		return &StatusError{Method: http.MethodDelete, URL: url, StatusCode: response.StatusCode}
+	}
+
+	resBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, "Delete failed to ReadAll")
+	}
+	defer response.Body.Close()
+
+	if res != nil {
+		if err := json.Unmarshal(resBody, res); err != nil {
+			return errors.Wrap(err, "Delete failed to Unmarshal")
+		}
+	}
+
+	return nil
+}
+
 func HttpClient() *http.Client {
+	return HttpClientWithTimeout(0)
+}
+
+// HttpClientWithTimeout returns a client whose requests give up after timeout, or never if timeout is 0
+func HttpClientWithTimeout(timeout time.Duration) *http.Client {
 	// Customize the Transport to have larger connection pool
 	defaultRoundTripper := http.DefaultTransport
 	defaultTransportPointer, ok := defaultRoundTripper.(*http.Transport)
@@ -93,7 +187,7 @@ func HttpClient() *http.Client {
 
 	myClient := &http.Client{
 		Transport: &defaultTransport,
-		// Timeout:   time.Second * 2,
+		Timeout:   timeout,
 	}
 
 	return myClient
@@ -58,3 +58,18 @@ func BadRequest(w http.ResponseWriter) {
 func Forbidden(w http.ResponseWriter) {
 	http.Error(w, "Forbidden", http.StatusForbidden)
 }
+
+// Gone responds with 410
+func Gone(w http.ResponseWriter) {
+	http.Error(w, "Gone", http.StatusGone)
+}
+
+// ServiceUnavailable responds with 503
+func ServiceUnavailable(w http.ResponseWriter) {
+	http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+}
+
+// GatewayTimeout responds with 504
+func GatewayTimeout(w http.ResponseWriter) {
+	http.Error(w, "Gateway Timeout", http.StatusGatewayTimeout)
+}
@@ -1,10 +1,12 @@
 package workers
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/astromechio/astrocache/model"
 	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
 
 	"github.com/astromechio/astrocache/config"
 
@@ -29,34 +31,102 @@ func ActionWorker(app *config.App) {
 	logger.LogInfo("starting action worker")
 
 	for true {
-		block := <-chain.ActionChan
+		blockJob := <-chain.ActionChan
 
+		block := blockJob.Block
 		if block == nil {
 			logger.LogWarn("ActionWorker received nil block, continuing..")
+			continue
 		}
 
-		actionJSON, err := app.KeySet.GlobalKey.Decrypt(block.Data)
-		if err != nil {
-			logger.LogError(errors.Wrap(err, "ActionWorker failed to Decrypt for block with ID "+block.ID))
-			continue
+		var result *blockchain.ActionResult
+
+		app.Applied.Apply(block.ID, func() {
+			result = applyBlock(app, block)
+		})
+
+		if errors.Cause(result.Err) == actions.ErrVersionUnknown {
+			// expected on a node with a bounded cache, which forgets the key instead
+			logger.LogInfo("ActionWorker could not decide a conditional write: " + result.Err.Error())
+		} else if result.Err != nil {
+			logger.LogError(errors.Wrap(result.Err, "ActionWorker failed to applyBlock"))
 		}
 
-		action, err := actions.UnmarshalAction(actionJSON, block.ActionType)
-		if err != nil {
-			logger.LogError(errors.Wrap(err, "ActionWorker failed to unmarshalAction for block with ID "+block.ID))
-			continue
+		if blockJob.AppliedChan != nil {
+			blockJob.AppliedChan <- result
+		}
+
+		// the block is committed even if its action failed (a version mismatch, for example),
+		// so every worker needs it to keep its chain intact and make the same decision
+		if app.Self.Type == model.NodeTypeVerifier {
+			// distribute the block to all worker nodes we care about
+			chain.DistributeChan <- block
 		}
+	}
+}
 
-		logger.LogInfo("executing action (type " + action.ActionType() + ") from block with ID " + block.ID)
+// ReplayChain applies the actions from every block already in the chain, used when a node restarts from a data dir
+// it must be called before the workers are started, and does not distribute the blocks it replays
+func ReplayChain(app *config.App) {
+	blocks := app.Chain.Blocks()
 
-		if err := action.Execute(app); err != nil {
-			logger.LogError(errors.Wrap(err, "ActionWorker failed to Execute for block with ID "+block.ID))
-			continue
-		} else {
-			if app.Self.Type == model.NodeTypeVerifier {
-				// distribute the block to all worker nodes we care about
-				chain.DistributeChan <- block
+	logger.LogInfo(fmt.Sprintf("ReplayChain replaying %d blocks", len(blocks)))
+
+	for _, block := range blocks {
+		app.Applied.Apply(block.ID, func() {
+			if result := applyBlock(app, block); result.Err != nil {
+				logger.LogError(errors.Wrap(result.Err, "ReplayChain failed to applyBlock"))
 			}
-		}
+		})
 	}
 }
+
+// applyBlock executes the action in a block and returns its result
+// the keys it changed are published to watchers, along with an empty set of changes if it failed so the block can still be resumed from
+func applyBlock(app *config.App, block *blockchain.Block) *blockchain.ActionResult {
+	result := &blockchain.ActionResult{
+		BlockID:   block.ID,
+		Version:   block.ID,
+		Committed: true,
+	}
+
+	var changes []*config.Change
+	defer func() {
+		app.Watchers.Publish(block.ID, changes)
+	}()
+
+	actionJSON, err := app.KeySet.GlobalKey.Decrypt(block.Data)
+	if err != nil {
+		result.Err = errors.Wrap(err, "applyBlock failed to Decrypt for block with ID "+block.ID)
+		return result
+	}
+
+	action, err := actions.UnmarshalAction(actionJSON, block.ActionType)
+	if err != nil {
+		result.Err = errors.Wrap(err, "applyBlock failed to unmarshalAction for block with ID "+block.ID)
+		return result
+	}
+
+	logger.LogInfo("executing action (type " + action.ActionType() + ") from block with ID " + block.ID)
+
+	actions.AdvanceClock(app, action)
+
+	if err := action.Execute(app, block); err != nil {
+		result.Err = errors.Wrap(err, "applyBlock failed to Execute for block with ID "+block.ID)
+		return result
+	}
+
+	if resultAction, ok := action.(actions.ResultAction); ok {
+		result.Value = resultAction.Result()
+	}
+
+	if batch, ok := action.(*actions.ActionBatch); ok {
+		result.Batch = batch.Results()
+	}
+
+	if changeAction, ok := action.(actions.ChangeAction); ok {
+		changes = changeAction.Changes()
+	}
+
+	return result
+}
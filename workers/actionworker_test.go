@@ -0,0 +1,137 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/cache"
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+func newActionTestApp(t *testing.T) *config.App {
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &config.App{
+		Self:     &model.Node{NID: "self", Type: model.NodeTypeWorker},
+		Cache:    cache.EmptyCache(),
+		KeySet:   &acrypto.KeySet{GlobalKey: globalKey},
+		NodeList: &config.NodeList{},
+	}
+}
+
+// applyTestAction applies action as if it had been committed in the block with id
+func applyTestAction(t *testing.T, app *config.App, action actions.Action, id string) *blockchain.ActionResult {
+	block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, action.JSON(), action.ActionType())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block.ID = id
+
+	return applyBlock(app, block)
+}
+
+// expectNoChanges checks that w has been sent nothing, and that a watch can still resume from blockID
+func expectNoChanges(t *testing.T, app *config.App, w *config.Watcher, blockID string) {
+	t.Helper()
+
+	select {
+	case changes := <-w.Events:
+		t.Errorf("expected a batch that failed not to be watched, got %+v", changes)
+	default:
+	}
+
+	resumed, err := app.Watchers.Watch("", "", blockID)
+	if err != nil {
+		t.Errorf("expected a watch to resume from the failed batch, got %s", err)
+		return
+	}
+
+	app.Watchers.Unwatch(resumed)
+}
+
+func TestApplyBatchSetVersionMismatch(t *testing.T) {
+	app := newActionTestApp(t)
+
+	for _, key := range []string{"a", "b", "d"} {
+		if result := applyTestAction(t, app, actions.NewSetValue("", key, []byte("old"), "", 0, ""), "set-"+key); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	w, _ := app.Watchers.Watch("", "", "")
+	defer app.Watchers.Unwatch(w)
+
+	batch := actions.NewBatchSet("", []*actions.BatchSetValue{
+		{Key: "a", Value: "new", IfMatch: "set-a"},
+		{Key: "c", Value: "new"},
+		{Key: "b", Value: "new", IfMatch: "stale"},
+	}, []string{"d"})
+
+	if result := applyTestAction(t, app, batch, "batch"); errors.Cause(result.Err) != actions.ErrVersionMismatch {
+		t.Fatalf("expected the batch to fail with %q, got %v", actions.ErrVersionMismatch, result.Err)
+	}
+
+	for _, key := range []string{"a", "b", "d"} {
+		if item, ok := app.Cache.ItemForKey(key); !ok || string(item.Value) != "old" || item.Version != "set-"+key {
+			t.Errorf("expected key %q to be left as it was", key)
+		}
+	}
+
+	if _, ok := app.Cache.ItemForKey("c"); ok {
+		t.Error("expected key \"c\" not to be set")
+	}
+
+	expectNoChanges(t, app, w, "batch")
+}
+
+func TestApplyBatchSetQuotaExceeded(t *testing.T) {
+	app := newActionTestApp(t)
+
+	if err := app.Namespaces.Create("ns", 2, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := applyTestAction(t, app, actions.NewSetValue("ns", "a", []byte("old"), "", 0, ""), "set-a"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	w, _ := app.Watchers.Watch("ns", "", "")
+	defer app.Watchers.Unwatch(w)
+
+	// the first new key fits in the quota on its own, but the second doesn't
+	batch := actions.NewBatchSet("ns", []*actions.BatchSetValue{
+		{Key: "a", Value: "new"},
+		{Key: "b", Value: "new"},
+		{Key: "c", Value: "new"},
+	}, nil)
+
+	if result := applyTestAction(t, app, batch, "batch"); errors.Cause(result.Err) != config.ErrQuotaExceeded {
+		t.Fatalf("expected the batch to fail with %q, got %v", config.ErrQuotaExceeded, result.Err)
+	}
+
+	namespace := app.Namespaces.Get("ns")
+
+	if item, ok := namespace.Cache.ItemForKey("a"); !ok || string(item.Value) != "old" {
+		t.Error("expected key \"a\" to be left as it was")
+	}
+
+	for _, key := range []string{"b", "c"} {
+		if _, ok := namespace.Cache.ItemForKey(key); ok {
+			t.Errorf("expected key %q not to be set", key)
+		}
+	}
+
+	if entries, _ := namespace.Usage(); entries != 1 {
+		t.Errorf("expected the namespace to still count 1 entry, got %d", entries)
+	}
+
+	expectNoChanges(t, app, w, "batch")
+}
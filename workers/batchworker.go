@@ -0,0 +1,146 @@
+package workers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+// BatchWorker runs on a goroutine and groups actions submitted close together into a single block
+// while one block is being reserved, proposed and committed, new actions queue up to go into the next one
+func BatchWorker(app *config.App) {
+	if app.Chain == nil {
+		logger.LogError(errors.New("BatchWorker received nil chain, terminating"))
+		os.Exit(1)
+	}
+
+	options, err := config.BatchOptionsFromEnv()
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "BatchWorker failed to BatchOptionsFromEnv, terminating"))
+		os.Exit(1)
+	}
+
+	chain := app.Chain
+
+	logger.LogInfo(fmt.Sprintf("starting batch worker with max size %d and linger %s", options.MaxSize, options.Linger))
+
+	for true {
+		batch := nextBatch(chain.PendingChan, options)
+
+		logger.LogInfo(fmt.Sprintf("BatchWorker got batch of %d actions", len(batch)))
+
+		appliedChan, err := commitBatch(app, batch)
+		if err != nil {
+			logger.LogError(errors.Wrap(err, "BatchWorker failed to commitBatch"))
+
+			for _, pending := range batch {
+				pending.ResultChan <- &blockchain.ActionResult{Err: err}
+			}
+
+			continue
+		}
+
+		// the next batch can be committed while this one is being executed
+		go reportBatchResults(batch, appliedChan)
+	}
+}
+
+// nextBatch waits for an action, then collects more until the batch is full or it has lingered long enough
+func nextBatch(pendingChan chan *blockchain.PendingAction, options *config.BatchOptions) []*blockchain.PendingAction {
+	batch := []*blockchain.PendingAction{<-pendingChan}
+
+	linger := time.After(options.Linger)
+
+	for len(batch) < options.MaxSize {
+		select {
+		case pending := <-pendingChan:
+			batch = append(batch, pending)
+			continue
+		default:
+		}
+
+		select {
+		case pending := <-pendingChan:
+			batch = append(batch, pending)
+		case <-linger:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+// commitBatch puts the actions into a block and adds it to the chain
+// a batch of one is committed as a plain block so that single writes look the same as they always have
+func commitBatch(app *config.App, batch []*blockchain.PendingAction) (chan *blockchain.ActionResult, error) {
+	actionType := batch[0].ActionType
+	actionJSON := batch[0].ActionJSON
+
+	if len(batch) > 1 {
+		batched := make([]*actions.BatchedAction, len(batch))
+		for i, pending := range batch {
+			batched[i] = &actions.BatchedAction{
+				ActionType: pending.ActionType,
+				Action:     json.RawMessage(pending.ActionJSON),
+			}
+		}
+
+		action := actions.NewActionBatch(batched)
+
+		actionType = action.ActionType()
+		actionJSON = action.JSON()
+	}
+
+	block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, actionJSON, actionType)
+	if err != nil {
+		return nil, errors.Wrap(err, "commitBatch failed to NewBlockWithData")
+	}
+
+	errChan, _ := app.Chain.ReserveBlockID(app.Self.NID)
+	if err := <-errChan; err != nil {
+		return nil, errors.Wrap(err, "commitBatch failed to ReserveBlockID")
+	}
+
+	errChan, appliedChan := app.Chain.AddNewBlock(block, app.Self.NID)
+	if err := <-errChan; err != nil {
+		return nil, errors.Wrap(err, "commitBatch failed to AddNewBlock")
+	}
+
+	return appliedChan, nil
+}
+
+// reportBatchResults waits for a committed batch to be executed and sends each action its own result
+func reportBatchResults(batch []*blockchain.PendingAction, appliedChan chan *blockchain.ActionResult) {
+	applied := <-appliedChan
+
+	if len(batch) == 1 {
+		batch[0].ResultChan <- applied
+		return
+	}
+
+	for i, pending := range batch {
+		result := &blockchain.ActionResult{
+			Err: applied.Err,
+		}
+
+		if applied.Err == nil {
+			if i < len(applied.Batch) {
+				result = applied.Batch[i]
+			} else {
+				result.Err = fmt.Errorf("reportBatchResults found no result for action at index %d", i)
+			}
+		}
+
+		result.BlockID = applied.BlockID
+		result.Committed = applied.Committed
+
+		pending.ResultChan <- result
+	}
+}
@@ -0,0 +1,131 @@
+package workers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/pkg/errors"
+)
+
+func newPendingActions(count int) []*blockchain.PendingAction {
+	pending := make([]*blockchain.PendingAction, count)
+
+	for i := range pending {
+		pending[i] = &blockchain.PendingAction{
+			ActionType: "test",
+			ActionJSON: []byte(fmt.Sprintf(`{"i":%d}`, i)),
+			ResultChan: make(chan *blockchain.ActionResult, 1),
+		}
+	}
+
+	return pending
+}
+
+func TestNextBatchMaxSize(t *testing.T) {
+	pendingChan := make(chan *blockchain.PendingAction, 10)
+
+	for _, pending := range newPendingActions(10) {
+		pendingChan <- pending
+	}
+
+	options := &config.BatchOptions{MaxSize: 4, Linger: time.Minute}
+
+	for _, expected := range []int{4, 4} {
+		if batch := nextBatch(pendingChan, options); len(batch) != expected {
+			t.Errorf("expected a batch of %d, got %d", expected, len(batch))
+		}
+	}
+
+	// the last two are all that is left, so the batch is sent once it has lingered
+	options.Linger = 10 * time.Millisecond
+
+	if batch := nextBatch(pendingChan, options); len(batch) != 2 {
+		t.Errorf("expected a batch of 2, got %d", len(batch))
+	}
+}
+
+func TestNextBatchLinger(t *testing.T) {
+	pendingChan := make(chan *blockchain.PendingAction)
+	pending := newPendingActions(3)
+
+	go func() {
+		pendingChan <- pending[0]
+		pendingChan <- pending[1]
+
+		<-time.After(200 * time.Millisecond)
+
+		pendingChan <- pending[2]
+	}()
+
+	batch := nextBatch(pendingChan, &config.BatchOptions{MaxSize: 10, Linger: 50 * time.Millisecond})
+
+	if len(batch) != 2 || batch[0] != pending[0] || batch[1] != pending[1] {
+		t.Errorf("expected the actions sent within the linger in order, got %d", len(batch))
+	}
+
+	if batch := nextBatch(pendingChan, &config.BatchOptions{MaxSize: 10, Linger: time.Millisecond}); len(batch) != 1 || batch[0] != pending[2] {
+		t.Error("expected the action sent after the linger in the next batch")
+	}
+}
+
+func TestReportBatchResults(t *testing.T) {
+	batch := newPendingActions(3)
+	appliedChan := make(chan *blockchain.ActionResult, 1)
+
+	appliedChan <- &blockchain.ActionResult{
+		BlockID:   "block",
+		Committed: true,
+		Batch: []*blockchain.ActionResult{
+			{Value: "first"},
+			{Err: errors.New("failed")},
+		},
+	}
+
+	reportBatchResults(batch, appliedChan)
+
+	first := <-batch[0].ResultChan
+	if first.Value != "first" || first.Err != nil || first.BlockID != "block" || !first.Committed {
+		t.Errorf("expected the first action to get its own result in the committed block, got %+v", first)
+	}
+
+	if second := <-batch[1].ResultChan; second.Err == nil || second.BlockID != "block" {
+		t.Errorf("expected the second action to get its own error, got %+v", second)
+	}
+
+	if third := <-batch[2].ResultChan; third.Err == nil {
+		t.Error("expected an action with no result to get an error")
+	}
+}
+
+func TestReportBatchResultsBlockFailed(t *testing.T) {
+	batch := newPendingActions(2)
+	appliedChan := make(chan *blockchain.ActionResult, 1)
+
+	appliedChan <- &blockchain.ActionResult{Err: errors.New("failed"), BlockID: "block"}
+
+	reportBatchResults(batch, appliedChan)
+
+	for i, pending := range batch {
+		if result := <-pending.ResultChan; result.Err == nil || result.BlockID != "block" {
+			t.Errorf("expected action at index %d to get the block's error, got %+v", i, result)
+		}
+	}
+}
+
+func TestReportBatchResultsSingle(t *testing.T) {
+	batch := newPendingActions(1)
+	appliedChan := make(chan *blockchain.ActionResult, 1)
+
+	applied := &blockchain.ActionResult{Value: "value", BlockID: "block"}
+	appliedChan <- applied
+
+	reportBatchResults(batch, appliedChan)
+
+	// a batch of one is committed as a plain block, so its result is passed on as it is
+	if result := <-batch[0].ResultChan; result != applied {
+		t.Errorf("expected the block's result, got %+v", result)
+	}
+}
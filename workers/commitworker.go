@@ -3,10 +3,12 @@ package workers
 import (
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
 	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/astromechio/astrocache/send"
 	"github.com/pkg/errors"
@@ -38,21 +40,39 @@ func CommitWorker(app *config.App) {
 			continue
 		}
 
-		if err := commitBlock(blockJob, app); err != nil {
+		if err := commitBlock(blockJob, app); err == errDuplicateBlock {
+			// the block was already committed, probably by catching up with the master while it was being distributed
+			// so it must not be executed again, but whoever proposed it is still waiting to hear that it was
+			blockJob.ResultChan <- nil
+
+			if blockJob.AppliedChan != nil {
+				blockJob.AppliedChan <- &blockchain.ActionResult{
+					BlockID:   blockJob.Block.ID,
+					Version:   blockJob.Block.ID,
+					Committed: true,
+				}
+			}
+
+			chain.SetProposed(nil)
+
+			chain.CommittedChan <- nil
+
+			continue
+		} else if err != nil {
 			logger.LogError(errors.Wrap(err, "CommitWorker failed to checkBlock"))
 			blockJob.ResultChan <- errors.Wrap(err, "CommitWorker failed to checkBlock")
 
-			chain.Proposed = nil
+			chain.SetProposed(nil)
 
 			chain.CommittedChan <- nil
-			go loadMissingBlocks(app)
+			go loadMissingBlocks(app, blockJob.Block)
 
 			continue
 		}
 
 		blockJob.ResultChan <- nil
 
-		chain.ActionChan <- blockJob.Block // send the block to be executed
+		chain.ActionChan <- blockJob // send the block to be executed
 
 		logger.LogInfo("CommitWorker completed commit job, reporting committed")
 		chain.CommittedChan <- blockJob.Block // notify other goroutines that something was committed
@@ -60,19 +80,23 @@ func CommitWorker(app *config.App) {
 	}
 }
 
+// errDuplicateBlock is returned by commitBlock when the block is already the last in the chain
+var errDuplicateBlock = errors.New("block is already committed")
+
 func commitBlock(job *blockchain.NewBlockJob, app *config.App) error {
 	chain := app.Chain
 
 	logger.LogInfo(fmt.Sprintf("commitBlock committing block with ID %q", job.Block.ID))
 
-	if chain.Proposed == nil || !job.Block.IsSameAsBlock(chain.Proposed) {
+	proposed := chain.Proposed()
+	if proposed == nil || !job.Block.IsSameAsBlock(proposed) {
 		return fmt.Errorf("commitBlock tried to commit a non-proposed block")
 	}
 
 	last := chain.LastBlock()
 	if last != nil && job.Block.IsSameAsBlock(last) {
 		logger.LogWarn("Tried committing duplicate block, skipping...")
-		return nil
+		return errDuplicateBlock
 	}
 
 	// Verify handles the genesis case
@@ -82,15 +106,32 @@ func commitBlock(job *blockchain.NewBlockJob, app *config.App) error {
 
 	logger.LogInfo(fmt.Sprintf("*** Committing bock with ID %q ***", job.Block.ID))
 
-	chain.Blocks = append(chain.Blocks, chain.Proposed)
+	if err := chain.Commit(proposed); err != nil {
+		return errors.Wrap(err, "commitBlock failed to Commit")
+	}
 
-	chain.Proposed = nil
+	chain.SetProposed(nil)
 
 	return nil
 }
 
-func loadMissingBlocks(app *config.App) {
+// loadingMissing is set while loadMissingBlocks is running, so that a commit failure and a repair don't load the same blocks at once
+var loadingMissing int32
+
+// loadMissingBlocks loads the blocks after our last one from the master, waiting for it to have some if it doesn't yet
+// pending is the block that arrived before the ones it follows, if there is one, and is committed after them
+// since the master may not have it yet, such as when the verifier that sent it has only just been assigned to us
+func loadMissingBlocks(app *config.App, pending *blockchain.Block) {
+	if !atomic.CompareAndSwapInt32(&loadingMissing, 0, 1) {
+		return
+	}
+
+	defer atomic.StoreInt32(&loadingMissing, 0)
+
 	lastBlock := app.Chain.LastBlock()
+	if lastBlock == nil {
+		return
+	}
 
 	logger.LogInfo(fmt.Sprintf("loadMissingBlocks attempting to load missing blocks after %q", lastBlock.ID))
 
@@ -98,9 +139,12 @@ func loadMissingBlocks(app *config.App) {
 
 	for true {
 		var err error
-		missing, err = send.GetBlocksAfter(app.NodeList.Master, lastBlock.ID)
+		// the verifiers redirect to whoever they think is master, for when ours is gone or we don't know of one
+		sources := append([]*model.Node{app.NodeList.CurrentMaster()}, app.NodeList.AllVerifiers()...)
+
+		missing, err = send.GetBlocksAfterFromAny(sources, lastBlock.ID)
 		if err != nil {
-			logger.LogError(errors.Wrap(err, "loadMissingBlocks failed to GetBlocksAfter"))
+			logger.LogError(errors.Wrap(err, "loadMissingBlocks failed to GetBlocksAfterFromAny"))
 			return
 		}
 
@@ -114,6 +158,11 @@ func loadMissingBlocks(app *config.App) {
 	}
 
 	for i := range missing {
+		// blocks can still arrive as usual while we wait for the master
+		if app.Chain.HasCommittedBlock(missing[i]) {
+			continue
+		}
+
 		logger.LogInfo(fmt.Sprintf("loadMissingBlocks loading missing block with ID %q", missing[i].ID))
 
 		errChan := app.Chain.VerifyProposedBlock(missing[i], "")
@@ -124,4 +173,15 @@ func loadMissingBlocks(app *config.App) {
 	}
 
 	logger.LogInfo(fmt.Sprintf("loadMissingBlocks loaded %d missing blocks", len(missing)))
+
+	if pending == nil || app.Chain.HasCommittedBlock(pending) || pending.PrevID != app.Chain.LastBlock().ID {
+		return
+	}
+
+	logger.LogInfo(fmt.Sprintf("loadMissingBlocks loading pending block with ID %q", pending.ID))
+
+	errChan := app.Chain.VerifyProposedBlock(pending, "")
+	if err := <-errChan; err != nil {
+		logger.LogError(errors.Wrap(err, "loadMissingBlocks failed to VerifyProposedBlock for pending block with ID "+pending.ID))
+	}
 }
@@ -0,0 +1,67 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/blockchain"
+)
+
+// a verifier can catch its own block up from the master before it is committed here, and its proposer must still hear back
+func TestCommitWorkerDuplicateBlock(t *testing.T) {
+	master, err := acrypto.GenerateMasterKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalKey, err := acrypto.GenerateGlobalSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := blockchain.BrandNewChain(master, globalKey, []byte("{}"), "test.genesis", blockchain.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &config.App{
+		Self:     &model.Node{NID: "self", Type: model.NodeTypeVerifier},
+		KeySet:   &acrypto.KeySet{KeyPair: master, GlobalKey: globalKey},
+		Chain:    chain,
+		NodeList: &config.NodeList{},
+	}
+
+	go CommitWorker(app)
+
+	last := chain.LastBlock()
+	chain.SetProposed(last)
+
+	resultChan := make(chan error, 1)
+	appliedChan := make(chan *blockchain.ActionResult, 1)
+
+	chain.CommitChan <- &blockchain.NewBlockJob{Block: last, ResultChan: resultChan, AppliedChan: appliedChan}
+
+	if err := <-resultChan; err != nil {
+		t.Fatalf("expected a duplicate block to be reported as committed, got %s", err)
+	}
+
+	select {
+	case applied := <-appliedChan:
+		if applied.BlockID != last.ID || !applied.Committed || applied.Err != nil {
+			t.Errorf("expected block %q to be reported as applied, got %+v", last.ID, applied)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the proposer of a duplicate block to be told it was applied")
+	}
+
+	if committed := <-chain.CommittedChan; committed != nil {
+		t.Errorf("expected a duplicate block not to be reported as newly committed, got %q", committed.ID)
+	}
+
+	if len(chain.Blocks()) != 1 {
+		t.Errorf("expected the chain to still hold 1 block, got %d", len(chain.Blocks()))
+	}
+}
@@ -3,6 +3,9 @@ package workers
 import (
 	"github.com/astromechio/astrocache/config"
 	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
 	"github.com/astromechio/astrocache/send"
 	"github.com/pkg/errors"
 )
@@ -13,14 +16,61 @@ func DistributeWorker(app *config.App) {
 
 	logger.LogInfo("starting distribute worker")
 
+	previous := []*model.Node{}
+
 	for true {
 		block := <-chain.DistributeChan
 
 		// update this every time in case we got a new worker
 		workers := app.NodeList.WorkersForVerifierWithNID(app.Self.NID)
 
+		// a worker removed by this block is no longer in the list, but still needs the block so that it stops serving
+		// an elected master commits removals through the batch worker, so they can be part of a batch
+		if block.ActionType == actions.ActionTypeNodeRemoved || block.ActionType == actions.ActionTypeActionBatch {
+			if removed := missingNodes(previous, workers); len(removed) > 0 {
+				go sendRemovedBlock(app, block, removed)
+			}
+		}
+
+		previous = workers
+
+		// blocks we proposed also go straight to the master, so that it doesn't miss any the primary verifier wasn't part of the quorum for
+		master := app.NodeList.CurrentMaster()
+		if master != nil && master.Type == model.NodeTypeMaster && master.ParentNID != app.Self.NID && block.Signature.KID == app.KeySet.KeyPair.KID {
+			workers = append(workers, master)
+		}
+
 		if err := send.DistributeBlockToWorkers(block, workers, app.Self); err != nil {
 			logger.LogError(errors.Wrap(err, "DistributeWorker failed to DistributeBlockToWorkers"))
 		}
 	}
 }
+
+// sendRemovedBlock sends the block that removed nodes to them, without holding up distribution to the rest if they don't answer
+func sendRemovedBlock(app *config.App, block *blockchain.Block, nodes []*model.Node) {
+	if err := send.DistributeBlockToWorkers(block, nodes, app.Self); err != nil {
+		logger.LogError(errors.Wrap(err, "sendRemovedBlock failed to DistributeBlockToWorkers"))
+	}
+}
+
+// missingNodes returns the nodes in previous that aren't in current
+func missingNodes(previous, current []*model.Node) []*model.Node {
+	missing := []*model.Node{}
+
+	for _, p := range previous {
+		found := false
+
+		for _, c := range current {
+			if c.NID == p.NID {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing
+}
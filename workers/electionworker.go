@@ -0,0 +1,176 @@
+package workers
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/send"
+	"github.com/pkg/errors"
+)
+
+// ElectionWorker runs on a goroutine on verifiers and pings the master, recording when it last responded so that votes can be refused while it is alive
+// the master's last block also shows whether this verifier has fallen behind, in which case the missing blocks are loaded from it
+// if this verifier is master-eligible and the master stops responding, it stands for election and takes over as master if it wins a majority of verifiers
+func ElectionWorker(app *config.App) {
+	options, err := config.ElectionOptionsFromEnv()
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "ElectionWorker failed to ElectionOptionsFromEnv, terminating"))
+		os.Exit(1)
+	}
+
+	app.Election.Timeout = options.Timeout
+	app.Election.Heard()
+
+	interval := options.Timeout / 3
+
+	logger.LogInfo(fmt.Sprintf("starting election worker with timeout %s, master eligible: %t", options.Timeout, options.Eligible))
+
+	// how many pings in a row the master has had a block that we haven't
+	behind := 0
+
+	for true {
+		<-time.After(interval)
+
+		if app.IsMaster() {
+			continue
+		}
+
+		// with no master there is nobody to hear from, so we stand for election once the timeout passes
+		if master := app.NodeList.CurrentMaster(); master != nil {
+			if last, err := send.PingMaster(master, interval); err == nil {
+				app.Election.Heard()
+
+				// a verifier that rejected or missed a block the rest of the quorum accepted finds out here if no later block has shown it already
+				// it has to be behind twice in a row, since the master can see a block just before we finish committing it
+				if app.Chain.BlocksAfterID(last.BlockID) == nil {
+					behind++
+				} else {
+					behind = 0
+				}
+
+				if behind >= 2 {
+					logger.LogWarn(fmt.Sprintf("ElectionWorker found master has block with ID %q that we don't, repairing", last.BlockID))
+					behind = 0
+
+					go loadMissingBlocks(app, nil)
+				}
+
+				continue
+			}
+		}
+
+		// each check waits a random extra amount so that eligible verifiers rarely stand at the same time
+		timeout := options.Timeout + time.Duration(rand.Int63n(int64(options.Timeout)))
+		if !options.Eligible || app.Election.HeardWithin(timeout) {
+			continue
+		}
+
+		if err := standForElection(app, interval); err != nil {
+			logger.LogError(errors.Wrap(err, "ElectionWorker failed to standForElection"))
+		}
+	}
+}
+
+// standForElection starts a new term and asks every other verifier for its vote
+// if a majority of verifiers including this one agree, it becomes master and commits a MasterElected block
+func standForElection(app *config.App, timeout time.Duration) error {
+	term, err := app.Election.StartCandidacy(app.Self.NID)
+	if err != nil {
+		return errors.Wrap(err, "standForElection failed to StartCandidacy")
+	}
+
+	req := &requests.VoteRequest{
+		Term:         term,
+		CandidateNID: app.Self.NID,
+		LastBlockID:  app.Chain.LastBlock().ID,
+		Height:       app.Chain.Height(),
+	}
+
+	sig, err := app.KeySet.KeyPair.Sign(req.SigningBody())
+	if err != nil {
+		return errors.Wrap(err, "standForElection failed to Sign")
+	}
+
+	req.Signature = sig
+
+	voters := app.NodeList.AllVerifiers()
+
+	logger.LogInfo(fmt.Sprintf("standForElection standing for master in term %d, asking %d verifiers", term, len(voters)))
+
+	respChan := make(chan *requests.VoteResponse, len(voters))
+
+	for i := range voters {
+		go func(voter *model.Node) {
+			resp, err := send.RequestVote(req, voter, timeout)
+			if err != nil {
+				logger.LogWarn(fmt.Sprintf("standForElection failed to RequestVote from %s: %s", voter.Address, err.Error()))
+			}
+
+			respChan <- resp
+		}(voters[i])
+	}
+
+	votes := 1
+
+	for range voters {
+		resp := <-respChan
+		if resp == nil {
+			continue
+		}
+
+		newer, err := app.Election.Observe(resp.Term)
+		if err != nil {
+			return errors.Wrap(err, "standForElection failed to Observe")
+		}
+
+		if newer {
+			return fmt.Errorf("standForElection found newer term %d, standing down", resp.Term)
+		}
+
+		if resp.Granted {
+			votes++
+		}
+	}
+
+	if votes*2 <= len(voters)+1 {
+		return fmt.Errorf("standForElection got %d of %d votes in term %d, not a majority", votes, len(voters)+1, term)
+	}
+
+	if app.Election.Term() != term {
+		return fmt.Errorf("standForElection saw a newer term than %d while counting votes, standing down", term)
+	}
+
+	logger.LogInfo(fmt.Sprintf("standForElection won term %d with %d of %d votes, taking over as master", term, votes, len(voters)+1))
+
+	previous := app.NodeList.CurrentMaster()
+
+	// reserving a block ID for the MasterElected block is done by the master, which is now us
+	app.NodeList.SetMaster(app.Self)
+
+	if err := commitMasterElected(app, term); err != nil {
+		app.NodeList.SetMaster(previous)
+		return errors.Wrap(err, "standForElection failed to commitMasterElected")
+	}
+
+	return nil
+}
+
+// commitMasterElected commits the block that tells every other node that we are master
+// it goes through the batch worker like any other action, so that it doesn't race writes for a block ID
+func commitMasterElected(app *config.App, term int) error {
+	action := actions.NewMasterElected(app.Self, term)
+
+	result := <-app.Chain.SubmitAction(action.ActionType(), action.JSON())
+	if result.Err != nil {
+		return errors.Wrap(result.Err, "commitMasterElected failed to SubmitAction")
+	}
+
+	return nil
+}
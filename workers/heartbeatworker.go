@@ -0,0 +1,231 @@
+package workers
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/send"
+	"github.com/pkg/errors"
+)
+
+// HeartbeatWorker runs on a goroutine on the master and every verifier, but only sends heartbeats while this node is master
+// each verifier and worker in the node list is sent a heartbeat every interval, and is suspect as soon as it misses one
+// a node that stays suspect for the grace period is dead, and a NodeRemoved block is committed to evict it from the network
+// any worker left without a verifier, by an eviction or otherwise, is then reassigned to another one, as is the master if it lost its primary verifier
+func HeartbeatWorker(app *config.App) {
+	options, err := config.HeartbeatOptionsFromEnv()
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "HeartbeatWorker failed to HeartbeatOptionsFromEnv, terminating"))
+		os.Exit(1)
+	}
+
+	logger.LogInfo(fmt.Sprintf("starting heartbeat worker with interval %s, eviction grace %s", options.Interval, options.Grace))
+
+	for true {
+		<-time.After(options.Interval)
+
+		if !app.IsMaster() {
+			continue
+		}
+
+		nodes := append(app.NodeList.AllVerifiers(), app.NodeList.AllWorkers()...)
+
+		for _, node := range sendHeartbeats(app, nodes, options) {
+			logger.LogWarn(fmt.Sprintf("HeartbeatWorker evicting node with NID %s at %s, it hasn't responded for %s", node.NID, node.Address, options.Grace))
+
+			if err := evictNode(app, node); err != nil {
+				logger.LogError(errors.Wrap(err, "HeartbeatWorker failed to evictNode"))
+			}
+		}
+
+		reassignOrphans(app)
+
+		if err := ReassignMaster(app, ""); err != nil {
+			logger.LogError(errors.Wrap(err, "HeartbeatWorker failed to ReassignMaster"))
+		}
+	}
+}
+
+// sendHeartbeats sends a heartbeat to every node at once and records which ones responded, returning the nodes that are dead
+func sendHeartbeats(app *config.App, nodes []*model.Node, options *config.HeartbeatOptions) []*model.Node {
+	deadChan := make(chan *model.Node, len(nodes))
+
+	for i := range nodes {
+		go func(node *model.Node) {
+			resp, err := send.SendHeartbeat(app, node, options.Interval)
+			if err == nil && resp.NID != node.NID {
+				err = fmt.Errorf("node at %s responded with NID %s", node.Address, resp.NID)
+			}
+
+			if err == nil {
+				if previous := app.Liveness.Alive(node.NID); previous != config.LivenessAlive {
+					logger.LogInfo(fmt.Sprintf("HeartbeatWorker heard from %s node with NID %s again", previous, node.NID))
+				}
+
+				deadChan <- nil
+				return
+			}
+
+			previous := app.Liveness.State(node.NID)
+
+			state := app.Liveness.Missed(node.NID, options.Grace)
+			if state != previous {
+				logger.LogWarn(fmt.Sprintf("HeartbeatWorker marked node with NID %s %s: %s", node.NID, state, err.Error()))
+			}
+
+			if state != config.LivenessDead {
+				node = nil
+			}
+
+			deadChan <- node
+		}(nodes[i])
+	}
+
+	dead := []*model.Node{}
+
+	for range nodes {
+		if node := <-deadChan; node != nil {
+			dead = append(dead, node)
+		}
+	}
+
+	return dead
+}
+
+// evictNode commits a NodeRemoved block for node, and gives the master a new primary verifier if node was its primary
+func evictNode(app *config.App, node *model.Node) error {
+	pubKey, err := node.KeyPair()
+	if err != nil {
+		return errors.Wrap(err, "evictNode failed to KeyPair")
+	}
+
+	action := actions.NewNodeRemoved(node.NID, pubKey.KID)
+
+	if _, err := CommitMembershipAction(app, action); err != nil {
+		return errors.Wrap(err, "evictNode failed to CommitMembershipAction")
+	}
+
+	if err := ReassignMaster(app, node.NID); err != nil {
+		return errors.Wrap(err, "evictNode failed to ReassignMaster")
+	}
+
+	return nil
+}
+
+// reassignOrphans gives every worker whose verifier is no longer in the network a new one
+// the new verifier distributes blocks to it from then on, and the worker loads the blocks it missed in between from the master
+func reassignOrphans(app *config.App) {
+	for _, worker := range app.NodeList.AllWorkers() {
+		if worker.ParentNID == app.Self.NID || app.NodeList.VerifierWithNID(worker.ParentNID) != nil {
+			continue
+		}
+
+		// an elected master doesn't keep itself in its list of verifiers, but can still look after workers
+		verifier := app.NodeList.RandomVerifier()
+		if verifier == nil && app.Self.Type == model.NodeTypeVerifier {
+			verifier = app.Self
+		}
+
+		if verifier == nil {
+			logger.LogWarn(fmt.Sprintf("HeartbeatWorker found no verifier to reassign worker with NID %s to", worker.NID))
+			return
+		}
+
+		logger.LogInfo(fmt.Sprintf("HeartbeatWorker reassigning orphaned worker with NID %s to verifier with NID %s", worker.NID, verifier.NID))
+
+		action := actions.NewWorkerReassigned(worker.NID, verifier)
+
+		if _, err := CommitMembershipAction(app, action); err != nil {
+			logger.LogError(errors.Wrap(err, "reassignOrphans failed to CommitMembershipAction"))
+		}
+	}
+}
+
+// ReassignMaster gives the original master a new primary verifier if its primary is no longer in the network or is removedNID
+// nothing else distributes every committed block to the master, so without one it falls behind and can't reserve block IDs
+// removedNID is a verifier whose NodeRemoved block was just committed, and may not have been executed yet
+func ReassignMaster(app *config.App, removedNID string) error {
+	verifier := newPrimaryVerifier(app, removedNID)
+	if verifier == nil {
+		return nil
+	}
+
+	logger.LogInfo(fmt.Sprintf("reassigning the master to primary verifier with NID %s", verifier.NID))
+
+	action := actions.NewWorkerReassigned(app.Self.NID, verifier)
+
+	if _, err := CommitMembershipAction(app, action); err != nil {
+		return errors.Wrap(err, "ReassignMaster failed to CommitMembershipAction")
+	}
+
+	return nil
+}
+
+// newPrimaryVerifier returns the verifier the master should be reassigned to, or nil if it doesn't need one or there is none
+// an elected master is a verifier and gets every block without one
+func newPrimaryVerifier(app *config.App, removedNID string) *model.Node {
+	if app.Self.Type != model.NodeTypeMaster || !app.IsMaster() {
+		return nil
+	}
+
+	primaryNID := app.NodeList.PrimaryNID()
+	if primaryNID == "" || (primaryNID != removedNID && app.NodeList.VerifierWithNID(primaryNID) != nil) {
+		return nil
+	}
+
+	candidates := []*model.Node{}
+
+	for _, verifier := range app.NodeList.AllVerifiers() {
+		if verifier.NID != removedNID {
+			candidates = append(candidates, verifier)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// CommitMembershipAction commits a block changing who is in the network, as the master does when nodes join, returning the block's ID
+// an elected master is a verifier, so it goes through the batch worker like any other action rather than racing it for a block ID
+// it returns once the action has been executed, so the caller sees the node list as the block left it
+func CommitMembershipAction(app *config.App, action actions.Action) (string, error) {
+	if app.Self.Type == model.NodeTypeVerifier {
+		result := <-app.Chain.SubmitAction(action.ActionType(), action.JSON())
+		if result.Err != nil {
+			return "", errors.Wrap(result.Err, "CommitMembershipAction failed to SubmitAction")
+		}
+
+		return result.BlockID, nil
+	}
+
+	block, err := blockchain.NewBlockWithData(app.KeySet.GlobalKey, action.JSON(), action.ActionType())
+	if err != nil {
+		return "", errors.Wrap(err, "CommitMembershipAction failed to NewBlockWithData")
+	}
+
+	errChan, _ := app.Chain.ReserveBlockID(app.Self.NID)
+	if err := <-errChan; err != nil {
+		return "", errors.Wrap(err, "CommitMembershipAction failed to ReserveBlockID")
+	}
+
+	errChan, appliedChan := app.Chain.AddNewBlock(block, app.Self.NID)
+	if err := <-errChan; err != nil {
+		return "", errors.Wrap(err, "CommitMembershipAction failed to AddNewBlock")
+	}
+
+	if applied := <-appliedChan; applied.Err != nil {
+		return "", errors.Wrap(applied.Err, "CommitMembershipAction failed to Execute")
+	}
+
+	return block.ID, nil
+}
@@ -0,0 +1,191 @@
+package workers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	acrypto "github.com/astromechio/astrocache/crypto"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/actions"
+	"github.com/astromechio/astrocache/model/blockchain"
+	"github.com/astromechio/astrocache/model/requests"
+)
+
+// newTestNode returns a node of nodeType with a fresh keyPair, so that blocks naming it can be executed
+func newTestNode(t *testing.T, nid, nodeType, address string) (*model.Node, *acrypto.KeyPair) {
+	keyPair, err := acrypto.GenerateNewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &model.Node{NID: nid, Type: nodeType, Address: address, PubKey: keyPair.PubKeyJSON()}
+
+	return node, keyPair
+}
+
+func TestNewPrimaryVerifier(t *testing.T) {
+	master := &model.Node{NID: "master", Type: model.NodeTypeMaster}
+
+	app := &config.App{
+		Self:     master,
+		NodeList: &config.NodeList{Master: master},
+	}
+
+	if verifier := newPrimaryVerifier(app, ""); verifier != nil {
+		t.Errorf("expected a master with no verifiers not to be reassigned, got %q", verifier.NID)
+	}
+
+	app.NodeList.AddVerifier(&model.Node{NID: "primary", Type: model.NodeTypeVerifier})
+	app.NodeList.AddVerifier(&model.Node{NID: "other", Type: model.NodeTypeVerifier})
+
+	if verifier := newPrimaryVerifier(app, ""); verifier != nil {
+		t.Errorf("expected a master whose primary is in the network not to be reassigned, got %q", verifier.NID)
+	}
+
+	// a primary whose NodeRemoved block hasn't been executed yet is passed in, and is never picked again
+	for i := 0; i < 10; i++ {
+		if verifier := newPrimaryVerifier(app, "primary"); verifier == nil || verifier.NID != "other" {
+			t.Fatal("expected a master whose primary is being removed to be reassigned to the other verifier")
+		}
+	}
+
+	app.NodeList.RemoveNode("primary")
+
+	if verifier := newPrimaryVerifier(app, ""); verifier == nil || verifier.NID != "other" {
+		t.Error("expected a master whose primary was removed to be reassigned to the verifier that is left")
+	}
+
+	app.NodeList.ReassignMaster("other")
+
+	if verifier := newPrimaryVerifier(app, ""); verifier != nil {
+		t.Errorf("expected a reassigned master not to be reassigned again, got %q", verifier.NID)
+	}
+
+	// an elected master gets every block as a verifier
+	elected := &model.Node{NID: "other", Type: model.NodeTypeVerifier}
+	app.Self = elected
+	app.NodeList.SetMaster(elected)
+	app.NodeList.RemoveNode("other")
+	app.NodeList.AddVerifier(&model.Node{NID: "last", Type: model.NodeTypeVerifier})
+
+	if verifier := newPrimaryVerifier(app, ""); verifier != nil {
+		t.Errorf("expected an elected master not to be reassigned, got %q", verifier.NID)
+	}
+}
+
+// the verifier the master is reassigned to must send it the blocks committed after its primary was evicted
+func TestDistributeToMasterAfterPrimaryEvicted(t *testing.T) {
+	received := make(chan *blockchain.Block, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &requests.ProposeBlockRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Error(err)
+		}
+
+		received <- req.Block
+
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	self, selfKeyPair := newTestNode(t, "self", model.NodeTypeVerifier, "")
+	primary, primaryKeyPair := newTestNode(t, "primary", model.NodeTypeVerifier, "")
+
+	app := &config.App{
+		Self:     self,
+		KeySet:   &acrypto.KeySet{KeyPair: selfKeyPair},
+		Chain:    blockchain.EmptyChain(),
+		NodeList: &config.NodeList{Master: &model.Node{NID: "master", Type: model.NodeTypeMaster, Address: server.URL}},
+	}
+
+	app.KeySet.AddKeyPair(primaryKeyPair)
+	app.NodeList.AddVerifier(primary)
+
+	evicted := actions.NewNodeRemoved(primary.NID, primaryKeyPair.KID)
+	if err := evicted.Execute(app, &blockchain.Block{ID: "evicted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reassigned := actions.NewWorkerReassigned("master", self)
+	if err := reassigned.Execute(app, &blockchain.Block{ID: "reassigned"}); err != nil {
+		t.Fatal(err)
+	}
+
+	go DistributeWorker(app)
+
+	// a block another verifier proposed, which we would only have sent to the master as its primary
+	app.Chain.DistributeChan <- &blockchain.Block{ID: "committed", Signature: &acrypto.Signature{KID: "proposer"}}
+
+	select {
+	case block := <-received:
+		if block.ID != "committed" {
+			t.Errorf("expected the master to be sent block %q, got %q", "committed", block.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected the master to be sent the block by its new primary verifier")
+	}
+}
+
+// answerReassignments commits every action submitted to chain, sending each WorkerReassigned it gets to reassigned
+func answerReassignments(t *testing.T, chain *blockchain.Chain, reassigned chan *actions.WorkerReassigned) {
+	for pending := range chain.PendingChan {
+		action := &actions.WorkerReassigned{}
+		if err := json.Unmarshal(pending.ActionJSON, action); err != nil || pending.ActionType != actions.ActionTypeWorkerReassigned {
+			t.Errorf("expected a WorkerReassigned action, got %s", pending.ActionType)
+		}
+
+		reassigned <- action
+		pending.ResultChan <- &blockchain.ActionResult{BlockID: "block", Committed: true}
+	}
+}
+
+func TestReassignOrphans(t *testing.T) {
+	self, _ := newTestNode(t, "self", model.NodeTypeVerifier, "")
+	other, _ := newTestNode(t, "other", model.NodeTypeVerifier, "")
+
+	app := &config.App{
+		Self:     self,
+		Chain:    blockchain.EmptyChain(),
+		NodeList: &config.NodeList{},
+	}
+
+	app.NodeList.AddVerifier(other)
+	app.NodeList.AddWorker(&model.Node{NID: "orphan", Type: model.NodeTypeWorker, ParentNID: "removed"})
+	app.NodeList.AddWorker(&model.Node{NID: "ours", Type: model.NodeTypeWorker, ParentNID: self.NID})
+	app.NodeList.AddWorker(&model.Node{NID: "others", Type: model.NodeTypeWorker, ParentNID: other.NID})
+
+	reassigned := make(chan *actions.WorkerReassigned, 10)
+	go answerReassignments(t, app.Chain, reassigned)
+	defer close(app.Chain.PendingChan)
+
+	reassignOrphans(app)
+
+	if len(reassigned) != 1 {
+		t.Fatalf("expected only the orphaned worker to be reassigned, got %d reassignments", len(reassigned))
+	}
+
+	if action := <-reassigned; action.NID != "orphan" || action.Verifier.NID != other.NID {
+		t.Errorf("expected the orphan to be reassigned to the other verifier, got %s to %s", action.NID, action.Verifier.NID)
+	}
+
+	// an elected master isn't in its own list of verifiers, so with no others it adopts the orphan itself
+	app.NodeList.RemoveNode(other.NID)
+	app.NodeList.SetMaster(self)
+
+	reassignOrphans(app)
+
+	if len(reassigned) != 2 {
+		t.Fatalf("expected both workers left without a verifier to be reassigned, got %d reassignments", len(reassigned))
+	}
+
+	for i := 0; i < 2; i++ {
+		if action := <-reassigned; action.Verifier.NID != self.NID {
+			t.Errorf("expected worker %s to be reassigned to the elected master, got %s", action.NID, action.Verifier.NID)
+		}
+	}
+}
@@ -24,6 +24,12 @@ func ProposeWorker(app *config.App) {
 		os.Exit(1)
 	}
 
+	quorum, err := config.QuorumFromEnv()
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "ProposeWorker failed to QuorumFromEnv, terminating"))
+		os.Exit(1)
+	}
+
 	chain := app.Chain
 
 	logger.LogInfo("starting propose worker")
@@ -41,16 +47,21 @@ func ProposeWorker(app *config.App) {
 				blockJob = <-chain.ProposeChan
 				logger.LogInfo("ProposeWorker got proposed block job")
 
-				if err := proposeBlock(blockJob, app); err != nil {
+				if err := proposeBlock(blockJob, app, quorum); err != nil {
 					blockJob.ResultChan <- errors.Wrap(err, "ProposeWorker failed to proposeBlock")
-					chain.Proposed = nil
+					chain.SetProposed(nil)
+
+					// the ReserveWorker is waiting for this block to be committed, so let it know it won't be
+					chain.CommittedChan <- nil
 
 					continue
 				}
 
 				if blockJob.Block.ID != reserveJob.BlockID {
 					blockJob.ResultChan <- fmt.Errorf("ProposeWorker failed after proposeBlock, block.ID did not match reserved.BlockID")
-					chain.Proposed = nil
+					chain.SetProposed(nil)
+
+					chain.CommittedChan <- nil
 
 					continue
 				}
@@ -61,16 +72,16 @@ func ProposeWorker(app *config.App) {
 				if reserveJob != nil && blockJob.Block.ID != reserveJob.BlockID {
 					logger.LogError(fmt.Errorf("ProposeWorker failed before verifyBlock, block.ID did not match reserved.BlockID"))
 					blockJob.ResultChan <- fmt.Errorf("ProposeWorker failed before verifyBlock, block.ID did not match reserved.BlockID")
-					chain.Proposed = nil
+					chain.SetProposed(nil)
 
 					continue
 				}
 
-				chain.Proposed = blockJob.Block
+				chain.SetProposed(blockJob.Block)
 			}
 		} else {
 			blockJob = <-chain.VerifyChan
-			chain.Proposed = blockJob.Block
+			chain.SetProposed(blockJob.Block)
 		}
 
 		// Send the block to be committed
@@ -93,7 +104,7 @@ func ProposeWorker(app *config.App) {
 	}
 }
 
-func proposeBlock(job *blockchain.NewBlockJob, app *config.App) error {
+func proposeBlock(job *blockchain.NewBlockJob, app *config.App, quorum *config.Quorum) error {
 	chain := app.Chain
 
 	prevBlock := chain.LastBlock()
@@ -106,11 +117,11 @@ func proposeBlock(job *blockchain.NewBlockJob, app *config.App) error {
 		return errors.Wrap(err, "proposeBlock failed to Verify")
 	}
 
-	if err := send.ProposeBlockToVerifiers(job.Block, app.NodeList.Verifiers, app.Self); err != nil {
+	if err := send.ProposeBlockToVerifiers(job.Block, app.NodeList.AllVerifiers(), app.Self, quorum); err != nil {
 		return errors.Wrap(err, "proposeBlock failed to ProposeBlockToVerifiers")
 	}
 
-	chain.Proposed = job.Block
+	chain.SetProposed(job.Block)
 
 	return nil
 }
@@ -10,7 +10,6 @@ import (
 	"github.com/astromechio/astrocache/config"
 	acrypto "github.com/astromechio/astrocache/crypto"
 	"github.com/astromechio/astrocache/logger"
-	"github.com/astromechio/astrocache/model"
 	"github.com/astromechio/astrocache/send"
 	"github.com/pkg/errors"
 )
@@ -37,8 +36,15 @@ func ReserveWorker(app *config.App) {
 			continue
 		}
 
-		if app.Self.Type == model.NodeTypeVerifier {
-			reservedID, err := send.RequestReservedID(app.NodeList.Master, app.Self.NID)
+		// whichever node is master hands out block IDs, which can be a verifier once one has been elected
+		if !app.IsMaster() {
+			master := app.NodeList.CurrentMaster()
+			if master == nil {
+				reserveJob.ResultChan <- errors.New("ReserveWorker has no master to reserve a block ID with")
+				continue
+			}
+
+			reservedID, err := send.RequestReservedID(master, app.Self.NID)
 			if err != nil {
 				logger.LogError(errors.Wrap(err, "ReserveWorker failed to RequesReservedID"))
 				reserveJob.ResultChan <- errors.Wrap(err, "ReserveWorker failed to RequesReservedID")
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+)
+
+const sweepInterval = time.Second * 5
+
+// SweepWorker periodically removes values that expired before the last applied action from the cache to reclaim their memory
+// expired values are already hidden from reads, so this only needs to run occasionally
+// values are only removed once they expired before the time the last applied action was executed as of, rather than now,
+// since a block applied after this may still see them alive, and every node must give it the same answer
+// that time only moves as blocks are applied, so on a cache that isn't being written to, expired values keep their memory until the next write
+func SweepWorker(app *config.App) {
+	logger.LogInfo("starting sweep worker")
+
+	for true {
+		<-time.After(sweepInterval)
+
+		if removed := sweepExpired(app); removed > 0 {
+			logger.LogInfo(fmt.Sprintf("SweepWorker removed %d expired values", removed))
+		}
+	}
+}
+
+// sweepExpired removes the values in every cache that expired before the app's clock, and returns the number removed
+func sweepExpired(app *config.App) int {
+	now := app.Clock.Now()
+	if now.IsZero() {
+		return 0
+	}
+
+	removed := app.Cache.Sweep(now)
+	for _, ns := range app.Namespaces.All() {
+		removed += ns.Cache.Sweep(now)
+	}
+
+	return removed
+}
@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astromechio/astrocache/model/actions"
+)
+
+// values are only swept once a block has been applied after they expired, however long ago that was
+func TestSweepExpiredFollowsClock(t *testing.T) {
+	app := newActionTestApp(t)
+
+	start := time.Now().Add(-time.Hour)
+
+	set := actions.NewSetValue("", "expired", []byte("value"), "", 1, "")
+	set.Timestamp = start.UnixNano()
+
+	if result := applyTestAction(t, app, set, "set"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if _, ok := app.Cache.ItemForKey("expired"); ok {
+		t.Fatal("expected an expired value to be hidden from reads before it is swept")
+	}
+
+	// the value expired an hour ago by the wall clock, but no block has been applied since, so it keeps its memory
+	if removed := sweepExpired(app); removed != 0 || app.Cache.Stats().Entries != 1 {
+		t.Errorf("expected nothing to be swept while the cache is idle, removed %d", removed)
+	}
+
+	later := actions.NewSetValue("", "other", []byte("value"), "", 0, "")
+	later.Timestamp = start.Add(2 * time.Second).UnixNano()
+
+	if result := applyTestAction(t, app, later, "later"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if removed := sweepExpired(app); removed != 1 {
+		t.Errorf("expected the value to be swept once a block was applied after it expired, removed %d", removed)
+	}
+}
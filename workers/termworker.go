@@ -0,0 +1,168 @@
+package workers
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/astromechio/astrocache/config"
+	"github.com/astromechio/astrocache/logger"
+	"github.com/astromechio/astrocache/model"
+	"github.com/astromechio/astrocache/model/requests"
+	"github.com/astromechio/astrocache/send"
+	"github.com/pkg/errors"
+)
+
+// termKeep and others are what the original master does after asking the verifiers about the current term
+const (
+	termKeep     = iota // nothing has changed
+	termStepDown        // a verifier is in a newer term or follows another master
+	termReclaim         // a majority of verifiers still follow us in our term
+)
+
+// TermWorker runs on a goroutine on the original master, which can't stand for election, and asks the verifiers which term they are in
+// if any of them has moved on to a newer term or another master, we stop acting as master and catch up on the blocks that say who replaced us
+// a master that was restarted comes back without a master, and only takes over again once a majority of verifiers confirm nobody replaced it
+func TermWorker(app *config.App) {
+	options, err := config.ElectionOptionsFromEnv()
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "TermWorker failed to ElectionOptionsFromEnv, terminating"))
+		os.Exit(1)
+	}
+
+	interval := options.Timeout / 3
+
+	logger.LogInfo(fmt.Sprintf("starting term worker with interval %s", interval))
+
+	for true {
+		<-time.After(interval)
+
+		verifiers := app.NodeList.AllVerifiers()
+		statuses := electionStatuses(verifiers, interval)
+
+		// nobody distributes blocks to a master that has been replaced or restarted, so it catches up with the verifiers before deciding anything
+		// the blocks it missed may include the one electing its replacement, and a reclaimed master must not reserve IDs the verifiers have already committed
+		if !app.IsMaster() {
+			if ahead := furthestAhead(statuses, app.Chain.Height()); ahead != nil {
+				catchUpFromVerifier(app, app.NodeList.VerifierWithNID(ahead.NID))
+				continue
+			}
+		}
+
+		decision, newest := decideTerm(app.Self.NID, app.Election.Term(), statuses, len(verifiers))
+
+		switch decision {
+		case termStepDown:
+			if app.IsMaster() {
+				logger.LogWarn(fmt.Sprintf("TermWorker found a verifier in term %d or following another master, stepping down", newest))
+				app.NodeList.SetMaster(nil)
+			}
+
+			if _, err := app.Election.Observe(newest); err != nil {
+				logger.LogError(errors.Wrap(err, "TermWorker failed to Observe"))
+			}
+
+		case termReclaim:
+			if app.NodeList.CurrentMaster() == nil {
+				logger.LogInfo(fmt.Sprintf("TermWorker found a majority of verifiers still follow this node in term %d, taking over as master again", app.Election.Term()))
+				app.NodeList.SetMaster(app.Self)
+			}
+		}
+	}
+}
+
+// decideTerm decides what the original master with selfNID in term does, given the status of the total verifiers that answered
+// it steps down if any verifier is in a newer term or follows another master, and reclaims the role once a majority follow it
+// a verifier that voted for someone else in our term isn't counted, since that candidate may be about to win
+func decideTerm(selfNID string, term int, statuses []*requests.ElectionStatusResponse, total int) (int, int) {
+	newest := term
+	following := 0
+
+	for _, status := range statuses {
+		if status.Term > newest {
+			newest = status.Term
+		}
+
+		if status.MasterNID != "" && status.MasterNID != selfNID {
+			return termStepDown, newest
+		}
+
+		if status.Term <= term && status.MasterNID == selfNID && (status.VotedFor == "" || status.VotedFor == selfNID) {
+			following++
+		}
+	}
+
+	if newest > term {
+		return termStepDown, newest
+	}
+
+	if following*2 > total || total == 0 {
+		return termReclaim, newest
+	}
+
+	return termKeep, newest
+}
+
+// electionStatuses asks every verifier for its election status at once, returning those that answered within timeout
+func electionStatuses(verifiers []*model.Node, timeout time.Duration) []*requests.ElectionStatusResponse {
+	statusChan := make(chan *requests.ElectionStatusResponse, len(verifiers))
+
+	for i := range verifiers {
+		go func(verifier *model.Node) {
+			status, err := send.GetElectionStatus(verifier, timeout)
+			if err != nil {
+				logger.LogWarn(fmt.Sprintf("electionStatuses failed to GetElectionStatus from %s: %s", verifier.Address, err.Error()))
+			}
+
+			statusChan <- status
+		}(verifiers[i])
+	}
+
+	statuses := []*requests.ElectionStatusResponse{}
+
+	for range verifiers {
+		if status := <-statusChan; status != nil {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// furthestAhead returns the status of the verifier that has committed the most blocks, if it has committed more than height
+func furthestAhead(statuses []*requests.ElectionStatusResponse, height int) *requests.ElectionStatusResponse {
+	var ahead *requests.ElectionStatusResponse
+
+	for _, status := range statuses {
+		if status.Height > height && (ahead == nil || status.Height > ahead.Height) {
+			ahead = status
+		}
+	}
+
+	return ahead
+}
+
+// catchUpFromVerifier loads the blocks after our last one from the chain the verifier has committed
+func catchUpFromVerifier(app *config.App, verifier *model.Node) {
+	if verifier == nil {
+		return
+	}
+
+	last := app.Chain.LastBlock()
+
+	blocks, err := send.GetCommittedBlocksAfter(verifier, last.ID)
+	if err != nil {
+		logger.LogError(errors.Wrap(err, "catchUpFromVerifier failed to GetCommittedBlocksAfter"))
+		return
+	}
+
+	if len(blocks) == 0 {
+		return
+	}
+
+	logger.LogInfo(fmt.Sprintf("catchUpFromVerifier catching up on %d blocks after %q from %s", len(blocks), last.ID, verifier.Address))
+
+	if err := app.Chain.AppendBlocks(blocks); err != nil {
+		logger.LogError(errors.Wrap(err, "catchUpFromVerifier failed to AppendBlocks"))
+	}
+}
@@ -0,0 +1,106 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/astromechio/astrocache/model/requests"
+)
+
+func TestDecideTerm(t *testing.T) {
+	tests := []struct {
+		name     string
+		term     int
+		statuses []*requests.ElectionStatusResponse
+		total    int
+		decision int
+		newest   int
+	}{
+		{
+			name:     "no verifiers",
+			term:     0,
+			total:    0,
+			decision: termReclaim,
+		},
+		{
+			name: "majority still follows us",
+			term: 2,
+			statuses: []*requests.ElectionStatusResponse{
+				{Term: 2, MasterNID: "self"},
+				{Term: 2, MasterNID: "self"},
+			},
+			total:    3,
+			decision: termReclaim,
+			newest:   2,
+		},
+		{
+			name: "only a minority answered",
+			term: 2,
+			statuses: []*requests.ElectionStatusResponse{
+				{Term: 2, MasterNID: "self"},
+			},
+			total:    3,
+			decision: termKeep,
+			newest:   2,
+		},
+		{
+			name: "a verifier follows another master",
+			term: 2,
+			statuses: []*requests.ElectionStatusResponse{
+				{Term: 2, MasterNID: "self"},
+				{Term: 2, MasterNID: "self"},
+				{Term: 3, MasterNID: "other"},
+			},
+			total:    3,
+			decision: termStepDown,
+			newest:   3,
+		},
+		{
+			name: "a candidate is in a newer term",
+			term: 2,
+			statuses: []*requests.ElectionStatusResponse{
+				{Term: 2, MasterNID: "self"},
+				{Term: 2, MasterNID: "self"},
+				{Term: 3, MasterNID: "self", VotedFor: "candidate"},
+			},
+			total:    3,
+			decision: termStepDown,
+			newest:   3,
+		},
+		{
+			name: "votes for someone else aren't counted",
+			term: 2,
+			statuses: []*requests.ElectionStatusResponse{
+				{Term: 2, MasterNID: "self", VotedFor: "candidate"},
+				{Term: 2, MasterNID: "self", VotedFor: "candidate"},
+				{Term: 2, MasterNID: "self"},
+			},
+			total:    3,
+			decision: termKeep,
+			newest:   2,
+		},
+	}
+
+	for _, test := range tests {
+		decision, newest := decideTerm("self", test.term, test.statuses, test.total)
+
+		if decision != test.decision || newest != test.newest {
+			t.Errorf("%s: expected decision %d in term %d, got %d in term %d", test.name, test.decision, test.newest, decision, newest)
+		}
+	}
+}
+
+func TestFurthestAhead(t *testing.T) {
+	statuses := []*requests.ElectionStatusResponse{
+		{NID: "behind", Height: 3},
+		{NID: "ahead", Height: 6},
+		{NID: "level", Height: 5},
+	}
+
+	if ahead := furthestAhead(statuses, 5); ahead == nil || ahead.NID != "ahead" {
+		t.Errorf("expected the verifier at height 6 to be furthest ahead, got %v", ahead)
+	}
+
+	if ahead := furthestAhead(statuses, 6); ahead != nil {
+		t.Errorf("expected no verifier to be ahead of height 6, got %s", ahead.NID)
+	}
+}